@@ -0,0 +1,201 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// AllOptions configures how RunAll runs a command across interpreters.
+type AllOptions struct {
+	Spec     *interpreter.Spec // Only run interpreters matching this, nil means every interpreter
+	Parallel bool              // Run all the interpreters at once rather than one after the other
+}
+
+// allResult is the outcome of running a command with a single interpreter.
+type allResult struct {
+	python   interpreter.Interpreter // The interpreter that was run
+	label    string                  // The prefix used for it's output
+	exitCode int                     // The exit code, -1 if it couldn't be started
+	err      error                   // Why it couldn't be started, if it couldn't
+}
+
+// RunAll runs python with 'args' once for every interpreter on $PATH (or those matching opts.Spec),
+// prefixing each line of output with the interpreter it came from, then prints a summary
+// table of the exit codes.
+//
+// An error is returned if any of the runs failed.
+func (a *App) RunAll(opts AllOptions, args []string) error {
+	interpreters, err := a.find(opts.Spec)
+	if err != nil {
+		return err
+	}
+
+	labels := allLabels(interpreters)
+
+	if a.Explain {
+		for _, python := range interpreters {
+			fmt.Fprintf(a.Stdout, "Would run: %s\n", strings.Join(append([]string{python.Path}, args...), " "))
+		}
+		return nil
+	}
+
+	// Output from parallel runs is interleaved line by line, so guard the real writers
+	stdout := &lockedWriter{w: a.Stdout}
+	stderr := &lockedWriter{w: a.Stderr}
+
+	results := make([]allResult, len(interpreters))
+	var wg sync.WaitGroup
+	for i, python := range interpreters {
+		run := func(i int, python interpreter.Interpreter) {
+			results[i] = a.runOne(python, labels[i], args, stdout, stderr)
+		}
+
+		if !opts.Parallel {
+			run(i, python)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, python interpreter.Interpreter) {
+			defer wg.Done()
+			run(i, python)
+		}(i, python)
+	}
+	wg.Wait()
+
+	return a.summarise(results)
+}
+
+// runOne runs the interpreter 'python' with 'args', writing it's output line by line
+// to 'stdout' and 'stderr' prefixed with 'label'.
+func (a *App) runOne(python interpreter.Interpreter, label string, args []string, stdout, stderr io.Writer) allResult {
+	a.Logger.Debug("Running interpreter", LogKeyInterpreter, python.Path)
+
+	prefix := fmt.Sprintf("[%s] ", label)
+	out := &prefixWriter{w: stdout, prefix: prefix}
+	errOut := &prefixWriter{w: stderr, prefix: prefix}
+
+	cmd := exec.Command(python.Path, args...)
+	cmd.Stdout = out
+	cmd.Stderr = errOut
+	err := cmd.Run()
+
+	out.Flush()
+	errOut.Flush()
+
+	result := allResult{python: python, label: label}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		result.exitCode = 0
+	case errors.As(err, &exitErr):
+		result.exitCode = exitErr.ExitCode()
+	default:
+		result.exitCode = -1
+		result.err = err
+	}
+
+	return result
+}
+
+// summarise prints a table of the exit code of each run in 'results', returning
+// an error if any of them failed.
+func (a *App) summarise(results []allResult) error {
+	fmt.Fprintln(a.Stdout)
+	table := tabwriter.NewWriter(a.Stdout, 0, 0, 2, ' ', 0) //nolint: mnd // Padding between columns
+	fmt.Fprintln(table, "INTERPRETER\tVERSION\tEXIT")
+
+	failed := 0
+	for _, result := range results {
+		status := fmt.Sprintf("%d", result.exitCode)
+		if result.err != nil {
+			status = fmt.Sprintf("could not run: %v", result.err)
+		}
+		if result.exitCode != 0 {
+			failed++
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\n", result.label, result.python.Version(), status)
+	}
+
+	if err := table.Flush(); err != nil {
+		return fmt.Errorf("could not write summary: %w", err)
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d interpreters failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// allLabels returns the label used to prefix the output of each of 'interpreters', which
+// is the executable name (e.g. python3.12) unless that's ambiguous, in which case it's the full path.
+func allLabels(interpreters []interpreter.Interpreter) []string {
+	counts := make(map[string]int)
+	for _, python := range interpreters {
+		counts[filepath.Base(python.Path)]++
+	}
+
+	labels := make([]string, 0, len(interpreters))
+	for _, python := range interpreters {
+		label := filepath.Base(python.Path)
+		if counts[label] > 1 {
+			label = python.Path
+		}
+		labels = append(labels, label)
+	}
+
+	return labels
+}
+
+// lockedWriter serialises writes to w so it can be shared between goroutines.
+type lockedWriter struct {
+	w  io.Writer  // The writer being protected
+	mu sync.Mutex // Guards w
+}
+
+// Write writes p to the underlying writer, holding the lock.
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// prefixWriter writes complete lines to w, each starting with prefix. Partial
+// lines are held back until they're completed or the writer is flushed.
+type prefixWriter struct {
+	w      io.Writer    // Where the prefixed lines go
+	prefix string       // What to put at the start of every line
+	buf    bytes.Buffer // Any incomplete line waiting on a newline
+}
+
+// Write buffers p and writes out any complete lines, always reporting all of p as written
+// as a failed write to the underlying writer shouldn't kill the python process writing to us.
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf.Write(b)
+	for {
+		i := bytes.IndexByte(p.buf.Bytes(), '\n')
+		if i == -1 {
+			return len(b), nil
+		}
+		line := p.buf.Next(i + 1)
+		fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+	}
+}
+
+// Flush writes out any incomplete line left in the buffer, adding a newline.
+func (p *prefixWriter) Flush() {
+	if p.buf.Len() != 0 {
+		fmt.Fprintf(p.w, "%s%s\n", p.prefix, p.buf.Bytes())
+		p.buf.Reset()
+	}
+}
@@ -0,0 +1,152 @@
+package cli //nolint: testpackage // Need access to internals
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// fakeAllPython is a stand in python that prints it's name and arguments, exits
+// with a non-zero status if it's a 3.9 and writes a partial line to stderr otherwise.
+const fakeAllPython = `#!/bin/sh
+name="$(basename "$0")"
+echo "$name $*"
+case "$name" in
+python3.9) exit 3 ;;
+*) printf "no newline" >&2 ;;
+esac
+`
+
+func TestApp_RunAll(t *testing.T) {
+	dir := fakePythons(t, t.TempDir(), fakeAllPython, "python3.12", "python3.11", "python3.9")
+	major3 := interpreter.Spec{Major: 3, Minor: interpreter.Any, Patch: interpreter.Any}
+	exact := interpreter.Spec{Major: 3, Minor: 11, Patch: interpreter.Any}
+
+	tests := []struct {
+		name       string
+		spec       *interpreter.Spec
+		parallel   bool
+		wantStdout []string
+		wantStderr []string
+		wantErr    bool
+	}{
+		{
+			name: "all in order",
+			spec: nil,
+			wantStdout: []string{
+				"[python3.12] python3.12 -c pass",
+				"[python3.11] python3.11 -c pass",
+				"[python3.9] python3.9 -c pass",
+				"",
+				"INTERPRETER  VERSION  EXIT",
+				"python3.12   3.12     0",
+				"python3.11   3.11     0",
+				"python3.9    3.9      3",
+			},
+			wantStderr: []string{"[python3.12] no newline", "[python3.11] no newline"},
+			wantErr:    true,
+		},
+		{
+			name: "filtered by exact spec",
+			spec: &exact,
+			wantStdout: []string{
+				"[python3.11] python3.11 -c pass",
+				"",
+				"INTERPRETER  VERSION  EXIT",
+				"python3.11   3.11     0",
+			},
+			wantStderr: []string{"[python3.11] no newline"},
+			wantErr:    false,
+		},
+		{
+			name:     "parallel",
+			spec:     &major3,
+			parallel: true,
+			wantStdout: []string{
+				"",
+				"INTERPRETER  VERSION  EXIT",
+				"[python3.11] python3.11 -c pass",
+				"[python3.12] python3.12 -c pass",
+				"[python3.9] python3.9 -c pass",
+				"python3.11   3.11     0",
+				"python3.12   3.12     0",
+				"python3.9    3.9      3",
+			},
+			wantStderr: []string{"[python3.11] no newline", "[python3.12] no newline"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := newTestApp(stdout, stderr, dir)
+
+			err := app.RunAll(AllOptions{Spec: tt.spec, Parallel: tt.parallel}, []string{"-c", "pass"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunAll() err = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			gotStdout := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+			gotStderr := strings.Split(strings.TrimSuffix(stderr.String(), "\n"), "\n")
+
+			// Parallel output comes in any order but every line must be intact
+			if tt.parallel {
+				slices.Sort(gotStdout)
+				slices.Sort(gotStderr)
+			}
+
+			if !reflect.DeepEqual(gotStdout, tt.wantStdout) {
+				t.Errorf("wrong stdout\ngot:  %#v\nwant: %#v", gotStdout, tt.wantStdout)
+			}
+
+			if !reflect.DeepEqual(gotStderr, tt.wantStderr) {
+				t.Errorf("wrong stderr\ngot:  %#v\nwant: %#v", gotStderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestApp_RunAllNoMatch(t *testing.T) {
+	app := newTestApp(&bytes.Buffer{}, &bytes.Buffer{}, fakePythons(t, t.TempDir(), fakeAllPython, "python3.12"))
+	spec := interpreter.Spec{Major: 3, Minor: 8, Patch: interpreter.Any}
+
+	if err := app.RunAll(AllOptions{Spec: &spec}, []string{"-c", "pass"}); err == nil {
+		t.Error("expected an error when no interpreter matches, got nil")
+	}
+}
+
+func Test_allLabels(t *testing.T) {
+	interpreters := []interpreter.Interpreter{
+		{Path: "/usr/bin/python3.12"},
+		{Path: "/usr/local/bin/python3.12"},
+		{Path: "/usr/bin/python3.11"},
+	}
+
+	want := []string{"/usr/bin/python3.12", "/usr/local/bin/python3.12", "python3.11"}
+	if got := allLabels(interpreters); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
+
+func Test_prefixWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := &prefixWriter{w: out, prefix: "[py] "}
+
+	for _, chunk := range []string{"one\ntw", "o\n", "", "three\nfo", "ur"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write() returned an unexpected error: %v", err)
+		}
+	}
+	w.Flush()
+
+	want := "[py] one\n[py] two\n[py] three\n[py] four\n"
+	if got := out.String(); got != want {
+		t.Errorf("got %q, wanted %q", got, want)
+	}
+}
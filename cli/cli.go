@@ -3,28 +3,29 @@
 package cli
 
 import (
-	"bufio"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
 
 	"github.com/FollowTheProcess/py/interpreter"
-	"github.com/sirupsen/logrus"
+	"github.com/FollowTheProcess/py/resolve"
 )
 
 var (
-	version    = "dev"                            // py version, set at compile time by ldflags
-	commit     = ""                               // py version's commit hash, set at compile time by ldflags
-	majorRegex = regexp.MustCompile(`^\d+$`)      // The regex for a single major version specifier in string form e.g "3"
-	exactRegex = regexp.MustCompile(`^\d+\.\d+$`) // The regex for an exact version specifier in string form e.g "3.9"
-	helpText   = fmt.Sprintf(`
+	version  = "dev" // py version, set at compile time by ldflags
+	commit   = ""    // py version's commit hash, set at compile time by ldflags
+	date     = ""    // py version's build date, set at compile time by ldflags
+	helpText = fmt.Sprintf(`
 Python launcher for Unix (The experimental Go port!)
 
 Version: %s
@@ -41,7 +42,7 @@ want to use by looking in a few different places:
 1) Passed version as an argument
 2) An activated virtual environment
 3) A virtual environment in the current directory
-4) The shebang of the target file (if relevant)
+4) The inline metadata or shebang of the target script (if relevant)
 5) The latest version of python on $PATH
 
 The full control flow can be found in the documentation.
@@ -51,7 +52,11 @@ it will exit with an error message.
 
 Usage:
 
-	py [args] [flags]
+	py [flags] [specifier] [--] [python args]
+
+py's own flags and version specifier must come first, in any order, anything after them
+(or after a "--") is passed to python. --help and --version after a version specifier are
+python's e.g. "py -3.12 --version" is python 3.12's version.
 
 Examples:
 
@@ -64,56 +69,144 @@ $ py -3
 # Launch a specific version on $PATH
 $ py -3.10
 
+# Launch a specific patch release on $PATH
+$ py -3.12.4
+
+# Launch a free-threaded (or debug with "d") build
+$ py -3.13t
+
+# Launch a 64 (or 32) bit build
+$ py -3.12-64
+
+# Launch an alternative implementation (pypy, graalpy or pyston)
+$ py --impl pypy -3.10
+$ py pypy3.10
+
 # Can use normal python flags
 $ py -m venv .venv
 
-# List all found interpreters
+# Create a .venv (or any path) using .python-version, pyproject.toml requires-python or a specifier
+$ py --venv
+$ py --venv -3.12 --upgrade-pip env
+$ py --venv --force
+
+# Run something with every python on $PATH (or every 3.x, in parallel)
+$ py --all -c "import sys; print(sys.version)"
+$ py --all --parallel -3 -m pytest
+
+# Pick which python (or virtual environment) to launch from a list, and remember it for the next --pick
+$ py --pick
+$ py --pick --remember script.py
+
+# See why py would pick the python it does
+$ py --explain script.py
+
+# List all found interpreters (or just the 3.12s, as JSON)
 $ py --list
+$ py --list -3.12 --json
+
+# Include the symlinks to each one
+$ py --list --verbose
+
+# Enable shell completions (bash, zsh or fish)
+$ source <(py --completions bash)
 
 Flags:
-	--help      Help for py
-	--list      List all found python interpreters on $PATH
+	--help         Help for py
+	--list         List all found python interpreters on $PATH, optionally matching a specifier
+	--json         Print --list or --version as JSON
+	--verbose      Show the other paths (e.g. symlinks) to each interpreter in --list
+	--impl         Launch a specific python implementation e.g. pypy (default prefers cpython)
+	--pre          Allow pre-release pythons (alpha, beta, rc) to be selected as the latest
+	--doctor       Check $PATH, virtual environments and version pins for problems, exits non-zero on errors
+	--venv         Create a virtual environment (default .venv) with the python py finds, see below
+	--all          Run python with the following arguments once for every interpreter found, see below
+	--pick         Pick the python to launch from a list, --remember selects it by default next time you --pick
+	--explain      Explain how the python would be found and print it, rather than launching it
+	--completions  Print the completion script for a shell: bash, zsh or fish
+	--version      Print py's version, commit and build info (python's version is py -V)
 
 Environment Variables:
-	PY_PYTHON        The version of python you wish to be the default (e.g. "3.10")
-	PYLAUNCH_DEBUG   If set to anything will print debug information to stderr
+	PY_PYTHON          The version of python you wish to be the default (e.g. "3.10")
+	PYLAUNCH_DEBUG     If set to anything will print debug information to stderr, as JSON lines if set to "json"
+	PY_PRERELEASE      If set to "1" or "true" allows pre-release pythons to be selected, like --pre
+	PY_SCRIPT_VENV     If set to "1" or "true" installs script dependencies into a cached venv
+	PY_SHEBANG_POLICY  What to do if a shebang's python is missing: "strict" (error), "warn" or "nearest"
 	`, version, commit)
 )
 
 const (
-	vitualEnvKey   = "VIRTUAL_ENV"    // The key for the python activated venv environment variable
-	debugEnvKey    = "PYLAUNCH_DEBUG" // The key for the env variable to trigger verbose logging
-	pyPythonEnvKey = "PY_PYTHON"      // The key for py's default python environment variable
-
-	xYParts = 2 // Number of parts in an X.Y version specifier
-	xParts  = 1 // Number of parts in an X version specifier
-
+	vitualEnvKey     = resolve.EnvVirtualEnv    // The key for the python activated venv environment variable
+	debugEnvKey      = "PYLAUNCH_DEBUG"         // The key for the env variable to trigger verbose logging
+	pyPythonEnvKey   = resolve.EnvPyPython      // The key for py's default python environment variable
+	preReleaseEnvKey = resolve.EnvPreRelease    // The key for the env variable allowing pre-release pythons to be selected
+	shebangPolicyKey = resolve.EnvShebangPolicy // The key for the env variable setting what happens when a shebang version is missing
 )
 
 // App represents the py program.
 type App struct {
-	Stdout io.Writer      // Normal CLI output
-	Stderr io.Writer      // Where the logger and errors will write to
-	Logger *logrus.Logger // The debug logger
-	Path   string         // The path to search through i.e. $PATH, passable field to facilitate testing
+	Stdin      io.Reader    // Where interactive input (e.g. py --pick) is read from, nil means os.Stdin
+	Stdout     io.Writer    // Normal CLI output
+	Stderr     io.Writer    // Where the logger and errors will write to
+	Logger     *slog.Logger // The debug logger
+	Path       string       // The path to search through i.e. $PATH, passable field to facilitate testing
+	PreRelease bool         // Whether pre-release (alpha, beta, rc) pythons may be selected as the latest
+	ScriptVenv bool         // Whether to install script dependencies from inline metadata into a cached venv
+	CacheDir   string       // Where py keeps it's cached files e.g. script venvs
+
+	// Getenv looks up environment variables e.g. $VIRTUAL_ENV, nil means os.Getenv
+	Getenv func(key string) string
+
+	// Dir is the working directory, where virtual environments, project files and scripts
+	// are looked for. Empty means the working directory of the process
+	Dir string
+
+	// ShebangPolicy decides what happens when the version a shebang asks for isn't installed
+	// one of "strict" (the default), "warn" or "nearest"
+	ShebangPolicy string
+
+	Explain bool // Explain the decisions made on the way to an interpreter, and print it rather than launching it
 }
 
 // New creates a new default App configured to write to 'stdout' and DEBUG log to 'stderr'.
 func New(stdout, stderr io.Writer) *App {
-	log := logrus.New()
+	return NewWithEnv(stdout, stderr, os.Getenv)
+}
 
+// NewWithEnv is New but configures the App from, and has it look up, the environment
+// variables with 'getenv' rather than the environment of the process.
+func NewWithEnv(stdout, stderr io.Writer, getenv func(key string) string) *App {
 	// Get the value of $PATH
-	path := os.Getenv("PATH")
+	path := getenv("PATH")
+
+	// If the PYLAUNCH_DEBUG environment variable is set to anything log debug
+	// information to stderr, as JSON if it's set to "json"
+	log := newLogger(stderr, getenv(debugEnvKey))
 
-	// If the PYLAUNCH_DEBUG environment variable is set to anything
-	// set logging level accordingly, otherwise leave at default (InfoLevel)
-	if debug := os.Getenv(debugEnvKey); debug != "" {
-		log.Level = logrus.DebugLevel
+	// If PY_PRERELEASE is set to something truthy e.g. "1" or "true"
+	// allow pre-release pythons to be picked
+	preRelease, _ := strconv.ParseBool(getenv(preReleaseEnvKey)) //nolint: errcheck // Anything else means no
+
+	// Same for PY_SCRIPT_VENV, building venvs for script dependencies is opt in
+	scriptVenv, _ := strconv.ParseBool(getenv(scriptVenvEnvKey)) //nolint: errcheck // Anything else means no
+
+	// If there's no user cache dir, fall back to somewhere we can always write
+	cacheDir := userCacheDir(getenv)
+	if cacheDir == "" {
+		cacheDir = os.TempDir()
 	}
-	log.Formatter = &logrus.TextFormatter{DisableLevelTruncation: true, DisableTimestamp: true}
-	log.Out = stderr
 
-	return &App{Stdout: stdout, Stderr: stderr, Logger: log, Path: path}
+	return &App{
+		Stdout:        stdout,
+		Stderr:        stderr,
+		Logger:        log,
+		Path:          path,
+		PreRelease:    preRelease,
+		ScriptVenv:    scriptVenv,
+		CacheDir:      filepath.Join(cacheDir, "py"),
+		ShebangPolicy: getenv(shebangPolicyKey),
+		Getenv:        getenv,
+	}
 }
 
 // Help shows py's help text and usage info.
@@ -121,25 +214,74 @@ func (a *App) Help() {
 	fmt.Fprintln(a.Stdout, helpText)
 }
 
-// List shows a list of all python interpreters on $PATH, sorted latest to oldest.
-func (a *App) List() error {
-	interpreters, err := a.getAllPythonInterpreters()
+// ListOptions configures what List shows and how.
+type ListOptions struct {
+	Spec    *interpreter.Spec // Only list interpreters matching this, nil means all of them
+	JSON    bool              // Print the interpreters as a JSON array rather than a table
+	Verbose bool              // Show the other paths (e.g. symlinks) found to each interpreter
+}
+
+// listEntry is the JSON representation of an interpreter printed by List.
+type listEntry struct {
+	Path           string   `json:"path"`
+	Implementation string   `json:"implementation"`
+	Version        string   `json:"version"`
+	Major          int      `json:"major"`
+	Minor          int      `json:"minor"`
+	Patch          *int     `json:"patch"`
+	PreRelease     string   `json:"preRelease,omitempty"`
+	ABIFlags       string   `json:"abiFlags,omitempty"`
+	Arch           string   `json:"arch,omitempty"`
+	Bits           int      `json:"bits,omitempty"`
+	Aliases        []string `json:"aliases,omitempty"`
+}
+
+// List shows a list of all python interpreters on $PATH (or those matching opts.Spec), sorted latest to oldest.
+func (a *App) List(opts ListOptions) error {
+	interpreters, err := a.find(opts.Spec)
 	if err != nil {
 		return err
 	}
 
-	// Handle the case where the user does not have any pythons
-	if len(interpreters) == 0 {
-		return fmt.Errorf("no python interpreters found on $PATH")
-	}
-	// Ensure interpreters are sorted latest to oldest regardless of
-	// any filepath based sorting from ReadDir
-	interpreter.Sort(interpreters)
+	if opts.JSON {
+		entries := make([]listEntry, 0, len(interpreters))
+		for _, python := range interpreters {
+			entry := listEntry{
+				Path:           python.Path,
+				Implementation: python.Implementation,
+				Version:        python.Version(),
+				Major:          python.Major,
+				Minor:          python.Minor,
+				PreRelease:     python.PreRelease,
+				ABIFlags:       python.ABIFlags,
+				Arch:           python.Arch,
+				Bits:           python.Bits,
+			}
+			if python.Patch != interpreter.Unknown {
+				patch := python.Patch
+				entry.Patch = &patch
+			}
+			if opts.Verbose {
+				entry.Aliases = python.Aliases
+			}
+			entries = append(entries, entry)
+		}
 
-	a.Logger.WithField("interpreters", interpreters).Debugln("Found python3 interpreters")
+		encoder := json.NewEncoder(a.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entries); err != nil {
+			return fmt.Errorf("could not encode interpreters as JSON: %w", err)
+		}
+		return nil
+	}
 
 	for _, interpreter := range interpreters {
 		fmt.Fprintln(a.Stdout, interpreter.ToString())
+		if opts.Verbose {
+			for _, alias := range interpreter.Aliases {
+				fmt.Fprintf(a.Stdout, "\t│   also %s\n", alias)
+			}
+		}
 	}
 
 	return nil
@@ -151,361 +293,166 @@ func (a *App) List() error {
 //  1. Activated virtual environment
 //  2. .venv directory
 //  3. venv directory
-//  4. Look for a python shebang line in the file (if we have a file)
-//  5. PY_PYTHON env variable
-//  6. Latest version on $PATH
+//  4. Look for PEP 723 inline metadata in the script (if we have one)
+//  5. Look for a python shebang line in the script (if we have one)
+//  6. PY_PYTHON env variable
+//  7. Latest version on $PATH
+//
+// See package resolve for the details.
 func (a *App) Launch(args []string) error {
-	// Here we follow the control flow specified, returning to the caller
-	// on the first matched condition, thus preventing later conditions
-	// from evaluating. This ensures our order of priority is followed
-
-	// 1) Activated virtual environment, as marked by the presence of
-	// an environment variable $VIRTUAL_ENV pointing to the directory
-	// e.g. /Users/you/Projects/thisproject/.venv
-	a.Logger.Debugln("Looking for $VIRTUAL_ENV environment variable")
-	if path := os.Getenv(vitualEnvKey); path != "" {
-		a.Logger.WithField("$VIRTUAL_ENV", path).Debugln("Found environment variable")
-		exe := filepath.Join(path, "bin", "python")
-		a.Logger.WithFields(logrus.Fields{"interpreter": exe, "arguments": args}).Debugln("Launching python interpreter with arguments")
-		return launch(exe, args)
-	}
-
-	// 2) & 3) Directory called .venv or venv in cwd
-	cwd, err := os.Getwd()
+	result, err := a.resolve(resolve.Options{Args: args})
 	if err != nil {
-		return fmt.Errorf("error getting cwd: %w", err)
-	}
-
-	a.Logger.WithField("cwd", cwd).Debugln("Looking for virtual environment in cwd")
-
-	exe := a.getVenvPython(cwd)
-	if exe != "" {
-		// Means we found a python interpreter inside .venv, so launch it and pass on any args
-		a.Logger.WithFields(logrus.Fields{"interpreter": exe, "arguments": args}).Debugln("Launching python interpreter with arguments")
-		return launch(exe, args)
+		return err
 	}
 
-	// 4) If first arg is a file, look for a python shebang line
-	if len(args) == 1 {
-		if exists(args[0]) {
-			// We have a file as the argument
-			if err := a.handlePotentialShebang(args); err != nil {
-				return err
-			}
-			// Note: we don't return nil here as we want to carry on the control flow
+	exe := result.Interpreter.Path
+	switch {
+	case len(result.Dependencies) == 0:
+		// Nothing to install
+	case a.Explain:
+		// Building the venv means running pip, so only say what would happen
+		dir, _ := a.scriptVenvDir(exe, result.Dependencies)
+		if scriptVenvComplete(dir) {
+			a.explain("Would reuse script venv %s with %s installed", dir, strings.Join(result.Dependencies, ", "))
+		} else {
+			a.explain("Would build script venv %s with %s installed", dir, strings.Join(result.Dependencies, ", "))
 		}
-	}
-
-	// 5) PY_PYTHON env variable specifying a X.Y version identifier e.g. 3.10
-	a.Logger.Debugln("Looking for $PY_PYTHON environment variable")
-	if version := os.Getenv(pyPythonEnvKey); version != "" {
-		a.Logger.WithField("$PY_PYTHON", version).Debugln("Found environment variable")
-		major, minor, err := a.parsePyPython(version)
+		exe = filepath.Join(dir, "bin", "python")
+	default:
+		exe, err = a.scriptVenv(exe, result.Dependencies)
 		if err != nil {
-			return fmt.Errorf("%w", err)
+			return err
 		}
-		// We're good to go
-		return a.LaunchExact(major, minor, args)
 	}
 
-	// 6) Launch latest on $PATH and pass the args through
-	a.Logger.Debugln("Falling back to latest python on $PATH")
-	if err := a.LaunchLatest(args); err != nil {
-		return err
-	}
-
-	// If we get here, user has no python so return an error
-	return fmt.Errorf("no python interpreters found after executing control flow")
+	// Interpreter flags from a shebang go before the script e.g. python -u script.py
+	return a.launch(exe, append(result.Flags, args...))
 }
 
 // LaunchLatest will search through $PATH, find the latest python interpreter
 // and launch it, passing through any arguments passed to it.
 func (a *App) LaunchLatest(args []string) error {
-	interpreters, err := a.getAllPythonInterpreters()
-	if err != nil {
-		return err
-	}
-
-	// Handle the case where none are found
-	if len(interpreters) == 0 {
-		return fmt.Errorf("no python interpreters found on $PATH")
-	}
-
-	interpreter.Sort(interpreters)
-
-	a.Logger.WithField("interpreters", interpreters).Debugln("Found python3 interpreters")
-
-	latest := interpreters[0]
-
-	a.Logger.WithFields(logrus.Fields{"latest": latest, "arguments": args}).Debugln("Launching latest python with arguments")
-
-	return launch(latest.Path, args)
+	spec := interpreter.Spec{Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any}
+	return a.LaunchSpec(spec, args)
 }
 
 // LaunchMajor will search through $PATH, find the latest python interpreter
 // satisfying the constraint imposed by 'major' version passed
 // launch it, and pass through any arguments passed to it.
 func (a *App) LaunchMajor(major int, args []string) error {
-	a.Logger.WithField("major", major).Debugln("Searching for latest python with major version")
-	interpreters, err := a.getAllPythonInterpreters()
-	if err != nil {
-		return err
-	}
-
-	// Create and populate a list of all the python interpreters that
-	// satisfy the specified major version
-	var supportingInterpreters []interpreter.Interpreter
-	for _, python := range interpreters {
-		if python.SatisfiesMajor(major) {
-			supportingInterpreters = append(supportingInterpreters, python)
-		}
-	}
-
-	// Handle the case where none are found
-	if len(supportingInterpreters) == 0 {
-		return fmt.Errorf("no python%d interpreters found on $PATH", major)
-	}
-
-	// Sort so the latest supporting interpreter is first
-	interpreter.Sort(supportingInterpreters)
-
-	a.Logger.WithField("matching interpreters", supportingInterpreters).Debugln("Found matching interpreters")
-
-	latest := supportingInterpreters[0]
-
-	a.Logger.WithField("interpreter", latest.Path).Debugln("Launching python")
-	return launch(latest.Path, args)
+	return a.LaunchSpec(interpreter.Spec{Major: major, Minor: interpreter.Any, Patch: interpreter.Any}, args)
 }
 
 // LaunchExact will search through $PATH, find the latest python interpreter
 // satisfying the constraint imposed by both 'major' and 'minor' version passed
 // launch it, and pass through any args passed to it.
 func (a *App) LaunchExact(major, minor int, args []string) error {
-	a.Logger.WithField("version", fmt.Sprintf("%d.%d", major, minor)).Debugln("Searching for exact python version")
-	interpreters, err := a.getAllPythonInterpreters()
-	if err != nil {
-		return err
-	}
-
-	// Create and populate a list of all the python interpreters that
-	// satisfy the specify major version
-	var supportingInterpreters []interpreter.Interpreter
-	for _, python := range interpreters {
-		if python.SatisfiesExact(major, minor) {
-			supportingInterpreters = append(supportingInterpreters, python)
-		}
-	}
-
-	// Handle the case where none are found
-	if len(supportingInterpreters) == 0 {
-		return fmt.Errorf("no python%d.%d interpreter found on $PATH", major, minor)
-	}
-
-	// Sort so the latest supporting interpreter is first
-	interpreter.Sort(supportingInterpreters)
-
-	a.Logger.WithField("matching interpreters", supportingInterpreters).Debugln("Found matching interpreters")
-
-	latest := supportingInterpreters[0]
-
-	a.Logger.WithField("python", latest.Path).Debugln("Launching exact python")
-	return launch(latest.Path, args)
+	return a.LaunchSpec(interpreter.Spec{Major: major, Minor: minor, Patch: interpreter.Any}, args)
 }
 
-// getPath goes through a.Path (which it expects to be $PATH or similar)
-// i.e. separated list of directories, and returns a string slice of the
-// entries in that path.
-//
-// Entries will be de-duplicated prior to returning.
-func (a *App) getPathEntries() []string {
-	paths := []string{}
-
-	for _, dir := range filepath.SplitList(a.Path) {
-		if dir == "" {
-			// Unix shell semantics: path element "" means "."
-			dir = "."
-		}
-		// Some new thing on macOS that breaks us, there's a new directory
-		// called /var/run/com.apple.security.cryptexd/codex.system/bootstrap/usr/local/bin
-		// which cannot be read by the user, so we'll just skip it.
-		if strings.HasPrefix(dir, "/var/run") {
-			continue
-		}
-		paths = append(paths, dir)
+// LaunchSpec will search through $PATH, find the latest python interpreter
+// satisfying every constraint in 'spec' (e.g. 3, 3.10, 3.12.4, 3.13t or pypy3.10)
+// launch it, and pass through any args passed to it.
+func (a *App) LaunchSpec(spec interpreter.Spec, args []string) error {
+	latest, err := a.latestMatching(spec)
+	if err != nil {
+		return err
 	}
 
-	// Dedupe
-	paths = deDupe(paths)
-
-	return paths
+	return a.launch(latest.Path, args)
 }
 
-// getVenvPython will look for a ".venv/bin/python" or a "venv/bin/python"
-// under the cwd, ensure that it exists and then return it's absolute path
-// .venv will be preferred over venv, venv will only be used if .venv
-// does not exist.
-//
-// If neither is found, an empty string will be returned.
-func (a *App) getVenvPython(cwd string) string {
-	dotVenv := filepath.Join(cwd, ".venv", "bin", "python")
-	venv := filepath.Join(cwd, "venv", "bin", "python")
-
-	switch {
-	case exists(dotVenv):
-		a.Logger.WithField("venv dir", dotVenv).Debugln("Found a virtual environment")
-		return dotVenv
-	case exists(venv):
-		a.Logger.WithField("venv dir", venv).Debugln("Found a virtual environment")
-		return venv
-	default:
-		return ""
+// latestMatching returns the latest python interpreter on $PATH satisfying every constraint in 'spec'.
+func (a *App) latestMatching(spec interpreter.Spec) (interpreter.Interpreter, error) {
+	result, err := a.resolve(resolve.Options{Spec: &spec})
+	if err != nil {
+		return interpreter.Interpreter{}, err
 	}
+	return result.Interpreter, nil
 }
 
-// parsePyPython is a helper that, when given the value of a valid PY_PYTHON env variable
-// will return the integer major and minor version parts so we can launch it
-//
-// A valid value for PY_PYTHON is X.Y, the same as the exact version specifier
-// e.g. "3.10"
-//
-// If 'version' is not a valid format, an error will be returned.
-func (a *App) parsePyPython(version string) (int, int, error) {
-	parts := strings.Split(version, ".")
-
-	if len(parts) != xYParts {
-		return 0, 0, fmt.Errorf("malformed PY_PYTHON: not X.Y format")
-	}
-
-	major, minor := parts[0], parts[1]
-
-	majorInt, err := strconv.Atoi(major)
-	if err != nil {
-		return 0, 0, fmt.Errorf("malformed PY_PYTHON: major component not an integer")
+// resolve runs resolve.Resolve with 'opts' filled in from the App and the environment,
+// explaining each decision it made if we've been asked to and showing any warnings.
+func (a *App) resolve(opts resolve.Options) (resolve.Result, error) {
+	opts.Logger = a.Logger
+	opts.Path = a.Path
+	opts.VirtualEnv = a.getenv(vitualEnvKey)
+	opts.PyPython = a.getenv(pyPythonEnvKey)
+	opts.Dir = a.Dir
+	opts.ShebangPolicy = a.ShebangPolicy
+	opts.PreRelease = a.PreRelease
+	opts.ScriptVenv = a.ScriptVenv
+
+	result, err := resolve.Resolve(context.Background(), opts)
+	for _, step := range result.Trace {
+		if step.Warning {
+			a.warn("%s", step.Message)
+		} else {
+			a.explain("%s", step.Message)
+		}
 	}
 
-	minorInt, err := strconv.Atoi(minor)
 	if err != nil {
-		return 0, 0, fmt.Errorf("malformed PY_PYTHON: minor component not an integer")
+		return resolve.Result{}, fmt.Errorf("%w", err)
 	}
 
-	// Now we're safe
-	return majorInt, minorInt, nil
+	a.Logger.Debug("Resolved python interpreter", LogKeyStep, result.Reason, LogKeyInterpreter, result.Interpreter.Path)
+	return result, nil
 }
 
-// parseShebang takes a line of text (as read from a file) and returns
-// the string version of a python version it may represent
-//
-// If 'shebang' is not a valid shebang line, or if no python version is specified
-// an empty string will be returned. This is the signal to use the remaining control flow to
-// determine the appropriate python version to launch
-//
-// Example
-//
-//	sh := ParseShebang("#!/usr/local/bin/python3.9")
-//	fmt.Println(sh)
-//
-// Output: "3.9".
-func (a *App) parseShebang(shebang string) string {
-	a.Logger.Debugln("Checking for a python shebang line")
-	if !strings.HasPrefix(shebang, "#!") {
-		return ""
+// find returns every python interpreter on $PATH, or just those matching 'spec' if it's not nil,
+// sorted latest first.
+func (a *App) find(spec *interpreter.Spec) ([]interpreter.Interpreter, error) {
+	interpreters, err := resolve.Find(context.Background(), resolve.Options{Logger: a.Logger, Path: a.Path, Spec: spec})
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
 	}
+	return interpreters, nil
+}
 
-	// Trim off the #!
-	shebang = strings.Replace(shebang, "#!", "", 1)
-
-	// Whitespace is allowed between #! and the path e.g. #! /usr/bin/python
-	shebang = strings.TrimSpace(shebang)
-
-	acceptedPaths := [4]string{
-		"python",
-		"/usr/bin/python",
-		"/usr/local/bin/python",
-		"/usr/bin/env python",
+// launch launches the python interpreter at 'path' with 'args', unless we're
+// explaining in which case it says what it would have launched instead.
+func (a *App) launch(path string, args []string) error {
+	a.Logger.Debug("Launching python interpreter", LogKeyInterpreter, path, LogKeyArgs, args)
+	if a.Explain {
+		fmt.Fprintf(a.Stdout, "Would launch: %s\n", strings.Join(append([]string{path}, args...), " "))
+		return nil
 	}
+	return launch(path, args)
+}
 
-	for _, path := range acceptedPaths {
-		if strings.HasPrefix(shebang, path) {
-			// Valid shebang, let's see if we can get a version
-			// from the end of 'path' e.g. /usr/bin/python3 -> 3
-			// /usr/bin/python3.9 -> 3.9
-			a.Logger.WithField("shebang", shebang).Debugln("Found python shebang line")
-			version := shebang[len(path):]
-			a.Logger.WithField("version", version).Debugln("Found potential python version in shebang line")
-			return version
-		}
+// getenv looks up the environment variable 'key' with a.Getenv, or os.Getenv if it's not set.
+func (a *App) getenv(key string) string {
+	if a.Getenv == nil {
+		return os.Getenv(key)
 	}
-
-	return ""
+	return a.Getenv(key)
 }
 
-// getAllPythonInterpreters does exactly what it says on the tin
-// it searches through $PATH and returns a list of all python interpreters.
-func (a *App) getAllPythonInterpreters() ([]interpreter.Interpreter, error) {
-	a.Logger.Debugln("Checking $PATH environment variable")
-	paths := a.getPathEntries()
-
-	a.Logger.Debugf("$PATH: %v\n", paths)
-
-	a.Logger.Debugln("Looking through $PATH for python interpreters")
-	interpreters, err := interpreter.GetAll(paths)
-	if err != nil {
-		return nil, fmt.Errorf("error fetching python interpreters: %w", err)
+// cwd returns a.Dir, or the working directory of the process if it's not set.
+func (a *App) cwd() (string, error) {
+	if a.Dir != "" {
+		return a.Dir, nil
 	}
 
-	return interpreters, nil
-}
-
-// handlePotentialShebang is called once we know the first argument is a file
-// it attempts to open the file, look for a shebang line, parse it
-// and launch the appropriate python interpreter
-// if it does not find a valid shebang line or there is no version found in it
-// it will return to signal the continuation of the control flow.
-func (a *App) handlePotentialShebang(args []string) error {
-	a.Logger.WithField("argument", args[0]).Debugln("argument is a file")
-	file, err := os.Open(args[0])
+	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("could not open %s: %w", args[0], err)
+		return "", fmt.Errorf("error getting cwd: %w", err)
 	}
-	defer file.Close()
-
-	// Read the first line from the file
-	scanner := bufio.NewScanner(file)
-	scanner.Scan()
-
-	version := a.parseShebang(scanner.Text())
-
-	switch {
-	case majorRegex.MatchString(version):
-		// Shebang is a major version e.g. /usr/bin/python3
-		a.Logger.WithField("major version", version).Debugln("Shebang line refers to major version")
-		major, err := strconv.Atoi(version)
-		if err != nil {
-			return fmt.Errorf("shebang major version %v could not be parsed an integer", version)
-		}
-		if err := a.LaunchMajor(major, args); err != nil {
-			return err
-		}
-
-	case exactRegex.MatchString(version):
-		// Shebang is an exact version e.g. /usr/bin/python3.9
-		// we can reuse the parsePyPython functionality here
-		a.Logger.WithField("exact version", version).Debugln("Shebang line refers to exact version")
-		major, minor, err := a.parsePyPython(version)
-		if err != nil {
-			return err
-		}
-		if err := a.LaunchExact(major, minor, args); err != nil {
-			return err
-		}
+	return cwd, nil
+}
 
-	default:
-		// The shebang either wasn't valid or had no version identifier e.g. /usr/bin/python
-		// in which case, continue the control flow
-		a.Logger.WithField("version", version).Debugln("Unrecognised or missing version in shebang line, continuing control flow")
+// explain writes a line explaining a decision in the control flow
+// to a.Stdout, but only if we've been asked to explain.
+func (a *App) explain(format string, args ...any) {
+	if a.Explain {
+		fmt.Fprintf(a.Stdout, "- %s\n", fmt.Sprintf(format, args...))
 	}
+}
 
-	return nil
+// warn writes a warning to a.Stderr.
+func (a *App) warn(format string, args ...any) {
+	fmt.Fprintf(a.Stderr, "warning: %s\n", fmt.Sprintf(format, args...))
 }
 
 // launch will launch a python interpreter at a specific (absolute) path
@@ -529,6 +476,27 @@ func launch(path string, args []string) error {
 	return nil
 }
 
+// userCacheDir is os.UserCacheDir but looks up the environment with 'getenv', so
+// $XDG_CACHE_HOME if it's set and absolute, else $HOME/.cache (or $HOME/Library/Caches
+// on macOS, which doesn't use $XDG_CACHE_HOME). It returns "" if neither is set.
+func userCacheDir(getenv func(key string) string) string {
+	if runtime.GOOS != "darwin" {
+		if dir := getenv("XDG_CACHE_HOME"); filepath.IsAbs(dir) {
+			return dir
+		}
+	}
+
+	home := getenv("HOME")
+	if home == "" {
+		return ""
+	}
+
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "Caches")
+	}
+	return filepath.Join(home, ".cache")
+}
+
 // exists returns true if 'path' exists, else false.
 func exists(path string) bool {
 	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
@@ -536,20 +504,3 @@ func exists(path string) bool {
 	}
 	return true
 }
-
-// deDupe takes in a list of paths (e.g. those returned from GetPath)
-// and returns a de-duplicated list
-// it is not that common to have a duplicated $PATH entry but it could happen
-// so let's handle it here.
-func deDupe(paths []string) []string {
-	keys := make(map[string]bool)
-	deDuped := []string{}
-	for _, item := range paths {
-		if _, ok := keys[item]; !ok {
-			keys[item] = true
-			deDuped = append(deDuped, item)
-		}
-	}
-
-	return deDuped
-}
@@ -2,13 +2,20 @@ package cli //nolint: testpackage // Need access to internals
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 
-	"github.com/sirupsen/logrus"
+	"github.com/FollowTheProcess/py/internal/fixture"
+	"github.com/FollowTheProcess/py/interpreter"
 )
 
 // newTestApp creates and returns a test App object configured to talk to 'out' and 'err'
@@ -18,7 +25,68 @@ func newTestApp(out, err io.Writer, path string) *App {
 		Stdout: out,
 		Stderr: err,
 		Path:   path,
-		Logger: logrus.New(), // Doesn't actually matter but it needs it to work
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)), // Doesn't actually matter but it needs it to work
+		Getenv: testEnv(nil),                                   // Nothing set, so the real environment can't leak in
+	}
+}
+
+// testEnv returns a Getenv looking up variables in 'env' rather than the real environment.
+func testEnv(env map[string]string) func(key string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+// fakePythonScript is a shell script standing in for python in the venv tests, it
+// understands just enough of "-m venv" and "-m pip install" to build a venv, logging every
+// pip install to pip.log next to the venv's bin directory so tests can see what happened.
+const fakePythonScript = `#!/bin/sh
+case "$2" in
+venv)
+	for dir; do :; done
+	mkdir -p "$dir/bin" && cp "$0" "$dir/bin/python" && touch "$dir/pyvenv.cfg"
+	echo "$@" >> "$dir/venv.log"
+	;;
+pip)
+	shift 2
+	echo "$@" >> "$(dirname "$0")/../pip.log"
+	[ -z "$FAKE_PIP_FAIL" ]
+	;;
+esac
+`
+
+// fakeBin creates the directory 'dir' containing an empty executable file for each of 'names', returning 'dir'.
+func fakeBin(t *testing.T, dir string, names ...string) string {
+	t.Helper()
+	return fakePythons(t, dir, "", names...)
+}
+
+// fakePythons creates the directory 'dir' containing an executable file running 'script'
+// for each of 'names' e.g. fakePythonScript, returning 'dir'.
+func fakePythons(t *testing.T, dir, script string, names ...string) string {
+	t.Helper()
+	mustMkdir(t, dir)
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatalf("could not write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// mustMkdir creates the directory 'dir' and any parents, failing the test if it can't.
+func mustMkdir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("could not create %s: %v", dir, err)
+	}
+}
+
+// writeFile writes 'contents' to the file at 'path', failing the test if it can't.
+func writeFile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("could not write %s: %v", path, err)
 	}
 }
 
@@ -37,59 +105,6 @@ func TestApp_Help(t *testing.T) {
 	}
 }
 
-func TestApp_getPathEntries(t *testing.T) {
-	tests := []struct {
-		name    string
-		key     string
-		path    string
-		want    []string
-		wantErr bool
-	}{
-		{
-			name: "normal path",
-			path: "/usr/bin:/usr/local/bin:/usr/local/somewhere",
-			want: []string{"/usr/bin", "/usr/local/bin", "/usr/local/somewhere"},
-		},
-		{
-			name: "empty",
-			path: "",
-			want: []string{},
-		},
-		{
-			name: "duplicate entries",
-			path: "/usr/bin:/usr/local/bin:/usr/bin:/usr/somewhere:/usr/local/bin",
-			want: []string{"/usr/bin", "/usr/local/bin", "/usr/somewhere"},
-		},
-		{
-			name: "empty entry should be replaced with .",
-			path: "/usr/bin:/usr/local/bin::/usr/somewhere:",
-			want: []string{"/usr/bin", "/usr/local/bin", ".", "/usr/somewhere"},
-		},
-		{
-			name: "multiple empty entries should be one .",
-			path: "/usr/bin::/usr/local/bin::/usr/somewhere:",
-			want: []string{"/usr/bin", ".", "/usr/local/bin", "/usr/somewhere"},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Make the test App
-			stdout := &bytes.Buffer{}
-			stderr := &bytes.Buffer{}
-
-			app := newTestApp(stdout, stderr, tt.path)
-
-			// Get the value using the key specified in the test case
-			got := app.getPathEntries()
-
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("got %#v, wanted %#v", got, tt.want)
-			}
-		})
-	}
-}
-
 func Test_exists(t *testing.T) {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -121,279 +136,269 @@ func Test_exists(t *testing.T) {
 	}
 }
 
-func Test_parsePyPython(t *testing.T) {
+func TestNewWithEnv(t *testing.T) {
+	env := map[string]string{
+		"PATH":              fixture.PythonPath(".."),
+		"PY_PRERELEASE":     "1",
+		"PY_SHEBANG_POLICY": "nearest",
+		"PY_PYTHON":         "3.9",
+		"XDG_CACHE_HOME":    "/xdg/cache",
+		"HOME":              "/home/someone",
+	}
+
+	stdout := &bytes.Buffer{}
+	app := NewWithEnv(stdout, &bytes.Buffer{}, testEnv(env))
+	app.Explain = true
+	app.Dir = t.TempDir()
+
+	if app.Path != env["PATH"] {
+		t.Errorf("got Path %q, wanted %q", app.Path, env["PATH"])
+	}
+
+	if !app.PreRelease {
+		t.Error("expected PreRelease to be set from PY_PRERELEASE")
+	}
+
+	if app.ShebangPolicy != "nearest" {
+		t.Errorf("got ShebangPolicy %q, wanted %q", app.ShebangPolicy, "nearest")
+	}
+
+	wantCache := "/xdg/cache/py"
+	if runtime.GOOS == "darwin" {
+		wantCache = "/home/someone/Library/Caches/py"
+	}
+
+	if app.CacheDir != wantCache {
+		t.Errorf("got CacheDir %q, wanted %q", app.CacheDir, wantCache)
+	}
+
+	// The whole control flow should see the injected environment, not the real one
+	if err := app.Launch(nil); err != nil {
+		t.Fatalf("Launch() returned an unexpected error: %v", err)
+	}
+
+	if got := stdout.String(); !strings.Contains(got, "python3.9\n") {
+		t.Errorf("expected $PY_PYTHON to pick python3.9, got:\n%s", got)
+	}
+}
+
+func Test_userCacheDir(t *testing.T) {
+	home := "/home/someone/.cache"
+	if runtime.GOOS == "darwin" {
+		home = "/home/someone/Library/Caches"
+	}
+
+	xdg := "/xdg/cache"
+	if runtime.GOOS == "darwin" {
+		xdg = home // macOS doesn't use $XDG_CACHE_HOME
+	}
+
 	tests := []struct {
-		name      string
-		version   string
-		wantMajor int
-		wantMinor int
-		wantErr   bool
+		env  map[string]string
+		name string
+		want string
 	}{
 		{
-			name:      "valid 3.10",
-			version:   "3.10",
-			wantMajor: 3,
-			wantMinor: 10,
-			wantErr:   false,
-		},
-		{
-			name:      "valid 3.9",
-			version:   "3.9",
-			wantMajor: 3,
-			wantMinor: 9,
-			wantErr:   false,
-		},
-		{
-			name:      "valid 4.0",
-			version:   "4.0",
-			wantMajor: 4,
-			wantMinor: 0,
-			wantErr:   false,
-		},
-		{
-			name:      "no major",
-			version:   ".9",
-			wantMajor: 0,
-			wantMinor: 0,
-			wantErr:   true,
-		},
-		{
-			name:      "no minor",
-			version:   "3.",
-			wantMajor: 0,
-			wantMinor: 0,
-			wantErr:   true,
-		},
-		{
-			name:      "no dot",
-			version:   "39",
-			wantMajor: 0,
-			wantMinor: 0,
-			wantErr:   true,
-		},
-		{
-			name:      "only one number",
-			version:   "3",
-			wantMajor: 0,
-			wantMinor: 0,
-			wantErr:   true,
+			name: "xdg and home",
+			env:  map[string]string{"XDG_CACHE_HOME": "/xdg/cache", "HOME": "/home/someone"},
+			want: xdg,
 		},
 		{
-			name:      "empty string",
-			version:   "",
-			wantMajor: 0,
-			wantMinor: 0,
-			wantErr:   true,
+			name: "home only",
+			env:  map[string]string{"HOME": "/home/someone"},
+			want: home,
 		},
 		{
-			name:      "whitespace before",
-			version:   " 3.9",
-			wantMajor: 0,
-			wantMinor: 0,
-			wantErr:   true,
+			name: "relative xdg",
+			env:  map[string]string{"XDG_CACHE_HOME": "xdg/cache", "HOME": "/home/someone"},
+			want: home,
 		},
 		{
-			name:      "whitespace after",
-			version:   "3.9 ",
-			wantMajor: 0,
-			wantMinor: 0,
-			wantErr:   true,
-		},
-		{
-			name:      "major not an int",
-			version:   "X.9",
-			wantMajor: 0,
-			wantMinor: 0,
-			wantErr:   true,
-		},
-		{
-			name:      "minor not an int",
-			version:   "3.X",
-			wantMajor: 0,
-			wantMinor: 0,
-			wantErr:   true,
+			name: "neither",
+			env:  map[string]string{},
+			want: "",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			app := &App{}
-			gotMajor, gotMinor, err := app.parsePyPython(tt.version)
-
-			if (err != nil) != tt.wantErr {
-				t.Errorf("parsePyPython() error = %v, wantErr = %v", err, tt.wantErr)
+			if got := userCacheDir(testEnv(tt.env)); got != tt.want {
+				t.Errorf("got %q, wanted %q", got, tt.want)
 			}
+		})
+	}
+}
 
-			if gotMajor != tt.wantMajor {
-				t.Errorf("wrong major version. got %d, wanted %d", gotMajor, tt.wantMajor)
-			}
+func TestApp_LaunchExplain(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "script.py")
+	writeFile(t, script, "#!/usr/bin/python3.6 -u\nprint('hello')\n")
 
-			if gotMinor != tt.wantMinor {
-				t.Errorf("wrong minor version. got %d, wanted %d", gotMinor, tt.wantMinor)
-			}
-		})
+	stdout := &bytes.Buffer{}
+	app := newTestApp(stdout, &bytes.Buffer{}, fixture.PythonPath(".."))
+	app.Explain = true
+	app.ShebangPolicy = "warn"
+	app.Dir = filepath.Dir(script)
+
+	if err := app.Launch([]string{script, "--verbose"}); err != nil {
+		t.Fatalf("Launch() returned an unexpected error: %v", err)
+	}
+
+	want := []string{
+		"- $VIRTUAL_ENV is not set\n",
+		"- No inline script metadata in " + script + "\n",
+		"- Shebang of " + script + " asks for python3.6 which is not installed, $PY_SHEBANG_POLICY is \"warn\"\n",
+		"- $PY_PYTHON is not set\n",
+		"python3.10 -u " + script + " --verbose\n",
+	}
+
+	got := stdout.String()
+	for _, line := range want {
+		if !strings.Contains(got, line) {
+			t.Errorf("explanation missing %q, got:\n%s", line, got)
+		}
 	}
 }
 
-func Test_parseShebang(t *testing.T) {
+func TestApp_LaunchErrors(t *testing.T) {
+	app := newTestApp(&bytes.Buffer{}, &bytes.Buffer{}, fixture.PythonPath(".."))
+
+	var broken *BrokenVenvError
+	app.Getenv = testEnv(map[string]string{"VIRTUAL_ENV": filepath.Join(t.TempDir(), "gone")})
+	if err := app.Launch(nil); !errors.As(err, &broken) {
+		t.Errorf("expected a *BrokenVenvError for $VIRTUAL_ENV, got %v", err)
+	}
+
+	var noMatch *NoMatchError
+	if err := app.LaunchExact(3, 4, nil); !errors.As(err, &noMatch) {
+		t.Errorf("expected a *NoMatchError, got %v", err)
+	}
+
+	empty := newTestApp(&bytes.Buffer{}, &bytes.Buffer{}, t.TempDir())
+	if err := empty.LaunchLatest(nil); !errors.Is(err, ErrNoInterpreter) {
+		t.Errorf("expected ErrNoInterpreter, got %v", err)
+	}
+}
+
+func TestApp_List(t *testing.T) {
+	pypyPath, err := filepath.Abs(filepath.Join("..", "interpreter", "testdata", "pythonpaths", "pythonpath2", "pypy3.10"))
+	if err != nil {
+		t.Fatalf("could not get absolute path: %v", err)
+	}
+	pypy := interpreter.Spec{Implementation: interpreter.PyPy, Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any}
+
 	tests := []struct {
 		name    string
-		shebang string
-		want    string
+		opts    ListOptions
+		want    []string
+		wantErr bool
 	}{
 		{
-			name:    "python3 returns 3",
-			shebang: "#!/usr/bin/python3",
-			want:    "3",
-		},
-		{
-			name:    "python3.9 returns 3.9",
-			shebang: "#!/usr/bin/python3.9",
-			want:    "3.9",
-		},
-		{
-			name:    "python3.10 returns 3.10",
-			shebang: "#!/usr/bin/python3.10",
-			want:    "3.10",
-		},
-		{
-			name:    "python4.12 returns 4.12",
-			shebang: "#!/usr/bin/python4.12",
-			want:    "4.12",
-		},
-		{
-			name:    "no version returns nothing",
-			shebang: "#!/usr/bin/python",
-			want:    "",
-		},
-		{
-			name:    "no version returns nothing (local)",
-			shebang: "#!/usr/local/bin/python",
-			want:    "",
-		},
-		{
-			name:    "no version returns nothing (env)",
-			shebang: "#!/usr/bin/env python",
-			want:    "",
-		},
-		{
-			name:    "local 3.9 returns 3.9",
-			shebang: "#!/usr/local/bin/python3.9",
-			want:    "3.9",
-		},
-		{
-			name:    "env 3.9 returns 3.9",
-			shebang: "#!/usr/bin/env python3.9",
-			want:    "3.9",
-		},
-		{
-			name:    "local 4.12 returns 4.12",
-			shebang: "#!/usr/local/bin/python4.12",
-			want:    "4.12",
-		},
-		{
-			name:    "env 4.12 returns 4.12",
-			shebang: "#!/usr/bin/env python4.12",
-			want:    "4.12",
-		},
-		{
-			name:    "whitespace isn't counted",
-			shebang: "#! /usr/bin/env python3",
-			want:    "3",
-		},
-		{
-			name:    "no #! means no shebang",
-			shebang: "/usr/bin/python",
-			want:    "",
-		},
-		{
-			name:    "non valid path returns nothing",
-			shebang: "#!/somewhere/not/recognised/python",
-			want:    "",
+			name:    "filtered",
+			opts:    ListOptions{Spec: &pypy},
+			want:    []string{"pypy3.10\t│ " + pypyPath},
+			wantErr: false,
+		},
+		{
+			name: "json",
+			opts: ListOptions{Spec: &pypy, JSON: true},
+			want: []string{
+				"[",
+				"  {",
+				`    "path": "` + pypyPath + `",`,
+				`    "implementation": "pypy",`,
+				`    "version": "3.10",`,
+				`    "major": 3,`,
+				`    "minor": 10,`,
+				`    "patch": null`,
+				"  }",
+				"]",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "nothing matches",
+			opts:    ListOptions{Spec: &interpreter.Spec{Major: 4, Minor: interpreter.Any, Patch: interpreter.Any}},
+			want:    []string{""},
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			app := &App{Stdout: os.Stdout, Logger: logrus.New()}
-			if got := app.parseShebang(tt.shebang); got != tt.want {
-				t.Errorf("got %s, wanted %s", got, tt.want)
+			stdout := &bytes.Buffer{}
+			app := newTestApp(stdout, &bytes.Buffer{}, fixture.PythonPath(".."))
+
+			if err := app.List(tt.opts); (err != nil) != tt.wantErr {
+				t.Fatalf("List() err = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			got := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
 			}
 		})
 	}
 }
 
-func Test_deDupe(t *testing.T) {
-	type args struct {
-		paths []string
+func TestApp_ListVerbose(t *testing.T) {
+	pypyPath, err := filepath.Abs(filepath.Join("..", "interpreter", "testdata", "pythonpaths", "pythonpath2", "pypy3.10"))
+	if err != nil {
+		t.Fatalf("could not get absolute path: %v", err)
 	}
+	pypy := interpreter.Spec{Implementation: interpreter.PyPy, Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any}
+
+	// Later on $PATH, so it's the alias
+	bin := t.TempDir()
+	alias := filepath.Join(bin, "pypy3.10")
+	if err := os.Symlink(pypyPath, alias); err != nil {
+		t.Fatalf("could not symlink: %v", err)
+	}
+	path := fixture.PythonPath("..") + string(os.PathListSeparator) + bin
+
 	tests := []struct {
 		name string
-		args args
-		want []string
+		opts ListOptions
+		want string
 	}{
 		{
-			name: "only 1 path",
-			args: args{paths: []string{"its/just/me/here"}},
-			want: []string{"its/just/me/here"},
+			name: "quiet",
+			opts: ListOptions{Spec: &pypy},
+			want: "pypy3.10\t│ " + pypyPath + "\n",
 		},
 		{
-			name: "3 different paths",
-			args: args{paths: []string{"a/path", "another/path", "athird/path"}},
-			want: []string{"a/path", "another/path", "athird/path"},
-		},
-		{
-			name: "1 unique, 2 duplicates",
-			args: args{paths: []string{"a/path", "a/path", "aunique/path"}},
-			want: []string{"a/path", "aunique/path"},
-		},
-		{
-			name: "all duplicates",
-			args: args{paths: []string{"a/path", "a/path", "a/path"}},
-			want: []string{"a/path"},
+			name: "verbose",
+			opts: ListOptions{Spec: &pypy, Verbose: true},
+			want: "pypy3.10\t│ " + pypyPath + "\n\t│   also " + alias + "\n",
 		},
 	}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := deDupe(tt.args.paths); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("deDupe() = %v, want %v", got, tt.want)
+			stdout := &bytes.Buffer{}
+			app := newTestApp(stdout, &bytes.Buffer{}, path)
+
+			if err := app.List(tt.opts); err != nil {
+				t.Fatalf("List() returned an unexpected error: %v", err)
+			}
+
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("got %q, wanted %q", got, tt.want)
 			}
 		})
 	}
-}
 
-// Not really necessary but I was just curious and it was easy to do.
-func Benchmark_deDupe(b *testing.B) {
-	// Some paths that contain duplicates
-	paths := []string{
-		"/usr/local/bin/python3.7",
-		"/usr/local/bin/python3.8",
-		"/usr/local/bin/python3.9",
-		"/usr/local/bin/python3.6",
-		"/usr/local/bin/python3.10",
-		"/usr/local/bin/python3.11",
-		"/usr/bin/python3.7",
-		"/usr/bin/python3.8",
-		"/usr/bin/python2.7",
-		"/usr/bin/python",
-		"/usr/bin/python2",
-		"/usr/bin/python3",
-		"/usr/bin/python2",
-		"/usr/bin/python",
-		"/usr/bin/python",
-		"/usr/bin/python",
-		"/usr/bin/python3",
-		"/usr/local/bin/python3.11",
-		"/usr/local/bin/python3.9",
-		"/usr/local/bin/python3.9",
-		"/usr/local/bin/python3.6",
-	}
-
-	// Reset prior to actually running the benchmark
-	// ensures we don't include the initialisation stuff
-	b.ResetTimer()
-
-	for n := 0; n < b.N; n++ {
-		deDupe(paths)
+	stdout := &bytes.Buffer{}
+	app := newTestApp(stdout, &bytes.Buffer{}, path)
+	if err := app.List(ListOptions{Spec: &pypy, JSON: true, Verbose: true}); err != nil {
+		t.Fatalf("List() returned an unexpected error: %v", err)
+	}
+
+	var entries []listEntry
+	if err := json.Unmarshal(stdout.Bytes(), &entries); err != nil {
+		t.Fatalf("could not decode JSON: %v", err)
+	}
+
+	if len(entries) != 1 || !reflect.DeepEqual(entries[0].Aliases, []string{alias}) {
+		t.Errorf("expected one entry with aliases %v, got %#v", []string{alias}, entries)
 	}
 }
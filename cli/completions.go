@@ -0,0 +1,313 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// completionOption is a flag or option offered by the shell completions.
+type completionOption struct {
+	name        string // The option as typed e.g. "--list" or "-m"
+	description string // Short description shown by shells that support them
+}
+
+// Flag is one of py's own command line flags.
+type Flag struct {
+	Name        string // The flag as typed e.g. "--list"
+	Description string // Short description shown by shells that support them
+	Hidden      bool   // Left out of the shell completions e.g. --list-specifiers
+}
+
+// flags are all of py's own flags, the command line only accepts these and the shell
+// completions offer all but the hidden ones, so the two can't disagree.
+var flags = [...]Flag{
+	{Name: "--help", Description: "Help for py"},
+	{Name: "--list", Description: "List all found python interpreters on $PATH"},
+	{Name: "--json", Description: "Print --list or --version as JSON"},
+	{Name: "--verbose", Description: "Show the symlinks to each interpreter in --list"},
+	{Name: "--impl", Description: "Launch a specific python implementation"},
+	{Name: "--pre", Description: "Allow pre-release pythons to be selected"},
+	{Name: "--doctor", Description: "Check the environment for problems"},
+	{Name: "--venv", Description: "Create a virtual environment"},
+	{Name: "--force", Description: "Replace an existing virtual environment with --venv"},
+	{Name: "--upgrade-pip", Description: "Upgrade pip in the virtual environment from --venv"},
+	{Name: "--all", Description: "Run python with every interpreter found"},
+	{Name: "--parallel", Description: "Run every interpreter at once with --all"},
+	{Name: "--pick", Description: "Pick which python to launch from a list"},
+	{Name: "--remember", Description: "Select the python from --pick by default next time"},
+	{Name: "--explain", Description: "Explain how the python would be found"},
+	{Name: "--completions", Description: "Print a shell completion script"},
+	{Name: "--list-specifiers", Description: "List the specifiers of every python found", Hidden: true},
+	{Name: "--version", Description: "Print py's version and build information"},
+}
+
+// Flags returns all of py's own flags, including the hidden ones.
+func Flags() []Flag {
+	return slices.Clone(flags[:])
+}
+
+// pyOptions returns py's own flags offered by the shell completions, only completed
+// as the first argument (after --pre and --explain).
+func pyOptions() []completionOption {
+	options := make([]completionOption, 0, len(flags))
+	for _, flag := range flags {
+		if !flag.Hidden {
+			options = append(options, completionOption{name: flag.Name, description: flag.Description})
+		}
+	}
+	return options
+}
+
+// pythonOptions are python's own command line options, completed once we're past py's arguments.
+var pythonOptions = [...]completionOption{
+	{name: "-b", description: "Issue warnings about bytes/str comparisons"},
+	{name: "-B", description: "Don't write .pyc files"},
+	{name: "-c", description: "Program passed in as string"},
+	{name: "-d", description: "Turn on parser debugging output"},
+	{name: "-E", description: "Ignore PYTHON* environment variables"},
+	{name: "-h", description: "Print python's help message"},
+	{name: "-i", description: "Inspect interactively after running script"},
+	{name: "-I", description: "Isolate python from the user's environment"},
+	{name: "-m", description: "Run library module as a script"},
+	{name: "-O", description: "Remove assert and __debug__ dependent statements"},
+	{name: "-OO", description: "Like -O and also discard docstrings"},
+	{name: "-P", description: "Don't prepend a potentially unsafe path to sys.path"},
+	{name: "-q", description: "Don't print version and copyright messages"},
+	{name: "-s", description: "Don't add user site directory to sys.path"},
+	{name: "-S", description: "Don't imply 'import site' on initialization"},
+	{name: "-u", description: "Force the stdout and stderr streams to be unbuffered"},
+	{name: "-v", description: "Verbose, trace import statements"},
+	{name: "-V", description: "Print the python version number and exit"},
+	{name: "-W", description: "Warning control"},
+	{name: "-x", description: "Skip first line of source"},
+	{name: "-X", description: "Set implementation-specific option"},
+}
+
+// completionShells are the shells we can generate completion scripts for.
+var completionShells = [...]string{"bash", "zsh", "fish"}
+
+// Completions prints the completion script for 'shell' (bash, zsh or fish).
+//
+// The scripts complete py's flags and the version specifiers of the interpreters
+// actually installed, by calling py --list-specifiers, then python's own options and filenames.
+func (a *App) Completions(shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion()
+	case "zsh":
+		script = zshCompletion()
+	case "fish":
+		script = fishCompletion()
+	default:
+		return fmt.Errorf("unsupported shell %q for completions, must be one of %s", shell, strings.Join(completionShells[:], ", "))
+	}
+
+	fmt.Fprint(a.Stdout, script)
+	return nil
+}
+
+// ListSpecifiers prints the version and executable specifiers that would select
+// each of the interpreters on $PATH, one per line, latest first e.g. "-3", "-3.12" and "pypy3.10".
+//
+// It's used by the shell completion scripts so is deliberately left out of the help.
+func (a *App) ListSpecifiers() error {
+	interpreters, err := a.find(nil)
+	if err != nil {
+		// Nothing installed just means nothing to complete
+		if errors.Is(err, ErrNoInterpreter) {
+			return nil
+		}
+		return err
+	}
+
+	for _, specifier := range specifiers(interpreters) {
+		fmt.Fprintln(a.Stdout, specifier)
+	}
+
+	return nil
+}
+
+// specifiers returns the de-duplicated specifiers for 'interpreters', keeping their order.
+func specifiers(interpreters []interpreter.Interpreter) []string {
+	var specs []string
+	seen := make(map[string]bool)
+	add := func(spec string) {
+		if !seen[spec] {
+			seen[spec] = true
+			specs = append(specs, spec)
+		}
+	}
+
+	for _, python := range interpreters {
+		spec := interpreter.Spec{
+			Implementation: python.Implementation,
+			ABIFlags:       python.ABIFlags,
+			Major:          python.Major,
+			Minor:          python.Minor,
+			Patch:          interpreter.Any,
+		}
+
+		if python.Implementation != interpreter.CPython {
+			add(spec.Executable())
+			continue
+		}
+
+		if python.ABIFlags == "" {
+			add(fmt.Sprintf("-%d", python.Major))
+		}
+		add("-" + spec.String())
+	}
+
+	return specs
+}
+
+// optionNames returns just the names of 'options', space separated.
+func optionNames(options []completionOption) string {
+	names := make([]string, 0, len(options))
+	for _, option := range options {
+		names = append(names, option.name)
+	}
+	return strings.Join(names, " ")
+}
+
+// implementationNames returns the names of the implementations py can find, space separated.
+func implementationNames() string {
+	return strings.Join([]string{interpreter.CPython, interpreter.PyPy, interpreter.GraalPy, interpreter.Pyston}, " ")
+}
+
+// bashCompletion returns the bash completion script.
+func bashCompletion() string {
+	return fmt.Sprintf(`# bash completion for py, load with: source <(py --completions bash)
+_py() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+    local i words=""
+
+    case "$prev" in
+        --impl) COMPREPLY=($(compgen -W "%[1]s" -- "$cur")); return ;;
+        --completions) COMPREPLY=($(compgen -W "%[2]s" -- "$cur")); return ;;
+    esac
+
+    # py's own flags and specifiers only come first, after --pre and --explain
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            --pre|--explain) ;;
+            *) break ;;
+        esac
+    done
+    if ((i == COMP_CWORD)); then
+        words="%[3]s $(py --list-specifiers 2>/dev/null)"
+    fi
+
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "$words %[4]s" -- "$cur"))
+    else
+        COMPREPLY=($(compgen -W "$words" -- "$cur") $(compgen -f -- "$cur"))
+    fi
+}
+complete -o filenames -F _py py
+`, implementationNames(), strings.Join(completionShells[:], " "), optionNames(pyOptions()), optionNames(pythonOptions[:]))
+}
+
+// zshCompletion returns the zsh completion script.
+func zshCompletion() string {
+	s := &strings.Builder{}
+	s.WriteString(`#compdef py
+# zsh completion for py, load with: source <(py --completions zsh)
+_py() {
+    local -a flags specifiers pyopts
+    flags=(
+`)
+	for _, option := range pyOptions() {
+		fmt.Fprintf(s, "        %s\n", shellQuote(option.name+":"+option.description))
+	}
+	s.WriteString("    )\n    pyopts=(\n")
+	for _, option := range pythonOptions {
+		fmt.Fprintf(s, "        %s\n", shellQuote(option.name+":"+option.description))
+	}
+	fmt.Fprintf(s, `    )
+
+    case "${words[CURRENT-1]}" in
+        --impl) compadd -- %[1]s; return ;;
+        --completions) compadd -- %[2]s; return ;;
+    esac
+
+    # py's own flags and specifiers only come first, after --pre and --explain
+    local i=2
+    while (( i < CURRENT )) && [[ "${words[i]}" == (--pre|--explain) ]]; do
+        (( i++ ))
+    done
+    if (( i == CURRENT )); then
+        specifiers=(${(f)"$(py --list-specifiers 2>/dev/null)"})
+        _describe -t flags 'py flags' flags
+        compadd -a specifiers
+    fi
+
+    _describe -t options 'python options' pyopts
+    _files
+}
+
+if [ "$funcstack[1]" = "_py" ]; then
+    _py "$@"
+else
+    compdef _py py
+fi
+`, implementationNames(), strings.Join(completionShells[:], " "))
+
+	return s.String()
+}
+
+// fishCompletion returns the fish completion script.
+func fishCompletion() string {
+	s := &strings.Builder{}
+	s.WriteString(`# fish completion for py, load with: py --completions fish | source
+
+# py's own flags and specifiers only come first, after --pre and --explain
+function __py_at_start
+    for token in (commandline -opc)[2..-1]
+        contains -- $token --pre --explain; or return 1
+    end
+end
+
+complete -c py -n __py_at_start -a "(py --list-specifiers 2>/dev/null)" -d "Installed python"
+`)
+	for _, option := range pyOptions() {
+		name := strings.TrimPrefix(option.name, "--")
+		switch name {
+		case "impl":
+			fmt.Fprintf(s, "complete -c py -n __py_at_start -l %s -x -a %q -d %s\n", name, implementationNames(), fishQuote(option.description))
+		case "completions":
+			fmt.Fprintf(s, "complete -c py -n __py_at_start -l %s -x -a %q -d %s\n", name, strings.Join(completionShells[:], " "), fishQuote(option.description))
+		default:
+			fmt.Fprintf(s, "complete -c py -n __py_at_start -l %s -d %s\n", name, fishQuote(option.description))
+		}
+	}
+
+	s.WriteString("\n# python's own options\n")
+	for _, option := range pythonOptions {
+		switch {
+		case strings.HasPrefix(option.name, "--"):
+			fmt.Fprintf(s, "complete -c py -l %s -d %s\n", strings.TrimPrefix(option.name, "--"), fishQuote(option.description))
+		case len(option.name) == 2: //nolint: mnd // "-" and a single letter
+			fmt.Fprintf(s, "complete -c py -s %s -d %s\n", strings.TrimPrefix(option.name, "-"), fishQuote(option.description))
+		default:
+			fmt.Fprintf(s, "complete -c py -o %s -d %s\n", strings.TrimPrefix(option.name, "-"), fishQuote(option.description))
+		}
+	}
+
+	return s.String()
+}
+
+// shellQuote single quotes 's' for bash or zsh so nothing in it is expanded.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single quotes 's' for fish so nothing in it is expanded.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
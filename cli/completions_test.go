@@ -0,0 +1,94 @@
+package cli //nolint: testpackage // Need access to internals
+
+import (
+	"bytes"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+func TestApp_Completions(t *testing.T) {
+	tests := []struct {
+		name    string
+		shell   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:    "bash",
+			shell:   "bash",
+			want:    []string{"complete -o filenames -F _py py", "py --list-specifiers", "--venv", "--version"},
+			wantErr: false,
+		},
+		{
+			name:    "zsh",
+			shell:   "zsh",
+			want:    []string{"#compdef py", "py --list-specifiers", "'--list:List all found python interpreters on $PATH'", `'-S:Don'\''t imply`},
+			wantErr: false,
+		},
+		{
+			name:    "fish",
+			shell:   "fish",
+			want:    []string{"py --list-specifiers", "-l impl -x -a \"cpython pypy graalpy pyston\"", `-s S -d 'Don\'t imply`, "-o OO"},
+			wantErr: false,
+		},
+		{
+			name:    "unknown shell",
+			shell:   "powershell",
+			want:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			app := newTestApp(out, &bytes.Buffer{}, "")
+
+			if err := app.Completions(tt.shell); (err != nil) != tt.wantErr {
+				t.Fatalf("Completions() err = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("%s completions missing %q\n%s", tt.shell, want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_pyOptions(t *testing.T) {
+	var got []string
+	for _, option := range pyOptions() {
+		got = append(got, option.name)
+	}
+
+	for _, want := range []string{"--force", "--upgrade-pip", "--parallel", "--remember"} {
+		if !slices.Contains(got, want) {
+			t.Errorf("completions missing %s: %v", want, got)
+		}
+	}
+
+	if slices.Contains(got, "--list-specifiers") {
+		t.Errorf("completions offer hidden --list-specifiers: %v", got)
+	}
+}
+
+func Test_specifiers(t *testing.T) {
+	interpreters := []interpreter.Interpreter{
+		{Implementation: interpreter.CPython, ABIFlags: "t", Major: 3, Minor: 13},
+		{Implementation: interpreter.CPython, Major: 3, Minor: 12},
+		{Implementation: interpreter.CPython, Major: 3, Minor: 12, Patch: 4},
+		{Implementation: interpreter.CPython, Major: 3, Minor: 11},
+		{Implementation: interpreter.PyPy, Major: 3, Minor: 10},
+	}
+
+	want := []string{"-3.13t", "-3", "-3.12", "-3.11", "pypy3.10"}
+	if got := specifiers(interpreters); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
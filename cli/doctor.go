@@ -0,0 +1,314 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/FollowTheProcess/py/interpreter"
+	"github.com/FollowTheProcess/py/resolve"
+)
+
+const (
+	levelError   = "error"   // A finding that will stop py (or python) working properly
+	levelWarning = "warning" // A finding that might mean you get the wrong python
+)
+
+// finding is a single problem found by the doctor.
+type finding struct {
+	level   string // How bad it is, levelError or levelWarning
+	message string // What the problem is
+	fix     string // What to do about it
+}
+
+// Doctor inspects the environment py runs in for anything that could result in the
+// wrong python being launched, printing each finding along with how to fix it.
+//
+// If any of the findings are errors, an error is returned so the exit status can be used in CI.
+func (a *App) Doctor() error {
+	cwd, err := a.cwd()
+	if err != nil {
+		return err
+	}
+
+	findings := a.diagnose(cwd)
+
+	var errs, warnings int
+	for _, f := range findings {
+		switch f.level {
+		case levelError:
+			errs++
+		case levelWarning:
+			warnings++
+		}
+		fmt.Fprintf(a.Stdout, "%s: %s\n", f.level, f.message)
+		if f.fix != "" {
+			fmt.Fprintf(a.Stdout, "    fix: %s\n", f.fix)
+		}
+	}
+
+	if len(findings) == 0 {
+		fmt.Fprintln(a.Stdout, "No problems found")
+		return nil
+	}
+
+	fmt.Fprintf(a.Stdout, "\nFound %d error(s) and %d warning(s)\n", errs, warnings)
+	if errs != 0 {
+		return fmt.Errorf("doctor found %d error(s)", errs)
+	}
+
+	return nil
+}
+
+// diagnose runs all the doctor's checks for the project in 'cwd', returning what it finds.
+func (a *App) diagnose(cwd string) []finding {
+	var findings []finding
+	findings = append(findings, a.checkPath()...)
+	findings = append(findings, a.checkVirtualEnv()...)
+	findings = append(findings, a.checkPyPython()...)
+	findings = append(findings, checkCwdVenvs(cwd)...)
+	findings = append(findings, a.checkVersionPins(cwd)...)
+
+	return findings
+}
+
+// checkPath looks through $PATH for duplicate and unreadable entries, dangling
+// interpreter symlinks and shims (e.g. pyenv's) shadowing real interpreters.
+func (a *App) checkPath() []finding {
+	var findings []finding
+
+	seen := make(map[string]int)
+	var order []string
+	for _, dir := range filepath.SplitList(a.Path) {
+		if dir == "" {
+			dir = "."
+		}
+		if seen[dir] == 0 {
+			order = append(order, dir)
+		}
+		seen[dir]++
+	}
+
+	// Where we first saw each interpreter name, to spot shadowing
+	firstSeen := make(map[string]string)
+	shadowed := make(map[string]bool)
+
+	for _, dir := range order {
+		if count := seen[dir]; count > 1 {
+			findings = append(findings, finding{
+				level:   levelWarning,
+				message: fmt.Sprintf("$PATH contains %s %d times", dir, count),
+				fix:     "remove the duplicate entries from $PATH",
+			})
+		}
+
+		// py skips these, see pathEntries in the resolve package
+		if strings.HasPrefix(dir, "/var/run") {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			findings = append(findings, finding{
+				level:   levelError,
+				message: fmt.Sprintf("$PATH entry %s cannot be read: %v", dir, err),
+				fix:     "remove it from $PATH or fix it's permissions",
+			})
+			continue
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			python := interpreter.Interpreter{}
+			if err := python.FromFilePath(path); err != nil {
+				continue
+			}
+
+			if entry.Type()&fs.ModeSymlink != 0 {
+				if _, err := os.Stat(path); err != nil {
+					target, _ := os.Readlink(path) //nolint: errcheck // Only used in the message
+					findings = append(findings, finding{
+						level:   levelWarning,
+						message: fmt.Sprintf("%s is a dangling symlink to %s", path, target),
+						fix:     "remove the symlink or reinstall the python it pointed to",
+					})
+					continue
+				}
+			}
+
+			first, ok := firstSeen[entry.Name()]
+			if !ok {
+				firstSeen[entry.Name()] = dir
+				continue
+			}
+
+			if isShimDir(first) && !isShimDir(dir) && !shadowed[entry.Name()] {
+				shadowed[entry.Name()] = true
+				findings = append(findings, finding{
+					level:   levelWarning,
+					message: fmt.Sprintf("%s in %s is a shim shadowing %s", entry.Name(), first, path),
+					fix:     fmt.Sprintf("check the shim resolves to the python you expect, or move %s earlier in $PATH", dir),
+				})
+			}
+		}
+	}
+
+	return findings
+}
+
+// checkVirtualEnv checks that $VIRTUAL_ENV, if set, is a working virtual environment.
+func (a *App) checkVirtualEnv() []finding {
+	path := a.getenv(vitualEnvKey)
+	if path == "" {
+		return nil
+	}
+
+	if !exists(filepath.Join(path, "bin", "python")) {
+		return []finding{{
+			level:   levelError,
+			message: fmt.Sprintf("$VIRTUAL_ENV is %s which is not a working virtual environment", path),
+			fix:     "run deactivate, or recreate it with py --venv --force",
+		}}
+	}
+
+	return nil
+}
+
+// checkPyPython checks that $PY_PYTHON, if set, is valid and refers to an installed python.
+func (a *App) checkPyPython() []finding {
+	version := a.getenv(pyPythonEnvKey)
+	if version == "" {
+		return nil
+	}
+
+	major, minor, err := resolve.ParsePyPython(version)
+	if err != nil {
+		return []finding{{
+			level:   levelError,
+			message: fmt.Sprintf("$PY_PYTHON is %q: %v", version, err),
+			fix:     "set it to an X.Y version e.g. 3.12",
+		}}
+	}
+
+	// Unreadable $PATH entries are reported by checkPath
+	spec := interpreter.Spec{Major: major, Minor: minor, Patch: interpreter.Any}
+	opts := resolve.Options{Logger: a.Logger, Path: a.Path, PreRelease: a.PreRelease, Spec: &spec}
+	var noMatch *NoMatchError
+	if _, err := resolve.Resolve(context.Background(), opts); errors.As(err, &noMatch) || errors.Is(err, ErrNoInterpreter) {
+		return []finding{{
+			level:   levelError,
+			message: fmt.Sprintf("$PY_PYTHON asks for %s but it isn't installed", spec.Executable()),
+			fix:     fmt.Sprintf("install python %s or change $PY_PYTHON to an installed version (see py --list)", spec),
+		}}
+	}
+
+	return nil
+}
+
+// checkCwdVenvs checks any virtual environments in 'cwd' that py would launch are working.
+func checkCwdVenvs(cwd string) []finding {
+	var findings []finding
+	for _, name := range [...]string{".venv", "venv"} {
+		dir := filepath.Join(cwd, name)
+		if !exists(filepath.Join(dir, pyvenvCfgFile)) {
+			continue
+		}
+
+		if !exists(filepath.Join(dir, "bin", "python")) {
+			findings = append(findings, finding{
+				level:   levelError,
+				message: fmt.Sprintf("virtual environment %s is broken, it's python no longer exists", dir),
+				fix:     fmt.Sprintf("recreate it with py --venv --force %s", name),
+			})
+		}
+	}
+
+	return findings
+}
+
+// checkVersionPins checks the python versions pinned by the project in 'cwd'
+// (.python-version and $PY_PYTHON) agree with it's pyproject.toml requires-python.
+func (a *App) checkVersionPins(cwd string) []finding {
+	var findings []finding
+
+	requires, err := readRequiresPython(filepath.Join(cwd, pyprojectFile))
+	if errors.Is(err, errParsePyproject) {
+		// py's TOML parser only understands what a pyproject.toml usually contains, so
+		// this may be py's fault rather than the file's
+		return []finding{{
+			level:   levelWarning,
+			message: fmt.Sprintf("skipped checking version pins against requires-python, %v", err),
+			fix:     fmt.Sprintf("check %s is valid TOML, if it is this is a bug in py", pyprojectFile),
+		}}
+	}
+	if err != nil {
+		return []finding{{level: levelError, message: err.Error(), fix: fmt.Sprintf(`make %s a PEP 440 version specifier string e.g. ">=3.10"`, requiresPythonKey)}}
+	}
+
+	pinned, ok, err := readPythonVersionFile(filepath.Join(cwd, pythonVersionFile))
+	if err != nil {
+		return []finding{{level: levelError, message: err.Error()}}
+	}
+
+	if requires == "" {
+		return nil
+	}
+
+	constraint, err := interpreter.ParseConstraint(requires)
+	if err != nil {
+		return []finding{{
+			level:   levelError,
+			message: fmt.Sprintf("%s has an invalid requires-python: %v", pyprojectFile, err),
+			fix:     "use a PEP 440 version specifier e.g. >=3.10",
+		}}
+	}
+
+	pins := make(map[string]interpreter.Spec)
+	if ok {
+		pins[pythonVersionFile] = pinned
+	}
+	if version := a.getenv(pyPythonEnvKey); version != "" {
+		if spec, err := interpreter.ParseSpec(version); err == nil {
+			pins["$"+pyPythonEnvKey] = spec
+		}
+	}
+
+	names := make([]string, 0, len(pins))
+	for name := range pins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		spec := pins[name]
+		if spec.Minor == interpreter.Any {
+			continue
+		}
+
+		patch := spec.Patch
+		if patch == interpreter.Any {
+			patch = interpreter.Unknown
+		}
+
+		if !constraint.Allows(interpreter.Interpreter{Major: spec.Major, Minor: spec.Minor, Patch: patch}) {
+			findings = append(findings, finding{
+				level:   levelWarning,
+				message: fmt.Sprintf("%s pins python %s but %s requires-python is %q", name, spec, pyprojectFile, requires),
+				fix:     fmt.Sprintf("change %s to a version allowed by requires-python", name),
+			})
+		}
+	}
+
+	return findings
+}
+
+// isShimDir reports whether 'dir' looks like a version manager's shim directory
+// e.g. ~/.pyenv/shims or ~/.asdf/shims.
+func isShimDir(dir string) bool {
+	return filepath.Base(dir) == "shims"
+}
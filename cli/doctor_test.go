@@ -0,0 +1,227 @@
+package cli //nolint: testpackage // Need access to internals
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApp_diagnose(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, root string, env map[string]string) (path string) // Builds the environment under root and returns $PATH
+		want  []string                                                             // "level: message substring" of each expected finding
+	}{
+		{
+			name: "healthy",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+			},
+			want: nil,
+		},
+		{
+			name: "duplicate path entries",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				bin := fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+				return strings.Join([]string{bin, bin}, string(os.PathListSeparator))
+			},
+			want: []string{"warning: $PATH contains " + "%root%/bin 2 times"},
+		},
+		{
+			name: "missing path entry",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				return filepath.Join(root, "missing")
+			},
+			want: []string{"error: $PATH entry %root%/missing cannot be read"},
+		},
+		{
+			name: "shim shadowing",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				shims := fakeBin(t, filepath.Join(root, ".pyenv", "shims"), "python3.12")
+				bin := fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+				return strings.Join([]string{shims, bin}, string(os.PathListSeparator))
+			},
+			want: []string{"warning: python3.12 in %root%/.pyenv/shims is a shim shadowing %root%/bin/python3.12"},
+		},
+		{
+			name: "dangling symlink",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				bin := fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+				if err := os.Symlink(filepath.Join(root, "gone", "python3.11"), filepath.Join(bin, "python3.11")); err != nil {
+					t.Fatalf("could not symlink: %v", err)
+				}
+				return bin
+			},
+			want: []string{"warning: %root%/bin/python3.11 is a dangling symlink to %root%/gone/python3.11"},
+		},
+		{
+			name: "broken VIRTUAL_ENV",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				env["VIRTUAL_ENV"] = filepath.Join(root, "old-venv")
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+			},
+			want: []string{"error: $VIRTUAL_ENV is %root%/old-venv which is not a working virtual environment"},
+		},
+		{
+			name: "malformed PY_PYTHON",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				env["PY_PYTHON"] = "3"
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+			},
+			want: []string{`error: $PY_PYTHON is "3"`},
+		},
+		{
+			name: "PY_PYTHON not installed",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				env["PY_PYTHON"] = "3.9"
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+			},
+			want: []string{"error: $PY_PYTHON asks for python3.9 but it isn't installed"},
+		},
+		{
+			name: "broken venv in cwd",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				mustMkdir(t, filepath.Join(root, ".venv", "bin"))
+				writeFile(t, filepath.Join(root, ".venv", pyvenvCfgFile), "")
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+			},
+			want: []string{"error: virtual environment %root%/.venv is broken"},
+		},
+		{
+			name: "conflicting pins",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				env["PY_PYTHON"] = "3.9"
+				writeFile(t, filepath.Join(root, pyprojectFile), "[project]\nrequires-python = \">=3.10\"\n")
+				writeFile(t, filepath.Join(root, pythonVersionFile), "3.8\n")
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.9")
+			},
+			want: []string{
+				`warning: $PY_PYTHON pins python 3.9 but pyproject.toml requires-python is ">=3.10"`,
+				`warning: .python-version pins python 3.8 but pyproject.toml requires-python is ">=3.10"`,
+			},
+		},
+		{
+			name: "realistic pyproject",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				writeFile(t, filepath.Join(root, pyprojectFile), `[project]
+name = "thing"
+description = """
+Does things.
+"""
+license = {text = "MIT"}
+authors = [{name = "A. Person", email = "a@example.com"}]
+requires-python = ">=3.10"
+`)
+				writeFile(t, filepath.Join(root, pythonVersionFile), "3.12\n")
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+			},
+			want: nil,
+		},
+		{
+			name: "unparseable pyproject",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				writeFile(t, filepath.Join(root, pyprojectFile), "[project\nrequires-python = \">=3.10\"\n")
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+			},
+			want: []string{"warning: skipped checking version pins against requires-python, could not parse %root%/pyproject.toml"},
+		},
+		{
+			name: "requires-python not a string",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				writeFile(t, filepath.Join(root, pyprojectFile), "[project]\nrequires-python = [\">=3.10\"]\n")
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+			},
+			want: []string{"error: invalid %root%/pyproject.toml: requires-python must be a string"},
+		},
+		{
+			name: "agreeing pins",
+			setup: func(t *testing.T, root string, env map[string]string) string {
+				t.Helper()
+				writeFile(t, filepath.Join(root, pyprojectFile), "[project]\nrequires-python = \">=3.10\"\n")
+				writeFile(t, filepath.Join(root, pythonVersionFile), "3.12.4\n")
+				return fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			env := make(map[string]string)
+			path := tt.setup(t, root, env)
+
+			app := newTestApp(&bytes.Buffer{}, &bytes.Buffer{}, path)
+			app.Getenv = testEnv(env)
+			findings := app.diagnose(root)
+
+			if len(findings) != len(tt.want) {
+				t.Fatalf("got %d findings, wanted %d: %#v", len(findings), len(tt.want), findings)
+			}
+
+			for i, f := range findings {
+				got := f.level + ": " + f.message
+				want := strings.ReplaceAll(tt.want[i], "%root%", root)
+				if !strings.HasPrefix(got, want) {
+					t.Errorf("finding %d: got %q, wanted it to start with %q", i, got, want)
+				}
+				if f.fix == "" {
+					t.Errorf("finding %d has no fix", i)
+				}
+			}
+		})
+	}
+}
+
+func TestApp_Doctor(t *testing.T) {
+	root := t.TempDir()
+	bin := fakeBin(t, filepath.Join(root, "bin"), "python3.12")
+
+	stdout := &bytes.Buffer{}
+	app := newTestApp(stdout, &bytes.Buffer{}, bin)
+	app.Dir = root
+	if err := app.Doctor(); err != nil {
+		t.Fatalf("Doctor() returned an unexpected error on a healthy environment: %v", err)
+	}
+
+	if got := stdout.String(); got != "No problems found\n" {
+		t.Errorf("got %q, wanted %q", got, "No problems found\n")
+	}
+
+	// Warnings alone don't fail
+	stdout.Reset()
+	app.Path = strings.Join([]string{bin, bin}, string(os.PathListSeparator))
+	if err := app.Doctor(); err != nil {
+		t.Fatalf("Doctor() returned an error for warnings only: %v", err)
+	}
+
+	if !strings.Contains(stdout.String(), "Found 0 error(s) and 1 warning(s)") {
+		t.Errorf("missing summary in %q", stdout.String())
+	}
+
+	// Errors do
+	stdout.Reset()
+	app.Path = filepath.Join(root, "missing")
+	if err := app.Doctor(); err == nil {
+		t.Fatal("Doctor() should return an error when it finds errors")
+	}
+
+	if !strings.Contains(stdout.String(), "    fix: ") {
+		t.Errorf("missing fix in %q", stdout.String())
+	}
+}
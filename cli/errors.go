@@ -0,0 +1,13 @@
+package cli
+
+import "github.com/FollowTheProcess/py/resolve"
+
+// ErrNoInterpreter is returned when there are no usable python interpreters on $PATH, see resolve.ErrNoInterpreter.
+var ErrNoInterpreter = resolve.ErrNoInterpreter
+
+// The errors py returns for each way it can fail to find a python, see package resolve for details.
+type (
+	NoMatchError      = resolve.NoMatchError
+	MalformedEnvError = resolve.MalformedEnvError
+	BrokenVenvError   = resolve.BrokenVenvError
+)
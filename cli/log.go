@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/FollowTheProcess/py/resolve"
+)
+
+// debugJSON is the value of $PYLAUNCH_DEBUG that switches the debug logs to JSON.
+const debugJSON = "json"
+
+// The keys used in py's structured debug logs, shared with package resolve so
+// the logs of a whole run can be searched and parsed consistently.
+const (
+	LogKeyStep           = resolve.LogKeyStep
+	LogKeyCommand        = resolve.LogKeyCommand
+	LogKeyArgs           = resolve.LogKeyArgs
+	LogKeySpecifier      = resolve.LogKeySpecifier
+	LogKeyInterpreter    = resolve.LogKeyInterpreter
+	LogKeyInterpreters   = resolve.LogKeyInterpreters
+	LogKeyEnv            = resolve.LogKeyEnv
+	LogKeyValue          = resolve.LogKeyValue
+	LogKeyPath           = resolve.LogKeyPath
+	LogKeyCwd            = resolve.LogKeyCwd
+	LogKeyVenv           = resolve.LogKeyVenv
+	LogKeyScript         = resolve.LogKeyScript
+	LogKeyShebang        = resolve.LogKeyShebang
+	LogKeyFlags          = resolve.LogKeyFlags
+	LogKeyVersion        = resolve.LogKeyVersion
+	LogKeyRequiresPython = resolve.LogKeyRequiresPython
+	LogKeyDependencies   = resolve.LogKeyDependencies
+	LogKeyError          = resolve.LogKeyError
+	LogKeyInstallers     = resolve.LogKeyInstallers
+)
+
+// newLogger returns the debug logger writing to 'w' as configured by 'debug', the
+// value of $PYLAUNCH_DEBUG.
+//
+// If 'debug' is empty nothing is logged, "json" logs JSON lines for machines
+// and anything else logs human readable text.
+func newLogger(w io.Writer, debug string) *slog.Logger {
+	switch debug {
+	case "":
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	case debugJSON:
+		return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	default:
+		return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: withoutTime}))
+	}
+}
+
+// withoutTime drops the time from the human readable logs, it's just noise
+// for something that runs for a few milliseconds.
+func withoutTime(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return attr
+}
@@ -0,0 +1,70 @@
+package cli //nolint: testpackage // Need access to internals
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/py/resolve"
+)
+
+func Test_newLogger(t *testing.T) {
+	tests := []struct {
+		name  string
+		debug string
+		check func(t *testing.T, out string)
+	}{
+		{
+			name:  "off",
+			debug: "",
+			check: func(t *testing.T, out string) {
+				t.Helper()
+				if out != "" {
+					t.Errorf("expected no logs, got %q", out)
+				}
+			},
+		},
+		{
+			name:  "text",
+			debug: "1",
+			check: func(t *testing.T, out string) {
+				t.Helper()
+				want := "level=DEBUG msg=\"Found environment variable\" step=py-python env=PY_PYTHON value=3.12\n"
+				if out != want {
+					t.Errorf("got %q, wanted %q", out, want)
+				}
+			},
+		},
+		{
+			name:  "json",
+			debug: "json",
+			check: func(t *testing.T, out string) {
+				t.Helper()
+				var entry map[string]any
+				if err := json.Unmarshal([]byte(out), &entry); err != nil {
+					t.Fatalf("log %q is not JSON: %v", out, err)
+				}
+
+				for key, want := range map[string]string{"level": "DEBUG", "msg": "Found environment variable", LogKeyStep: string(resolve.ReasonPyPython), LogKeyEnv: pyPythonEnvKey, LogKeyValue: "3.12"} {
+					if got := entry[key]; got != want {
+						t.Errorf("%s: got %v, wanted %v", key, got, want)
+					}
+				}
+
+				if !strings.HasSuffix(out, "}\n") {
+					t.Errorf("expected a single JSON line, got %q", out)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			logger := newLogger(out, tt.debug)
+			logger.Debug("Found environment variable", LogKeyStep, resolve.ReasonPyPython, LogKeyEnv, pyPythonEnvKey, LogKeyValue, "3.12")
+			tt.check(t, out.String())
+		})
+	}
+}
@@ -0,0 +1,428 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/FollowTheProcess/py/internal/term"
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+const (
+	pickFile       = ".py-pick" // Where py --pick --remember keeps the python picked for a project
+	pickMaxVisible = 10         // How many pythons the interactive picker shows at once
+)
+
+// errPickCancelled is returned when the user backs out of picking a python.
+var errPickCancelled = errors.New("no python picked")
+
+// PickOptions configures what Pick offers and what it does with the choice.
+type PickOptions struct {
+	Spec     *interpreter.Spec // Only offer interpreters matching this, nil offers virtual environments and every interpreter
+	Remember bool              // Remember the choice for the project so it's picked by default next time
+}
+
+// pickItem is a python offered by Pick.
+type pickItem struct {
+	label string // What's shown and filtered on e.g. "3.12	│ /usr/bin/python3.12"
+	path  string // The python executable
+}
+
+// Pick offers the virtual environments and interpreters py can find (or only the interpreters
+// matching opts.Spec) in an interactive list, filtered by typing and moved through with the
+// arrow keys, then launches the one picked with 'args'.
+//
+// If a.Stdin isn't a terminal a numbered prompt is shown instead. The python remembered for the
+// project (see PickOptions.Remember) is selected to begin with.
+func (a *App) Pick(opts PickOptions, args []string) error {
+	cwd, err := a.cwd()
+	if err != nil {
+		return err
+	}
+
+	items, err := a.pickItems(cwd, opts.Spec)
+	if err != nil {
+		return err
+	}
+
+	selected := 0
+	if remembered := rememberedPick(cwd); remembered != "" {
+		if i := slices.IndexFunc(items, func(item pickItem) bool { return item.path == remembered }); i != -1 {
+			a.Logger.Debug("Found remembered python", LogKeyInterpreter, remembered)
+			a.explain("Selected %s remembered in %s", remembered, pickFile)
+			selected = i
+		}
+	}
+
+	choice, err := a.choose(items, selected)
+	if err != nil {
+		return err
+	}
+
+	python := items[choice].path
+	a.explain("Picked %s", python)
+
+	if opts.Remember {
+		if a.Explain {
+			fmt.Fprintf(a.Stdout, "Would remember %s in %s\n", python, filepath.Join(cwd, pickFile))
+		} else if err := rememberPick(cwd, python); err != nil {
+			return err
+		}
+	}
+
+	return a.launch(python, args)
+}
+
+// pickItems returns the pythons Pick offers for the project in 'cwd': the activated and cwd
+// virtual environments (unless there's a 'spec') then every interpreter matching 'spec', in the
+// order py would prefer them.
+func (a *App) pickItems(cwd string, spec *interpreter.Spec) ([]pickItem, error) {
+	var items []pickItem
+	if spec == nil {
+		if venv := a.getenv(vitualEnvKey); venv != "" {
+			if python := filepath.Join(venv, "bin", "python"); exists(python) {
+				items = append(items, pickItem{label: fmt.Sprintf("venv\t│ %s (activated)", python), path: python})
+			}
+		}
+
+		for _, name := range [...]string{".venv", "venv"} {
+			python := filepath.Join(cwd, name, "bin", "python")
+			if exists(python) && !slices.ContainsFunc(items, func(item pickItem) bool { return item.path == python }) {
+				items = append(items, pickItem{label: "venv\t│ " + python, path: python})
+			}
+		}
+	}
+
+	interpreters, err := a.find(spec)
+	if err != nil && (len(items) == 0 || !errors.Is(err, ErrNoInterpreter)) {
+		return nil, err
+	}
+
+	for _, python := range interpreters {
+		items = append(items, pickItem{label: python.ToString(), path: python.Path})
+	}
+
+	return items, nil
+}
+
+// choose has the user choose one of 'items', starting at 'selected', returning the index
+// of the one they chose.
+//
+// It's the interactive picker if a.Stdin is a terminal we can put in raw mode, otherwise
+// a numbered prompt.
+func (a *App) choose(items []pickItem, selected int) (int, error) {
+	stdin := a.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	if file, ok := stdin.(*os.File); ok && term.IsTerminal(file.Fd()) {
+		restore, err := term.MakeRaw(file.Fd())
+		if err == nil {
+			defer restore() //nolint: errcheck // Nothing useful to do if we can't
+			return runPicker(file, a.Stderr, items, selected)
+		}
+		a.Logger.Debug("Could not use the interactive picker", LogKeyError, err)
+	}
+
+	return promptPick(stdin, a.Stderr, items, selected)
+}
+
+// rememberedPick returns the python remembered for the project in 'cwd' by py --pick --remember,
+// or "" if there isn't one.
+func rememberedPick(cwd string) string {
+	contents, err := os.ReadFile(filepath.Join(cwd, pickFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
+}
+
+// rememberPick remembers 'python' as the one picked for the project in 'cwd'.
+func rememberPick(cwd, python string) error {
+	if err := os.WriteFile(filepath.Join(cwd, pickFile), []byte(python+"\n"), 0o644); err != nil { //nolint: gosec,mnd // Not a secret
+		return fmt.Errorf("could not remember %s: %w", python, err)
+	}
+	return nil
+}
+
+// promptPick lists 'items' numbered on 'out' and reads the number of the one wanted from 'in',
+// an empty answer chooses 'selected'.
+func promptPick(in io.Reader, out io.Writer, items []pickItem, selected int) (int, error) {
+	for i, item := range items {
+		marker := " "
+		if i == selected {
+			marker = "*"
+		}
+		fmt.Fprintf(out, "%s %d) %s\n", marker, i+1, item.label)
+	}
+	fmt.Fprintf(out, "Pick a python [1-%d] (default %d): ", len(items), selected+1)
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("could not read choice: %w", err)
+		}
+		if line == "" {
+			// Nothing to read at all e.g. stdin is /dev/null
+			return 0, errPickCancelled
+		}
+	}
+
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return selected, nil
+	}
+
+	choice, err := strconv.Atoi(line)
+	if err != nil || choice < 1 || choice > len(items) {
+		return 0, fmt.Errorf("invalid choice %q, pick a number from 1 to %d", line, len(items))
+	}
+
+	return choice - 1, nil
+}
+
+// runPicker runs the interactive picker over 'items', starting at 'selected', reading keys from
+// the (raw mode) terminal 'in' and drawing to 'out', returning the index of the item picked.
+func runPicker(in io.Reader, out io.Writer, items []pickItem, selected int) (int, error) {
+	p := newPicker(items, selected)
+	keys := bufio.NewReader(in)
+
+	p.render(out)
+	defer p.clear(out)
+
+	for {
+		k, r, err := readKey(keys)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, errPickCancelled
+			}
+			return 0, fmt.Errorf("could not read from the terminal: %w", err)
+		}
+
+		done, err := p.handle(k, r)
+		if err != nil {
+			return 0, err
+		}
+		if done {
+			return p.selected(), nil
+		}
+
+		p.render(out)
+	}
+}
+
+// key is a key press the picker understands.
+type key int
+
+const (
+	keyNone      key = iota // Anything the picker ignores
+	keyRune                 // A printable character, added to the filter
+	keyUp                   // Up arrow or ctrl+p
+	keyDown                 // Down arrow or ctrl+n
+	keyBackspace            // Backspace, deletes from the filter
+	keyEnter                // Enter, picks the highlighted python
+	keyCancel               // Escape, ctrl+c or ctrl+d
+)
+
+// readKey reads the next key press from 'r', returning the rune typed if it's a keyRune.
+func readKey(r *bufio.Reader) (key, rune, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return keyNone, 0, err
+	}
+
+	switch c {
+	case '\r', '\n':
+		return keyEnter, 0, nil
+	case 0x7f, '\b': //nolint: mnd // DEL, what most terminals send for backspace
+		return keyBackspace, 0, nil
+	case 0x03, 0x04: //nolint: mnd // ctrl+c and ctrl+d
+		return keyCancel, 0, nil
+	case 0x10: //nolint: mnd // ctrl+p
+		return keyUp, 0, nil
+	case 0x0e: //nolint: mnd // ctrl+n
+		return keyDown, 0, nil
+	case 0x1b: //nolint: mnd // Escape, on it's own or starting an escape sequence
+		return readEscape(r)
+	}
+
+	if unicode.IsPrint(c) {
+		return keyRune, c, nil
+	}
+
+	return keyNone, 0, nil
+}
+
+// readEscape reads the rest of an escape sequence from 'r', the escape has already been read.
+//
+// The terminal sends the whole of a sequence at once, so an escape with nothing
+// buffered after it is the escape key itself.
+func readEscape(r *bufio.Reader) (key, rune, error) {
+	if r.Buffered() == 0 {
+		return keyCancel, 0, nil
+	}
+
+	// Arrow keys are ESC [ A or, in application mode, ESC O A
+	if next, err := r.ReadByte(); err != nil || (next != '[' && next != 'O') {
+		return keyNone, 0, nil
+	}
+
+	for r.Buffered() != 0 {
+		final, err := r.ReadByte()
+		if err != nil {
+			return keyNone, 0, nil
+		}
+		switch {
+		case final == 'A':
+			return keyUp, 0, nil
+		case final == 'B':
+			return keyDown, 0, nil
+		case final >= '0' && final <= '9', final == ';':
+			// Parameters of a longer sequence e.g. ESC [ 1 ; 5 A
+			continue
+		default:
+			return keyNone, 0, nil
+		}
+	}
+
+	return keyNone, 0, nil
+}
+
+// picker is the state of the interactive list shown by runPicker.
+type picker struct {
+	items   []pickItem // Everything that can be picked
+	matches []int      // Indexes into items of those matching the filter
+	filter  []rune     // What's been typed so far
+	cursor  int        // Index into matches of the highlighted item
+	drawn   int        // How many lines the last render drew, so they can be cleared
+}
+
+// newPicker returns a picker over 'items' with the one at 'selected' highlighted.
+func newPicker(items []pickItem, selected int) *picker {
+	p := &picker{items: items}
+	p.refilter()
+	if selected >= 0 && selected < len(p.matches) {
+		p.cursor = selected
+	}
+	return p
+}
+
+// handle updates the picker for the key press 'k' (typing 'r' if it's a keyRune), returning
+// true once a python has been picked.
+func (p *picker) handle(k key, r rune) (bool, error) {
+	switch k {
+	case keyUp:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case keyDown:
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+	case keyRune:
+		p.filter = append(p.filter, r)
+		p.refilter()
+	case keyBackspace:
+		if len(p.filter) != 0 {
+			p.filter = p.filter[:len(p.filter)-1]
+			p.refilter()
+		}
+	case keyEnter:
+		// Nothing to pick if the filter matches nothing
+		return len(p.matches) != 0, nil
+	case keyCancel:
+		return false, errPickCancelled
+	case keyNone:
+		// Ignored
+	}
+
+	return false, nil
+}
+
+// selected returns the index into items of the highlighted item, there must be one.
+func (p *picker) selected() int {
+	return p.matches[p.cursor]
+}
+
+// refilter works out which items match the filter, keeping the highlighted one
+// highlighted if it still matches.
+func (p *picker) refilter() {
+	current := -1
+	if p.cursor < len(p.matches) {
+		current = p.matches[p.cursor]
+	}
+
+	p.matches = p.matches[:0]
+	p.cursor = 0
+	for i, item := range p.items {
+		if !fuzzyMatch(string(p.filter), item.label) {
+			continue
+		}
+		if i == current {
+			p.cursor = len(p.matches)
+		}
+		p.matches = append(p.matches, i)
+	}
+}
+
+// render draws the picker to 'out', replacing what it drew last time.
+func (p *picker) render(out io.Writer) {
+	frame := &strings.Builder{}
+	p.clear(frame)
+
+	fmt.Fprintln(frame, "Pick a python (type to filter, ↑/↓ to move, enter to launch, esc to cancel)")
+	fmt.Fprintf(frame, "> %s\n", string(p.filter))
+	lines := 2 //nolint: mnd // The two lines above
+
+	// Scroll so the cursor is always in view
+	start := max(0, p.cursor-pickMaxVisible+1)
+	end := min(len(p.matches), start+pickMaxVisible)
+	for i := start; i < end; i++ {
+		marker := "  "
+		if i == p.cursor {
+			marker = "▸ "
+		}
+		fmt.Fprintf(frame, "%s%s\n", marker, p.items[p.matches[i]].label)
+		lines++
+	}
+
+	if len(p.matches) == 0 {
+		fmt.Fprintln(frame, "  no pythons match")
+		lines++
+	}
+
+	p.drawn = lines
+	io.WriteString(out, frame.String()) //nolint: errcheck // Nothing useful to do if we can't
+}
+
+// clear moves back to the start of what the last render drew and erases it.
+func (p *picker) clear(out io.Writer) {
+	if p.drawn != 0 {
+		fmt.Fprintf(out, "\x1b[%dA", p.drawn)
+	}
+	io.WriteString(out, "\r\x1b[J") //nolint: errcheck // Nothing useful to do if we can't
+	p.drawn = 0
+}
+
+// fuzzyMatch reports whether every character of 'pattern' appears in 's' in order,
+// ignoring case e.g. "p312" matches "3.12	│ /usr/bin/python3.12".
+func fuzzyMatch(pattern, s string) bool {
+	s = strings.ToLower(s)
+	for _, c := range strings.ToLower(pattern) {
+		i := strings.IndexRune(s, c)
+		if i == -1 {
+			return false
+		}
+		s = s[i+len(string(c)):]
+	}
+	return true
+}
@@ -0,0 +1,366 @@
+package cli //nolint: testpackage // Need access to internals
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// testPickItems are the items the picker tests choose from.
+var testPickItems = []pickItem{
+	{label: "venv\t│ /project/.venv/bin/python", path: "/project/.venv/bin/python"},
+	{label: "3.12\t│ /usr/bin/python3.12", path: "/usr/bin/python3.12"},
+	{label: "3.11\t│ /usr/bin/python3.11", path: "/usr/bin/python3.11"},
+	{label: "pypy3.10\t│ /usr/bin/pypy3.10", path: "/usr/bin/pypy3.10"},
+}
+
+func Test_fuzzyMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{pattern: "", s: "3.12\t│ /usr/bin/python3.12", want: true},
+		{pattern: "3.12", s: "3.12\t│ /usr/bin/python3.12", want: true},
+		{pattern: "p312", s: "3.12\t│ /usr/bin/python3.12", want: true},
+		{pattern: "PYPY", s: "pypy3.10\t│ /usr/bin/pypy3.10", want: true},
+		{pattern: "│", s: "3.12\t│ /usr/bin/python3.12", want: true},
+		{pattern: "213", s: "3.12\t│ /usr/bin/python3.12", want: false},
+		{pattern: "venv", s: "3.12\t│ /usr/bin/python3.12", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := fuzzyMatch(tt.pattern, tt.s); got != tt.want {
+				t.Errorf("fuzzyMatch(%q, %q) = %v, wanted %v", tt.pattern, tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_readKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []key
+	}{
+		{name: "enter", input: "\r\n", want: []key{keyEnter, keyEnter}},
+		{name: "typing", input: "p3é", want: []key{keyRune, keyRune, keyRune}},
+		{name: "backspace", input: "\x7f\b", want: []key{keyBackspace, keyBackspace}},
+		{name: "arrows", input: "\x1b[A\x1b[B\x1bOA", want: []key{keyUp, keyDown, keyUp}},
+		{name: "ctrl p and n", input: "\x10\x0e", want: []key{keyUp, keyDown}},
+		{name: "cancel", input: "\x03\x04", want: []key{keyCancel, keyCancel}},
+		{name: "escape on it's own", input: "\x1b", want: []key{keyCancel}},
+		{name: "other sequences ignored", input: "\x1b[3~\x1b[1;5Ax", want: []key{keyNone, keyUp, keyRune}},
+		{name: "control characters ignored", input: "\t", want: []key{keyNone}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tt.input))
+			var got []key
+			for {
+				k, _, err := readKey(r)
+				if err != nil {
+					break
+				}
+				got = append(got, k)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_runPicker(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string // What's typed
+		selected int    // Item selected to begin with
+		want     int    // Index of the item we want picked
+		wantErr  error
+	}{
+		{name: "enter picks the selected", keys: "\r", selected: 2, want: 2},
+		{name: "down", keys: "\x1b[B\x1b[B\r", want: 2},
+		{name: "up", keys: "\x1b[A\r", selected: 3, want: 2},
+		{name: "can't go past the top", keys: "\x1b[A\x1b[A\r", want: 0},
+		{name: "can't go past the bottom", keys: "\x1b[B\x1b[B\x1b[B\x1b[B\x1b[B\r", want: 3},
+		{name: "filter", keys: "pypy\r", want: 3},
+		{name: "fuzzy filter", keys: "p311\r", want: 2},
+		{name: "filter then move", keys: "3.1\x1b[B\r", want: 2},
+		{name: "selected stays selected while filtering", keys: "bin\r", selected: 2, want: 2},
+		{name: "backspace", keys: "pypyx\x7f\r", want: 3},
+		{name: "backspace to nothing", keys: "zz\x7f\x7f\x1b[B\r", want: 1},
+		{name: "enter with no matches does nothing", keys: "zzz\r\x7f\x7f\x7f\r", want: 0},
+		{name: "escape", keys: "3.1\x1b", wantErr: errPickCancelled},
+		{name: "ctrl c", keys: "\x03", wantErr: errPickCancelled},
+		{name: "end of input", keys: "3.1", wantErr: errPickCancelled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			got, err := runPicker(strings.NewReader(tt.keys), out, testPickItems, tt.selected)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("runPicker() error = %v, wanted %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("runPicker() returned an unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("picked %d (%s), wanted %d (%s)", got, testPickItems[got].path, tt.want, testPickItems[tt.want].path)
+			}
+
+			// Everything drawn should have been cleared away again
+			if !strings.HasSuffix(out.String(), "\r\x1b[J") {
+				t.Errorf("picker was not cleared, output ended %q", out.String()[max(0, out.Len()-20):])
+			}
+		})
+	}
+}
+
+func Test_pickerRender(t *testing.T) {
+	items := make([]pickItem, 0, 15) //nolint: mnd
+	for _, minor := range []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12", "13", "14"} {
+		items = append(items, pickItem{label: "3." + minor, path: "/usr/bin/python3." + minor})
+	}
+
+	p := newPicker(items, 12) //nolint: mnd
+	out := &bytes.Buffer{}
+	p.render(out)
+
+	// The cursor is kept in view
+	if !strings.Contains(out.String(), "▸ 3.12\n") {
+		t.Errorf("selected item not highlighted:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "3.2\n") || !strings.Contains(out.String(), "3.3\n") {
+		t.Errorf("expected 3.3 to 3.12 to be shown:\n%s", out.String())
+	}
+	if p.drawn != pickMaxVisible+2 {
+		t.Errorf("drew %d lines, wanted %d", p.drawn, pickMaxVisible+2)
+	}
+
+	// Drawing again moves back up over the last frame first
+	out.Reset()
+	p.render(out)
+	if !strings.HasPrefix(out.String(), "\x1b[12A\r\x1b[J") {
+		t.Errorf("expected the last frame to be cleared, got %q", out.String()[:20])
+	}
+}
+
+func Test_promptPick(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		selected int
+		want     int
+		wantErr  bool
+	}{
+		{name: "number", input: "3\n", want: 2},
+		{name: "number without newline", input: "2", want: 1},
+		{name: "whitespace", input: "  4 \n", want: 3},
+		{name: "default", input: "\n", selected: 1, want: 1},
+		{name: "nothing to read", input: "", wantErr: true},
+		{name: "zero", input: "0\n", wantErr: true},
+		{name: "too big", input: "5\n", wantErr: true},
+		{name: "not a number", input: "pypy\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			got, err := promptPick(strings.NewReader(tt.input), out, testPickItems, tt.selected)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("promptPick() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("picked %d, wanted %d", got, tt.want)
+			}
+
+			if !strings.Contains(out.String(), "Pick a python [1-4]") {
+				t.Errorf("prompt not shown:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestApp_Pick(t *testing.T) {
+	bin := fakeBin(t, filepath.Join(t.TempDir(), "bin"), "python3.12", "python3.11")
+	project := t.TempDir()
+	venv := fakeBin(t, filepath.Join(project, ".venv", "bin"), "python")
+	venvPython := filepath.Join(venv, "python")
+	python312 := filepath.Join(bin, "python3.12")
+	python311 := filepath.Join(bin, "python3.11")
+	exact := interpreter.Spec{Major: 3, Minor: 11, Patch: interpreter.Any}
+
+	tests := []struct {
+		name       string
+		opts       PickOptions
+		input      string // Typed at the numbered prompt
+		remembered string // Contents of the remember file, if any
+		wantStdout []string
+		wantStderr []string
+		wantErr    bool
+	}{
+		{
+			name:       "venv first",
+			input:      "\n",
+			wantStdout: []string{"Would launch: " + venvPython + " script.py"},
+			wantStderr: []string{"* 1) venv\t│ " + venvPython, "  2) 3.12\t│ " + python312, "  3) 3.11\t│ " + python311},
+		},
+		{
+			name:       "numbered",
+			input:      "3\n",
+			wantStdout: []string{"Would launch: " + python311 + " script.py"},
+		},
+		{
+			name:       "spec",
+			opts:       PickOptions{Spec: &exact},
+			input:      "\n",
+			wantStdout: []string{"Would launch: " + python311 + " script.py"},
+			wantStderr: []string{"Pick a python [1-1] (default 1)"},
+		},
+		{
+			name:       "remembered is the default",
+			input:      "\n",
+			remembered: python312 + "\n",
+			wantStdout: []string{"Selected " + python312 + " remembered in .py-pick", "Would launch: " + python312 + " script.py"},
+			wantStderr: []string{"* 2) 3.12"},
+		},
+		{
+			name:       "remembered but gone",
+			input:      "\n",
+			remembered: filepath.Join(bin, "python3.10"),
+			wantStdout: []string{"Would launch: " + venvPython + " script.py"},
+		},
+		{
+			name:       "remember",
+			opts:       PickOptions{Remember: true},
+			input:      "2\n",
+			wantStdout: []string{"Would remember " + python312 + " in " + filepath.Join(project, pickFile)},
+		},
+		{
+			name:    "cancelled",
+			input:   "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(project, pickFile)
+			os.Remove(file) //nolint: errcheck
+			if tt.remembered != "" {
+				writeFile(t, file, tt.remembered)
+			}
+
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			app := newTestApp(stdout, stderr, bin)
+			app.Dir = project
+			app.Stdin = strings.NewReader(tt.input)
+			app.Explain = true
+
+			err := app.Pick(tt.opts, []string{"script.py"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Pick() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout missing %q:\n%s", want, stdout.String())
+				}
+			}
+
+			for _, want := range tt.wantStderr {
+				if !strings.Contains(stderr.String(), want) {
+					t.Errorf("stderr missing %q:\n%s", want, stderr.String())
+				}
+			}
+
+			// Explaining never writes the remember file
+			if contents := rememberedPick(project); contents != strings.TrimSpace(tt.remembered) {
+				t.Errorf("remember file changed to %q", contents)
+			}
+		})
+	}
+}
+
+func Test_rememberPick(t *testing.T) {
+	project := t.TempDir()
+	if got := rememberedPick(project); got != "" {
+		t.Errorf("expected nothing remembered yet, got %q", got)
+	}
+
+	for _, python := range []string{"/usr/bin/python3.12", "/project/.venv/bin/python"} {
+		if err := rememberPick(project, python); err != nil {
+			t.Fatalf("rememberPick() returned an unexpected error: %v", err)
+		}
+		if got := rememberedPick(project); got != python {
+			t.Errorf("remembered %q, wanted %q", got, python)
+		}
+	}
+
+	if err := rememberPick(filepath.Join(project, "missing"), "/usr/bin/python3.12"); err == nil {
+		t.Error("expected an error remembering in a missing directory, got nil")
+	}
+}
+
+func TestApp_PickActivated(t *testing.T) {
+	bin := fakeBin(t, filepath.Join(t.TempDir(), "bin"), "python3.12")
+	activated := t.TempDir()
+	fakeBin(t, filepath.Join(activated, "bin"), "python")
+
+	stderr := &bytes.Buffer{}
+	app := newTestApp(&bytes.Buffer{}, stderr, bin)
+	app.Dir = t.TempDir()
+	app.Getenv = testEnv(map[string]string{vitualEnvKey: activated})
+	app.Stdin = strings.NewReader("\n")
+	app.Explain = true
+
+	if err := app.Pick(PickOptions{}, nil); err != nil {
+		t.Fatalf("Pick() returned an unexpected error: %v", err)
+	}
+
+	want := "* 1) venv\t│ " + filepath.Join(activated, "bin", "python") + " (activated)"
+	if !strings.Contains(stderr.String(), want) {
+		t.Errorf("expected the activated venv first, got:\n%s", stderr.String())
+	}
+}
+
+func TestApp_PickOnlyVenv(t *testing.T) {
+	project := t.TempDir()
+	fakeBin(t, filepath.Join(project, "venv", "bin"), "python")
+
+	stdout := &bytes.Buffer{}
+	app := newTestApp(stdout, &bytes.Buffer{}, t.TempDir())
+	app.Dir = project
+	app.Stdin = strings.NewReader("1\n")
+	app.Explain = true
+
+	if err := app.Pick(PickOptions{}, nil); err != nil {
+		t.Fatalf("Pick() with only a venv returned an unexpected error: %v", err)
+	}
+
+	// A spec only offers interpreters on $PATH, and there aren't any
+	spec := interpreter.Spec{Major: 3, Minor: interpreter.Any, Patch: interpreter.Any}
+	app.Stdin = strings.NewReader("1\n")
+	if err := app.Pick(PickOptions{Spec: &spec}, nil); !errors.Is(err, ErrNoInterpreter) {
+		t.Errorf("Pick() with a spec error = %v, wanted ErrNoInterpreter", err)
+	}
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/FollowTheProcess/py/resolve"
+)
+
+const (
+	scriptVenvEnvKey  = resolve.EnvScriptVenv // The key for the env variable enabling cached venvs for script dependencies
+	scriptVenvDir     = "script-venvs"        // Directory under the cache dir holding the script venvs
+	scriptVenvMarker  = ".py-complete"        // File written once a script venv is fully built
+	scriptVenvKeySize = 16                    // Number of hex characters of the dependency hash used to name a venv
+	scriptVenvPerms   = 0o644                 // Permissions of the marker file in a script venv
+)
+
+// scriptVenv returns the path to the python executable of a cached virtual environment
+// built from the interpreter at 'python' with 'dependencies' installed, creating it with
+// the stdlib venv module and pip if it doesn't exist yet.
+//
+// Venvs are keyed on the interpreter and the set of dependencies, so scripts with
+// the same requirements share one.
+func (a *App) scriptVenv(python string, dependencies []string) (string, error) {
+	dir, sorted := a.scriptVenvDir(python, dependencies)
+	exe := filepath.Join(dir, "bin", "python")
+
+	if scriptVenvComplete(dir) {
+		a.Logger.Debug("Reusing cached script venv", LogKeyVenv, dir)
+		return exe, nil
+	}
+
+	// Anything here without a marker is a half built venv from a failed attempt
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("could not remove incomplete script venv %s: %w", dir, err)
+	}
+
+	a.Logger.Debug("Creating script venv", LogKeyVenv, dir)
+	if err := a.run(python, "-m", "venv", dir); err != nil {
+		return "", fmt.Errorf("could not create script venv: %w", err)
+	}
+
+	a.Logger.Debug("Installing script dependencies", LogKeyVenv, dir, LogKeyDependencies, sorted)
+	install := append([]string{"-m", "pip", "install", "--disable-pip-version-check", "--quiet"}, sorted...)
+	if err := a.run(exe, install...); err != nil {
+		return "", fmt.Errorf("could not install script dependencies: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, scriptVenvMarker), []byte(strings.Join(sorted, "\n")+"\n"), scriptVenvPerms); err != nil {
+		return "", fmt.Errorf("could not mark script venv as complete: %w", err)
+	}
+
+	return exe, nil
+}
+
+// scriptVenvDir returns the directory of the cached script venv for the interpreter at 'python'
+// with 'dependencies' installed, along with the dependencies sorted.
+func (a *App) scriptVenvDir(python string, dependencies []string) (dir string, sorted []string) {
+	sorted = slices.Clone(dependencies)
+	slices.Sort(sorted)
+
+	hash := sha256.Sum256([]byte(python + "\n" + strings.Join(sorted, "\n")))
+	return filepath.Join(a.CacheDir, scriptVenvDir, hex.EncodeToString(hash[:])[:scriptVenvKeySize]), sorted
+}
+
+// scriptVenvComplete reports whether the script venv at 'dir' has been fully built.
+func scriptVenvComplete(dir string) bool {
+	return exists(filepath.Join(dir, scriptVenvMarker))
+}
+
+// run runs 'name' with 'args' to completion, sending all it's output to a.Stderr
+// so as not to get mixed up with the output of whatever we launch afterwards.
+func (a *App) run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = a.Stderr
+	cmd.Stderr = a.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
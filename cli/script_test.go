@@ -0,0 +1,135 @@
+package cli //nolint: testpackage // Need access to internals
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApp_scriptVenv(t *testing.T) {
+	python := filepath.Join(fakePythons(t, t.TempDir(), fakePythonScript, "python3.12"), "python3.12")
+
+	app := newTestApp(&bytes.Buffer{}, &bytes.Buffer{}, "")
+	app.CacheDir = t.TempDir()
+
+	// Order shouldn't matter, the same set of dependencies is the same venv
+	exe, err := app.scriptVenv(python, []string{"rich", "requests<3"})
+	if err != nil {
+		t.Fatalf("scriptVenv() returned an unexpected error: %v", err)
+	}
+
+	if !exists(exe) {
+		t.Fatalf("venv python %s was not created", exe)
+	}
+
+	again, err := app.scriptVenv(python, []string{"requests<3", "rich"})
+	if err != nil {
+		t.Fatalf("scriptVenv() returned an unexpected error on reuse: %v", err)
+	}
+
+	if again != exe {
+		t.Errorf("expected venv to be reused: got %s, wanted %s", again, exe)
+	}
+
+	log, err := os.ReadFile(filepath.Join(filepath.Dir(filepath.Dir(exe)), "pip.log"))
+	if err != nil {
+		t.Fatalf("could not read pip log: %v", err)
+	}
+
+	want := "install --disable-pip-version-check --quiet requests<3 rich\n"
+	if got := string(log); got != want {
+		t.Errorf("dependencies installed wrong: got %q, wanted %q", got, want)
+	}
+
+	other, err := app.scriptVenv(python, []string{"rich"})
+	if err != nil {
+		t.Fatalf("scriptVenv() returned an unexpected error: %v", err)
+	}
+
+	if other == exe {
+		t.Error("different dependencies should get a different venv")
+	}
+}
+
+func TestApp_scriptVenvFailedInstall(t *testing.T) {
+	python := filepath.Join(fakePythons(t, t.TempDir(), fakePythonScript, "python3.12"), "python3.12")
+
+	app := newTestApp(&bytes.Buffer{}, &bytes.Buffer{}, "")
+	app.CacheDir = t.TempDir()
+
+	t.Setenv("FAKE_PIP_FAIL", "1")
+	if _, err := app.scriptVenv(python, []string{"rich"}); err == nil {
+		t.Fatal("expected an error when pip install fails, got nil")
+	}
+
+	// The half built venv must not be reused, so the next attempt installs again
+	t.Setenv("FAKE_PIP_FAIL", "")
+	exe, err := app.scriptVenv(python, []string{"rich"})
+	if err != nil {
+		t.Fatalf("scriptVenv() returned an unexpected error: %v", err)
+	}
+
+	log, err := os.ReadFile(filepath.Join(filepath.Dir(filepath.Dir(exe)), "pip.log"))
+	if err != nil {
+		t.Fatalf("could not read pip log: %v", err)
+	}
+
+	if got := strings.Count(string(log), "\n"); got != 1 {
+		t.Errorf("expected a single install in a freshly built venv, got %d", got)
+	}
+}
+
+func TestApp_LaunchExplainScriptVenv(t *testing.T) {
+	bin := fakePythons(t, filepath.Join(t.TempDir(), "bin"), fakePythonScript, "python3.12")
+	python := filepath.Join(bin, "python3.12")
+
+	project := t.TempDir()
+	script := filepath.Join(project, "script.py")
+	writeFile(t, script, "# /// script\n# dependencies = [\"rich\"]\n# ///\nprint('hello')\n")
+
+	stdout := &bytes.Buffer{}
+	app := newTestApp(stdout, &bytes.Buffer{}, bin)
+	app.Dir = project
+	app.CacheDir = t.TempDir()
+	app.ScriptVenv = true
+	app.Explain = true
+
+	if err := app.Launch([]string{script}); err != nil {
+		t.Fatalf("Launch() returned an unexpected error: %v", err)
+	}
+
+	dir, _ := app.scriptVenvDir(python, []string{"rich"})
+	for _, want := range []string{
+		"- Would build script venv " + dir + " with rich installed\n",
+		"Would launch: " + filepath.Join(dir, "bin", "python") + " " + script + "\n",
+	} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+
+	// Explaining must never build the venv
+	entries, err := os.ReadDir(app.CacheDir)
+	if err != nil {
+		t.Fatalf("could not read cache dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("explaining wrote to the cache dir: %v", entries)
+	}
+
+	// Once it's built, it would be reused
+	if _, err := app.scriptVenv(python, []string{"rich"}); err != nil {
+		t.Fatalf("scriptVenv() returned an unexpected error: %v", err)
+	}
+
+	stdout.Reset()
+	if err := app.Launch([]string{script}); err != nil {
+		t.Fatalf("Launch() returned an unexpected error: %v", err)
+	}
+
+	if want := "- Would reuse script venv " + dir + " with rich installed\n"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, stdout.String())
+	}
+}
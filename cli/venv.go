@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/FollowTheProcess/py/internal/toml"
+	"github.com/FollowTheProcess/py/interpreter"
+	"github.com/FollowTheProcess/py/resolve"
+)
+
+const (
+	defaultVenvDir    = ".venv"           // Where to create a venv if not told otherwise
+	pythonVersionFile = ".python-version" // File used by pyenv, uv etc. to pin a project's python version
+	pyprojectFile     = "pyproject.toml"  // The python project metadata file, may contain requires-python
+	pyvenvCfgFile     = "pyvenv.cfg"      // File present in the root of every virtual environment
+	requiresPythonKey = "requires-python" // The pyproject.toml [project] key holding the python version constraint
+)
+
+// errParsePyproject is wrapped by the error readRequiresPython returns when pyproject.toml can't be parsed.
+var errParsePyproject = errors.New("could not parse")
+
+// VenvOptions configures how CreateVenv creates a virtual environment.
+type VenvOptions struct {
+	Spec       *interpreter.Spec // The python version to use, if nil it's worked out from the project
+	Path       string            // Where to create the venv, defaults to .venv
+	Force      bool              // Recreate the venv even if a healthy one already exists there
+	UpgradePip bool              // Upgrade pip in the new venv to the latest version
+}
+
+// CreateVenv creates a virtual environment using the stdlib venv module of the python
+// interpreter we resolve for the current project, then prints how to activate it.
+//
+// The interpreter is found by looking at (in order):
+//  1. opts.Spec (e.g. from py --venv -3.12)
+//  2. A .python-version file in cwd
+//  3. The requires-python of a pyproject.toml in cwd
+//  4. PY_PYTHON env variable
+//  5. Latest version on $PATH
+//
+// An existing healthy virtual environment is only replaced if opts.Force is set, and a directory
+// that isn't a virtual environment at all is never touched.
+func (a *App) CreateVenv(opts VenvOptions) error {
+	path := opts.Path
+	if path == "" {
+		path = defaultVenvDir
+	}
+
+	cwd, err := a.cwd()
+	if err != nil {
+		return err
+	}
+
+	// Only needed if the App isn't working in the process's directory, in which case
+	// python won't be either
+	if a.Dir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+
+	recreate, err := a.checkVenvTarget(path, opts.Force)
+	if err != nil {
+		return err
+	}
+
+	python, err := a.venvInterpreter(cwd, opts.Spec)
+	if err != nil {
+		return err
+	}
+
+	if a.Explain {
+		fmt.Fprintf(a.Stdout, "Would create virtual environment at %s with %s\n", path, python.Path)
+		return nil
+	}
+
+	args := []string{"-m", "venv"}
+	if recreate {
+		args = append(args, "--clear")
+	}
+	args = append(args, path)
+
+	a.Logger.Debug("Creating virtual environment", LogKeyVenv, path)
+	if err := a.run(python.Path, args...); err != nil {
+		return fmt.Errorf("could not create virtual environment: %w", err)
+	}
+
+	if opts.UpgradePip {
+		a.Logger.Debug("Upgrading pip in virtual environment", LogKeyVenv, path)
+		if err := a.run(filepath.Join(path, "bin", "python"), "-m", "pip", "install", "--upgrade", "pip"); err != nil {
+			return fmt.Errorf("could not upgrade pip: %w", err)
+		}
+	}
+
+	fmt.Fprintf(a.Stdout, "Created virtual environment at %s with python %s (%s)\n", path, python.Version(), python.Path)
+	fmt.Fprintf(a.Stdout, "Activate it with: %s\n", activateCommand(path, a.getenv("SHELL")))
+
+	return nil
+}
+
+// checkVenvTarget makes sure it's safe to create a virtual environment at 'path', returning
+// whether an existing one there needs clearing first.
+//
+// A healthy virtual environment is only cleared if 'force' is set, a broken one (e.g. whose
+// python has been uninstalled) is always cleared and anything else is left well alone.
+func (a *App) checkVenvTarget(path string, force bool) (bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("could not check %s: %w", path, err)
+	}
+
+	if !info.IsDir() {
+		return false, fmt.Errorf("%s already exists and is not a directory", path)
+	}
+
+	if !exists(filepath.Join(path, pyvenvCfgFile)) {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return false, fmt.Errorf("could not read %s: %w", path, err)
+		}
+		if len(entries) != 0 {
+			return false, fmt.Errorf("%s already exists and is not a virtual environment, refusing to overwrite it", path)
+		}
+		return false, nil
+	}
+
+	// exists follows symlinks, so a venv python pointing at an uninstalled interpreter is broken
+	if exists(filepath.Join(path, "bin", "python")) && !force {
+		return false, fmt.Errorf("a virtual environment already exists at %s, use --force to recreate it", path)
+	}
+
+	a.explain("Existing virtual environment at %s will be recreated", path)
+	return true, nil
+}
+
+// venvInterpreter resolves the interpreter to create a virtual environment for the project in 'cwd' with.
+func (a *App) venvInterpreter(cwd string, spec *interpreter.Spec) (interpreter.Interpreter, error) {
+	// 1) Explicitly asked for version
+	if spec != nil {
+		return a.latestMatching(*spec)
+	}
+
+	// 2) .python-version
+	versionSpec, ok, err := readPythonVersionFile(filepath.Join(cwd, pythonVersionFile))
+	if err != nil {
+		return interpreter.Interpreter{}, err
+	}
+	if ok {
+		a.explain("%s asks for %s", pythonVersionFile, versionSpec)
+		return a.latestMatching(versionSpec)
+	}
+	a.explain("No usable %s in %s", pythonVersionFile, cwd)
+
+	// 3) pyproject.toml requires-python
+	requires, err := readRequiresPython(filepath.Join(cwd, pyprojectFile))
+	if err != nil {
+		return interpreter.Interpreter{}, err
+	}
+	if requires != "" {
+		a.explain("%s requires python %q", pyprojectFile, requires)
+		result, err := a.resolve(resolve.Options{RequiresPython: requires})
+		if err != nil {
+			return interpreter.Interpreter{}, err
+		}
+		return result.Interpreter, nil
+	}
+	a.explain("No requires-python in a %s in %s", pyprojectFile, cwd)
+
+	// 4) PY_PYTHON
+	if version := a.getenv(pyPythonEnvKey); version != "" {
+		major, minor, err := resolve.ParsePyPython(version)
+		if err != nil {
+			return interpreter.Interpreter{}, err
+		}
+		a.explain("$PY_PYTHON asks for %s", version)
+		return a.latestMatching(interpreter.Spec{Major: major, Minor: minor, Patch: interpreter.Any})
+	}
+	a.explain("$PY_PYTHON is not set")
+
+	// 5) Latest
+	return a.latestMatching(interpreter.Spec{Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any})
+}
+
+// readPythonVersionFile reads the version specifier from a .python-version file at 'path'
+// returning false if there isn't one, or it doesn't contain a version we understand.
+//
+// The file may contain several versions one per line (as pyenv allows) and comments, the first
+// we understand wins. Versions like pyenv's "pypy3.10-7.3.12" are taken to mean just "pypy3.10"
+// and special values like "system" are skipped.
+func readPythonVersionFile(path string) (interpreter.Spec, bool, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return interpreter.Spec{}, false, nil
+	}
+	if err != nil {
+		return interpreter.Spec{}, false, fmt.Errorf("could not read %s: %w", path, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "cpython-")
+		if spec, err := interpreter.ParseSpec(line); err == nil {
+			return spec, true, nil
+		}
+
+		// Strip anything after the version e.g. pypy3.10-7.3.12 or 3.12.4-macos-aarch64
+		if before, _, ok := strings.Cut(line, "-"); ok {
+			if spec, err := interpreter.ParseSpec(before); err == nil {
+				return spec, true, nil
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return interpreter.Spec{}, false, fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	return interpreter.Spec{}, false, nil
+}
+
+// readRequiresPython reads the [project] requires-python from the pyproject.toml at 'path',
+// returning an empty string if there isn't one.
+func readRequiresPython(path string) (string, error) {
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	pyproject, err := toml.Parse(string(contents))
+	if err != nil {
+		return "", fmt.Errorf("%w %s: %w", errParsePyproject, path, err)
+	}
+
+	project, ok := pyproject["project"].(map[string]any)
+	if !ok {
+		return "", nil
+	}
+
+	value, ok := project[requiresPythonKey]
+	if !ok {
+		return "", nil
+	}
+
+	requires, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("invalid %s: %s must be a string", path, requiresPythonKey)
+	}
+
+	return requires, nil
+}
+
+// activateCommand returns the command to activate the virtual environment at 'path'
+// in the user's 'shell' (e.g. $SHELL).
+func activateCommand(path, shell string) string {
+	switch filepath.Base(shell) {
+	case "fish":
+		return "source " + filepath.Join(path, "bin", "activate.fish")
+	case "csh", "tcsh":
+		return "source " + filepath.Join(path, "bin", "activate.csh")
+	default:
+		return "source " + filepath.Join(path, "bin", "activate")
+	}
+}
@@ -0,0 +1,329 @@
+package cli //nolint: testpackage // Need access to internals
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+func Test_readPythonVersionFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     interpreter.Spec
+		wantOk   bool
+	}{
+		{
+			name:     "exact",
+			contents: "3.12\n",
+			want:     interpreter.Spec{Major: 3, Minor: 12, Patch: interpreter.Any},
+			wantOk:   true,
+		},
+		{
+			name:     "patch",
+			contents: "3.12.4",
+			want:     interpreter.Spec{Major: 3, Minor: 12, Patch: 4},
+			wantOk:   true,
+		},
+		{
+			name:     "pyenv pypy",
+			contents: "pypy3.10-7.3.12\n",
+			want:     interpreter.Spec{Implementation: interpreter.PyPy, Major: 3, Minor: 10, Patch: interpreter.Any},
+			wantOk:   true,
+		},
+		{
+			name:     "uv style cpython",
+			contents: "cpython-3.11.9-linux-x86_64-gnu\n",
+			want:     interpreter.Spec{Major: 3, Minor: 11, Patch: 9},
+			wantOk:   true,
+		},
+		{
+			name:     "comments and multiple versions",
+			contents: "# Pinned for CI\n\nsystem\n3.13t\n3.12\n",
+			want:     interpreter.Spec{Major: 3, Minor: 13, Patch: interpreter.Any, ABIFlags: "t"},
+			wantOk:   true,
+		},
+		{
+			name:     "nothing we understand",
+			contents: "system\n",
+			want:     interpreter.Spec{},
+			wantOk:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), pythonVersionFile)
+			writeFile(t, path, tt.contents)
+
+			got, ok, err := readPythonVersionFile(path)
+			if err != nil {
+				t.Fatalf("readPythonVersionFile() returned an unexpected error: %v", err)
+			}
+
+			if ok != tt.wantOk {
+				t.Errorf("got ok %v, wanted %v", ok, tt.wantOk)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		_, ok, err := readPythonVersionFile(filepath.Join(t.TempDir(), pythonVersionFile))
+		if err != nil || ok {
+			t.Errorf("missing file should be ok = false, err = nil: got ok = %v, err = %v", ok, err)
+		}
+	})
+}
+
+func Test_readRequiresPython(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "requires-python",
+			contents: "[project]\nname = \"thing\"\nrequires-python = \">=3.10\"\n",
+			want:     ">=3.10",
+			wantErr:  false,
+		},
+		{
+			name: "realistic pyproject",
+			contents: `[build-system]
+requires = ["hatchling"]
+build-backend = "hatchling.build"
+
+[project]
+name = "thing"
+description = """
+Does things.
+"""
+license = {text = "MIT"}
+authors = [{name = "A. Person", email = "a@example.com"}]
+requires-python = ">=3.11"
+dependencies = ["rich"]
+
+[tool.ruff.lint.per-file-ignores]
+"tests/*" = ["S101"]
+`,
+			want:    ">=3.11",
+			wantErr: false,
+		},
+		{
+			name:     "no requires-python",
+			contents: "[project]\nname = \"thing\"\n",
+			want:     "",
+			wantErr:  false,
+		},
+		{
+			name:     "no project table",
+			contents: "[tool.ruff]\nline-length = 120\n",
+			want:     "",
+			wantErr:  false,
+		},
+		{
+			name:     "not in project",
+			contents: "requires-python = \">=3.10\"\n",
+			want:     "",
+			wantErr:  false,
+		},
+		{
+			name:     "not a string",
+			contents: "[project]\nrequires-python = [\">=3.10\"]\n",
+			want:     "",
+			wantErr:  true,
+		},
+		{
+			name:     "bad TOML",
+			contents: "[project\n",
+			want:     "",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), pyprojectFile)
+			writeFile(t, path, tt.contents)
+
+			got, err := readRequiresPython(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readRequiresPython() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %q, wanted %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_activateCommand(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  string
+	}{
+		{shell: "/bin/bash", want: "source .venv/bin/activate"},
+		{shell: "/usr/bin/zsh", want: "source .venv/bin/activate"},
+		{shell: "/opt/homebrew/bin/fish", want: "source .venv/bin/activate.fish"},
+		{shell: "/bin/tcsh", want: "source .venv/bin/activate.csh"},
+		{shell: "", want: "source .venv/bin/activate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			if got := activateCommand(".venv", tt.shell); got != tt.want {
+				t.Errorf("got %q, wanted %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApp_checkVenvTarget(t *testing.T) {
+	tests := []struct {
+		name         string
+		setup        func(t *testing.T, dir string)
+		force        bool
+		wantRecreate bool
+		wantErr      bool
+	}{
+		{
+			name:         "doesn't exist",
+			setup:        func(t *testing.T, dir string) { t.Helper() },
+			wantRecreate: false,
+		},
+		{
+			name: "empty directory",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				mustMkdir(t, dir)
+			},
+			wantRecreate: false,
+		},
+		{
+			name: "not a directory",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				writeFile(t, dir, "")
+			},
+			wantErr: true,
+		},
+		{
+			name: "not a venv",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				mustMkdir(t, dir)
+				writeFile(t, filepath.Join(dir, "important.txt"), "")
+			},
+			force:   true,
+			wantErr: true,
+		},
+		{
+			name: "healthy venv",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				mustMkdir(t, filepath.Join(dir, "bin"))
+				writeFile(t, filepath.Join(dir, pyvenvCfgFile), "")
+				writeFile(t, filepath.Join(dir, "bin", "python"), "")
+			},
+			wantErr: true,
+		},
+		{
+			name: "healthy venv forced",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				mustMkdir(t, filepath.Join(dir, "bin"))
+				writeFile(t, filepath.Join(dir, pyvenvCfgFile), "")
+				writeFile(t, filepath.Join(dir, "bin", "python"), "")
+			},
+			force:        true,
+			wantRecreate: true,
+		},
+		{
+			name: "broken venv",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				mustMkdir(t, filepath.Join(dir, "bin"))
+				writeFile(t, filepath.Join(dir, pyvenvCfgFile), "")
+				if err := os.Symlink("/not/a/real/python3.6", filepath.Join(dir, "bin", "python")); err != nil {
+					t.Fatalf("could not symlink: %v", err)
+				}
+			},
+			wantRecreate: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), ".venv")
+			tt.setup(t, dir)
+
+			app := newTestApp(&bytes.Buffer{}, &bytes.Buffer{}, "")
+			recreate, err := app.checkVenvTarget(dir, tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkVenvTarget() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if recreate != tt.wantRecreate {
+				t.Errorf("got recreate %v, wanted %v", recreate, tt.wantRecreate)
+			}
+		})
+	}
+}
+
+func TestApp_CreateVenv(t *testing.T) {
+	bin := fakePythons(t, t.TempDir(), fakePythonScript, "python3.11", "python3.12")
+
+	venv := filepath.Join(t.TempDir(), ".venv")
+	stdout := &bytes.Buffer{}
+	app := newTestApp(stdout, &bytes.Buffer{}, bin)
+
+	spec := interpreter.Spec{Major: 3, Minor: 11, Patch: interpreter.Any}
+	if err := app.CreateVenv(VenvOptions{Spec: &spec, Path: venv, UpgradePip: true}); err != nil {
+		t.Fatalf("CreateVenv() returned an unexpected error: %v", err)
+	}
+
+	if !strings.Contains(stdout.String(), "Created virtual environment at "+venv+" with python 3.11") {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+
+	if !strings.Contains(stdout.String(), "Activate it with: source "+venv+"/bin/activate") {
+		t.Errorf("missing activation command: %q", stdout.String())
+	}
+
+	pipLog, err := os.ReadFile(filepath.Join(venv, "pip.log"))
+	if err != nil {
+		t.Fatalf("pip was not run: %v", err)
+	}
+	if got := string(pipLog); got != "install --upgrade pip\n" {
+		t.Errorf("wrong pip command: got %q", got)
+	}
+
+	// Now it exists, it shouldn't be touched without force
+	if err := app.CreateVenv(VenvOptions{Path: venv}); err == nil {
+		t.Fatal("expected an error recreating a healthy venv without force, got nil")
+	}
+
+	if err := app.CreateVenv(VenvOptions{Path: venv, Force: true}); err != nil {
+		t.Fatalf("CreateVenv() with force returned an unexpected error: %v", err)
+	}
+
+	venvLog, err := os.ReadFile(filepath.Join(venv, "venv.log"))
+	if err != nil {
+		t.Fatalf("could not read venv log: %v", err)
+	}
+
+	want := "-m venv " + venv + "\n-m venv --clear " + venv + "\n"
+	if got := string(venvLog); got != want {
+		t.Errorf("wrong venv commands: got %q, wanted %q", got, want)
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// BuildInfo describes the build of py that's running.
+type BuildInfo struct {
+	Version   string `json:"version"`   // The py version e.g. "v1.2.3" or "dev"
+	Commit    string `json:"commit"`    // The commit py was built from, empty if not known
+	GoVersion string `json:"goVersion"` // The version of Go py was built with e.g. "go1.21.5"
+	Date      string `json:"date"`      // When py was built (or committed), empty if not known
+}
+
+// Version prints py's version, the commit and date it was built from and the version
+// of Go used to build it, as JSON if 'asJSON' is set.
+//
+// This is py's own version, python's is still available with py -V.
+func (a *App) Version(asJSON bool) error {
+	info := buildInfo(debug.ReadBuildInfo)
+
+	if asJSON {
+		encoder := json.NewEncoder(a.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(info); err != nil {
+			return fmt.Errorf("could not encode version as JSON: %w", err)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(a.Stdout, "py %s\n", info.Version)
+	fmt.Fprintf(a.Stdout, "Commit: %s\n", orUnknown(info.Commit))
+	fmt.Fprintf(a.Stdout, "Built: %s\n", orUnknown(info.Date))
+	fmt.Fprintf(a.Stdout, "Go: %s\n", info.GoVersion)
+
+	return nil
+}
+
+// buildInfo works out the BuildInfo for py, preferring what was set with ldflags at
+// release time and falling back to what the Go toolchain embedded in the binary as
+// returned by 'read' (e.g. for a go install or a local build).
+func buildInfo(read func() (*debug.BuildInfo, bool)) BuildInfo {
+	info := BuildInfo{Version: version, Commit: commit, Date: date, GoVersion: runtime.Version()}
+
+	embedded, ok := read()
+	if !ok {
+		return info
+	}
+
+	if embedded.GoVersion != "" {
+		info.GoVersion = embedded.GoVersion
+	}
+
+	// go install github.com/FollowTheProcess/py/cmd/py@v1.2.3 sets the module version
+	if info.Version == "dev" && embedded.Main.Version != "" && embedded.Main.Version != "(devel)" {
+		info.Version = embedded.Main.Version
+	}
+
+	var revision, modified, vcsTime string
+	for _, setting := range embedded.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		case "vcs.time":
+			vcsTime = setting.Value
+		}
+	}
+
+	if info.Commit == "" && revision != "" {
+		info.Commit = revision
+		if modified == "true" {
+			info.Commit += "-dirty"
+		}
+	}
+
+	if info.Date == "" {
+		info.Date = vcsTime
+	}
+
+	return info
+}
+
+// orUnknown returns 's' or "unknown" if it's empty.
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
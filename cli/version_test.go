@@ -0,0 +1,110 @@
+package cli //nolint: testpackage // Need access to internals
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func Test_buildInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		embedded *debug.BuildInfo
+		version  string
+		commit   string
+		date     string
+		want     BuildInfo
+	}{
+		{
+			name:     "no embedded info",
+			embedded: nil,
+			version:  "dev",
+			want:     BuildInfo{Version: "dev", GoVersion: "go1.0"},
+		},
+		{
+			name: "ldflags win",
+			embedded: &debug.BuildInfo{
+				GoVersion: "go1.21.5",
+				Main:      debug.Module{Version: "v0.9.0"},
+				Settings:  []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}, {Key: "vcs.time", Value: "2024-01-01T00:00:00Z"}},
+			},
+			version: "v1.0.0",
+			commit:  "def456",
+			date:    "2024-02-02T00:00:00Z",
+			want:    BuildInfo{Version: "v1.0.0", Commit: "def456", GoVersion: "go1.21.5", Date: "2024-02-02T00:00:00Z"},
+		},
+		{
+			name: "go install",
+			embedded: &debug.BuildInfo{
+				GoVersion: "go1.21.5",
+				Main:      debug.Module{Version: "v1.2.3"},
+			},
+			version: "dev",
+			want:    BuildInfo{Version: "v1.2.3", GoVersion: "go1.21.5"},
+		},
+		{
+			name: "local build",
+			embedded: &debug.BuildInfo{
+				GoVersion: "go1.21.5",
+				Main:      debug.Module{Version: "(devel)"},
+				Settings: []debug.BuildSetting{
+					{Key: "vcs.revision", Value: "abc123"},
+					{Key: "vcs.time", Value: "2024-01-01T00:00:00Z"},
+					{Key: "vcs.modified", Value: "true"},
+				},
+			},
+			version: "dev",
+			want:    BuildInfo{Version: "dev", Commit: "abc123-dirty", GoVersion: "go1.21.5", Date: "2024-01-01T00:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oldVersion, oldCommit, oldDate := version, commit, date
+			t.Cleanup(func() { version, commit, date = oldVersion, oldCommit, oldDate })
+			version, commit, date = tt.version, tt.commit, tt.date
+
+			got := buildInfo(func() (*debug.BuildInfo, bool) { return tt.embedded, tt.embedded != nil })
+
+			// Without embedded info the Go version comes from the runtime
+			if tt.embedded == nil {
+				got.GoVersion = "go1.0"
+			}
+
+			if got != tt.want {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApp_Version(t *testing.T) {
+	stdout := &bytes.Buffer{}
+	app := newTestApp(stdout, &bytes.Buffer{}, "")
+
+	if err := app.Version(false); err != nil {
+		t.Fatalf("Version() returned an unexpected error: %v", err)
+	}
+
+	for _, want := range []string{"py ", "Commit: ", "Built: ", "Go: go"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("version output %q missing %q", stdout.String(), want)
+		}
+	}
+
+	stdout.Reset()
+	if err := app.Version(true); err != nil {
+		t.Fatalf("Version() returned an unexpected error: %v", err)
+	}
+
+	var info BuildInfo
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		t.Fatalf("Version() JSON %q is invalid: %v", stdout.String(), err)
+	}
+
+	if info.Version == "" || info.GoVersion == "" {
+		t.Errorf("incomplete version info: %#v", info)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/FollowTheProcess/py/cli"
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// errUnknownFlag is returned by parseArgs for a "--" flag that's neither py's nor python's.
+var errUnknownFlag = errors.New("unknown flag")
+
+// command is the thing py has been asked to do.
+type command int
+
+const (
+	commandLaunch         command = iota // Launch python, the default
+	commandHelp                          // --help
+	commandList                          // --list
+	commandDoctor                        // --doctor
+	commandVenv                          // --venv
+	commandAll                           // --all
+	commandPick                          // --pick
+	commandCompletions                   // --completions <shell>
+	commandListSpecifiers                // --list-specifiers (hidden)
+	commandVersion                       // --version
+)
+
+// commandFlags maps each command's flag to the command.
+var commandFlags = map[string]command{
+	"--help":            commandHelp,
+	"--list":            commandList,
+	"--doctor":          commandDoctor,
+	"--venv":            commandVenv,
+	"--all":             commandAll,
+	"--pick":            commandPick,
+	"--completions":     commandCompletions,
+	"--list-specifiers": commandListSpecifiers,
+	"--version":         commandVersion,
+}
+
+// pythonLongOptions are python's own long options, these are the only "--" flags
+// we pass through to python without a "--" separator. Note python's --help and --version
+// aren't here as they're shadowed by py's own, see shadowedPythonOptions.
+var pythonLongOptions = []string{"--check-hash-based-pycs", "--help-env", "--help-xoptions", "--help-all"}
+
+// shadowedPythonOptions are python's long options that py has commands of the same name
+// for, they're py's unless they come after a version specifier in which case they're
+// python's e.g. py -3.12 --version is python 3.12's version, not py's.
+var shadowedPythonOptions = []string{"--help", "--version"}
+
+// options is py's parsed command line.
+type options struct {
+	spec       *interpreter.Spec // The version or executable specifier, nil if there wasn't one
+	impl       string            // The implementation requested with --impl
+	shell      string            // The shell passed to --completions
+	flag       string            // The flag that selected the command e.g. "--list", empty for commandLaunch
+	args       []string          // The arguments after py's own, passed to python or used by the command
+	command    command           // What py has been asked to do
+	pre        bool              // --pre
+	explain    bool              // --explain
+	json       bool              // --json
+	verbose    bool              // --verbose
+	force      bool              // --force
+	upgradePip bool              // --upgrade-pip
+	parallel   bool              // --parallel
+	remember   bool              // --remember
+}
+
+// takesPythonArgs reports whether the command passes the remaining arguments through to python, commands
+// that don't are free to have their own arguments mixed in with py's options (e.g. py --venv env --force).
+func (c command) takesPythonArgs() bool {
+	return c == commandLaunch || c == commandAll || c == commandPick
+}
+
+// parseArgs parses py's command line 'args' (without the binary name).
+//
+// py's own options (flags and version or executable specifiers) must come first, in any order, and parsing
+// stops at the first argument meant for python, everything from there on is passed through untouched.
+// A "--" can be used to explicitly mark the end of py's options e.g. py -3.12 -- --weird-python-arg.
+//
+// Commands that don't launch python (e.g. --venv) have no python arguments, so their
+// own arguments may appear anywhere.
+//
+// 'dir' is the directory relative paths are relative to, a file there is always a
+// script even if it's name looks like an executable specifier.
+func parseArgs(args []string, dir string) (options, error) {
+	opts := options{command: commandLaunch}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if opts.spec != nil && opts.flag == "" && slices.Contains(shadowedPythonOptions, arg) {
+			// e.g. py -3 --help, python's help not ours
+			opts.args = append(opts.args, args[i:]...)
+			return opts.validate()
+		}
+
+		// py's flags are exactly cli.Flags, the same table the shell completions are generated from
+		if strings.HasPrefix(arg, "--") && arg != "--" && !isFlag(arg) && !slices.Contains(pythonLongOptions, arg) {
+			return options{}, fmt.Errorf("%w %q (use -- to pass it to python)", errUnknownFlag, arg)
+		}
+
+		if cmd, ok := commandFlags[arg]; ok {
+			if opts.flag != "" {
+				return options{}, fmt.Errorf("cannot use %s with %s", arg, opts.flag)
+			}
+			opts.command = cmd
+			opts.flag = arg
+
+			if cmd == commandCompletions {
+				if i+1 == len(args) {
+					return options{}, fmt.Errorf("--completions requires a shell name: bash, zsh or fish")
+				}
+				i++
+				opts.shell = args[i]
+			}
+			continue
+		}
+
+		switch {
+		case arg == "--":
+			opts.args = append(opts.args, args[i+1:]...)
+			return opts.validate()
+
+		case arg == "--pre":
+			opts.pre = true
+
+		case arg == "--explain":
+			opts.explain = true
+
+		case arg == "--json":
+			opts.json = true
+
+		case arg == "--verbose":
+			opts.verbose = true
+
+		case arg == "--force":
+			opts.force = true
+
+		case arg == "--upgrade-pip":
+			opts.upgradePip = true
+
+		case arg == "--parallel":
+			opts.parallel = true
+
+		case arg == "--remember":
+			opts.remember = true
+
+		case arg == "--impl":
+			if i+1 == len(args) {
+				return options{}, fmt.Errorf("--impl requires an implementation name e.g. pypy")
+			}
+			i++
+			if !interpreter.IsImplementation(args[i]) {
+				return options{}, fmt.Errorf("unknown python implementation %q", args[i])
+			}
+			opts.impl = args[i]
+
+		case isMajorSpecifier(arg), isExactSpecifier(arg), isExecutableSpecifier(arg, dir):
+			if opts.spec != nil {
+				return options{}, fmt.Errorf("only one version specifier may be given, got %s and %s", opts.spec.Executable(), arg)
+			}
+			spec := parseSpecifier(arg)
+			opts.spec = &spec
+
+		case opts.command.takesPythonArgs():
+			// The first python argument, everything from here on is python's
+			opts.args = append(opts.args, args[i:]...)
+			return opts.validate()
+
+		case strings.HasPrefix(arg, "-"):
+			return options{}, fmt.Errorf("unknown %s option %q", opts.flag, arg)
+
+		default:
+			opts.args = append(opts.args, arg)
+		}
+	}
+
+	return opts.validate()
+}
+
+// validate checks the options that have been parsed make sense together, filling in the
+// implementation on the spec if one was requested with --impl.
+func (o options) validate() (options, error) {
+	// Options only some commands understand
+	for _, check := range [...]struct {
+		flag    string
+		set     bool
+		allowed []command
+	}{
+		{flag: "--json", set: o.json, allowed: []command{commandList, commandVersion}},
+		{flag: "--verbose", set: o.verbose, allowed: []command{commandList}},
+		{flag: "--force", set: o.force, allowed: []command{commandVenv}},
+		{flag: "--upgrade-pip", set: o.upgradePip, allowed: []command{commandVenv}},
+		{flag: "--parallel", set: o.parallel, allowed: []command{commandAll}},
+		{flag: "--remember", set: o.remember, allowed: []command{commandPick}},
+	} {
+		if check.set && !slices.Contains(check.allowed, o.command) {
+			return options{}, fmt.Errorf("%s cannot be used without %s", check.flag, allowedFlags(check.allowed))
+		}
+	}
+
+	switch o.command {
+	case commandHelp, commandDoctor, commandCompletions, commandListSpecifiers, commandVersion:
+		if o.spec != nil || o.impl != "" || len(o.args) != 0 {
+			return options{}, fmt.Errorf("cannot use %s with any other arguments", o.flag)
+		}
+	case commandList:
+		if len(o.args) != 0 {
+			return options{}, fmt.Errorf("--list takes no arguments other than a version specifier, got %q", o.args[0])
+		}
+	case commandVenv:
+		if len(o.args) > 1 {
+			return options{}, fmt.Errorf("--venv takes a single path, got %q and %q", o.args[0], o.args[1])
+		}
+	case commandAll:
+		if len(o.args) == 0 {
+			return options{}, fmt.Errorf("--all requires something to run e.g. py --all -c 'import sys'")
+		}
+	case commandLaunch, commandPick:
+		// Anything goes
+	}
+
+	if o.impl != "" {
+		if o.spec == nil {
+			o.spec = &interpreter.Spec{Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any}
+		}
+		if o.spec.Implementation != "" && o.spec.Implementation != o.impl {
+			return options{}, fmt.Errorf("--impl %s conflicts with %s", o.impl, o.spec.Executable())
+		}
+		o.spec.Implementation = o.impl
+	}
+
+	return o, nil
+}
+
+// isFlag reports whether 'arg' is one of py's own flags.
+func isFlag(arg string) bool {
+	return slices.ContainsFunc(cli.Flags(), func(flag cli.Flag) bool { return flag.Name == arg })
+}
+
+// allowedFlags returns the flags for 'commands' for use in an error message e.g. "--list or --all".
+func allowedFlags(commands []command) string {
+	flags := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		for flag, c := range commandFlags {
+			if c == cmd {
+				flags = append(flags, flag)
+			}
+		}
+	}
+	slices.Sort(flags)
+	return strings.Join(flags, " or ")
+}
+
+// parseSpecifier parses 'arg', which must already be known to be a major, exact
+// or executable specifier, into a Spec.
+func parseSpecifier(arg string) interpreter.Spec {
+	switch {
+	case isMajorSpecifier(arg):
+		return interpreter.Spec{Major: parseMajorSpecifier(arg), Minor: interpreter.Any, Patch: interpreter.Any}
+	case isExactSpecifier(arg):
+		return parseExactSpecifier(arg)
+	default:
+		return parseExecutableSpecifier(arg)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/FollowTheProcess/py/cli"
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+func TestParseArgs(t *testing.T) {
+	spec := func(major, minor int) *interpreter.Spec {
+		return &interpreter.Spec{Major: major, Minor: minor, Patch: interpreter.Any}
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    options
+		wantErr bool
+	}{
+		{
+			name:    "nothing",
+			args:    nil,
+			want:    options{command: commandLaunch},
+			wantErr: false,
+		},
+		{
+			name:    "script",
+			args:    []string{"script.py", "--list"},
+			want:    options{command: commandLaunch, args: []string{"script.py", "--list"}},
+			wantErr: false,
+		},
+		{
+			name:    "specifier and python args",
+			args:    []string{"-3.12", "-m", "pytest", "-3"},
+			want:    options{command: commandLaunch, spec: spec(3, 12), args: []string{"-m", "pytest", "-3"}},
+			wantErr: false,
+		},
+		{
+			name:    "options in any order",
+			args:    []string{"-3", "--explain", "--pre", "-c", "pass"},
+			want:    options{command: commandLaunch, spec: spec(3, interpreter.Any), explain: true, pre: true, args: []string{"-c", "pass"}},
+			wantErr: false,
+		},
+		{
+			name:    "double dash",
+			args:    []string{"-3.12", "--", "--list", "-3"},
+			want:    options{command: commandLaunch, spec: spec(3, 12), args: []string{"--list", "-3"}},
+			wantErr: false,
+		},
+		{
+			name:    "python long option",
+			args:    []string{"--help-env"},
+			want:    options{command: commandLaunch, args: []string{"--help-env"}},
+			wantErr: false,
+		},
+		{
+			name:    "version",
+			args:    []string{"--version", "--json"},
+			want:    options{command: commandVersion, flag: "--version", json: true},
+			wantErr: false,
+		},
+		{
+			name:    "python's version",
+			args:    []string{"-3.12", "-V"},
+			want:    options{command: commandLaunch, spec: spec(3, 12), args: []string{"-V"}},
+			wantErr: false,
+		},
+		{
+			name:    "python's help after specifier",
+			args:    []string{"-3", "--help"},
+			want:    options{command: commandLaunch, spec: spec(3, interpreter.Any), args: []string{"--help"}},
+			wantErr: false,
+		},
+		{
+			name:    "python's long version after specifier",
+			args:    []string{"--pre", "-3.12", "--version", "--json"},
+			want:    options{command: commandLaunch, spec: spec(3, 12), pre: true, args: []string{"--version", "--json"}},
+			wantErr: false,
+		},
+		{
+			name:    "python's long version after python args",
+			args:    []string{"-u", "--version"},
+			want:    options{command: commandLaunch, args: []string{"-u", "--version"}},
+			wantErr: false,
+		},
+		{
+			name:    "list json",
+			args:    []string{"--list", "--json"},
+			want:    options{command: commandList, flag: "--list", json: true},
+			wantErr: false,
+		},
+		{
+			name:    "list verbose",
+			args:    []string{"--verbose", "--list", "-3.10"},
+			want:    options{command: commandList, flag: "--list", verbose: true, spec: &interpreter.Spec{Major: 3, Minor: 10, Patch: interpreter.Any}},
+			wantErr: false,
+		},
+		{
+			name:    "list with specifier",
+			args:    []string{"-3.12", "--list"},
+			want:    options{command: commandList, flag: "--list", spec: spec(3, 12)},
+			wantErr: false,
+		},
+		{
+			name: "impl with specifier",
+			args: []string{"--impl", "pypy", "-3.10", "script.py"},
+			want: options{
+				command: commandLaunch,
+				impl:    interpreter.PyPy,
+				spec:    &interpreter.Spec{Implementation: interpreter.PyPy, Major: 3, Minor: 10, Patch: interpreter.Any},
+				args:    []string{"script.py"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "impl without specifier",
+			args: []string{"--impl", "graalpy"},
+			want: options{
+				command: commandLaunch,
+				impl:    interpreter.GraalPy,
+				spec:    &interpreter.Spec{Implementation: interpreter.GraalPy, Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "venv options in any order",
+			args:    []string{"--venv", "env", "--force", "-3.12", "--upgrade-pip"},
+			want:    options{command: commandVenv, flag: "--venv", spec: spec(3, 12), force: true, upgradePip: true, args: []string{"env"}},
+			wantErr: false,
+		},
+		{
+			name:    "all",
+			args:    []string{"--all", "--parallel", "-3", "-m", "pytest"},
+			want:    options{command: commandAll, flag: "--all", spec: spec(3, interpreter.Any), parallel: true, args: []string{"-m", "pytest"}},
+			wantErr: false,
+		},
+		{
+			name:    "pick",
+			args:    []string{"--remember", "--pick", "-3", "script.py", "--remember"},
+			want:    options{command: commandPick, flag: "--pick", spec: spec(3, interpreter.Any), remember: true, args: []string{"script.py", "--remember"}},
+			wantErr: false,
+		},
+		{
+			name:    "completions",
+			args:    []string{"--completions", "zsh"},
+			want:    options{command: commandCompletions, flag: "--completions", shell: "zsh"},
+			wantErr: false,
+		},
+		{
+			name:    "unknown launcher flag",
+			args:    []string{"--lsit"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "two commands",
+			args:    []string{"--list", "--doctor"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "two specifiers",
+			args:    []string{"-3", "-3.12"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "verbose without list",
+			args:    []string{"--verbose"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "json without list",
+			args:    []string{"--json", "-3"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "force without venv",
+			args:    []string{"--force"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "parallel without all",
+			args:    []string{"--parallel", "-c", "pass"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "remember without pick",
+			args:    []string{"--remember", "script.py"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "version with specifier",
+			args:    []string{"--version", "-3"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "list with extra arg",
+			args:    []string{"--list", "something"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "list with unknown option",
+			args:    []string{"--list", "-x"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "help with specifier",
+			args:    []string{"--help", "-3"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "completions without shell",
+			args:    []string{"--completions"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "impl without name",
+			args:    []string{"--impl"},
+			want:    options{},
+			wantErr: true,
+		},
+		{
+			name:    "impl conflicts with executable specifier",
+			args:    []string{"--impl", "pypy", "graalpy3.11"},
+			want:    options{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseArgs(tt.args, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseArgs() err = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgs_scriptInDir(t *testing.T) {
+	// A script named like an executable specifier in the directory py is run
+	// from is a script, wherever the py process happens to be
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pypy3.10"), []byte("print('hello')\n"), 0o644); err != nil {
+		t.Fatalf("could not write script: %v", err)
+	}
+
+	got, err := parseArgs([]string{"pypy3.10"}, dir)
+	if err != nil {
+		t.Fatalf("parseArgs() returned an unexpected error: %v", err)
+	}
+
+	want := options{command: commandLaunch, args: []string{"pypy3.10"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
+
+func TestParseArgs_flags(t *testing.T) {
+	// Every flag the shell completions offer (and the hidden ones) must be handled by
+	// parseArgs, not rejected as unknown or passed through to python
+	values := map[string]string{"--impl": "pypy", "--completions": "bash"}
+
+	for _, flag := range cli.Flags() {
+		t.Run(flag.Name, func(t *testing.T) {
+			args := []string{flag.Name}
+			if value, ok := values[flag.Name]; ok {
+				args = append(args, value)
+			}
+
+			got, err := parseArgs(args, "")
+			if errors.Is(err, errUnknownFlag) {
+				t.Fatalf("parseArgs() doesn't know %s: %v", flag.Name, err)
+			}
+
+			if slices.Contains(got.args, flag.Name) {
+				t.Errorf("parseArgs() passed %s through as an argument: %#v", flag.Name, got.args)
+			}
+		})
+	}
+
+	// And nothing else
+	if _, err := parseArgs([]string{"--not-a-flag"}, ""); !errors.Is(err, errUnknownFlag) {
+		t.Errorf("parseArgs() err = %v, wanted %v", err, errUnknownFlag)
+	}
+}
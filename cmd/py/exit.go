@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/FollowTheProcess/py/cli"
+)
+
+// py's exit codes, so scripts can tell why it failed without matching on the message.
+//
+// When python is launched it replaces py, so any other exit code is python's own.
+const (
+	exitError         = 1 // Any error not listed below
+	exitUsage         = 2 // py was called with bad arguments
+	exitNoInterpreter = 3 // There are no python interpreters on $PATH
+	exitNoMatch       = 4 // There are interpreters but none match what was asked for
+	exitMalformedEnv  = 5 // An environment variable py reads is invalid
+	exitBrokenVenv    = 6 // The virtual environment py would use is broken
+)
+
+// usageError marks an error as being caused by the arguments py was called with.
+type usageError struct {
+	err error // The underlying error
+}
+
+// Error implements the error interface for usageError.
+func (u usageError) Error() string {
+	return u.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (u usageError) Unwrap() error {
+	return u.err
+}
+
+// exitCode returns the exit code py should exit with for 'err'.
+func exitCode(err error) int {
+	var (
+		usage     usageError
+		noMatch   *cli.NoMatchError
+		malformed *cli.MalformedEnvError
+		broken    *cli.BrokenVenvError
+	)
+
+	switch {
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.As(err, &noMatch):
+		return exitNoMatch
+	case errors.Is(err, cli.ErrNoInterpreter):
+		return exitNoInterpreter
+	case errors.As(err, &malformed):
+		return exitMalformedEnv
+	case errors.As(err, &broken):
+		return exitBrokenVenv
+	default:
+		return exitError
+	}
+}
+
+// hints returns suggestions of how to fix 'err', or nil if we don't have any.
+func hints(err error) []string {
+	var (
+		usage     usageError
+		noMatch   *cli.NoMatchError
+		malformed *cli.MalformedEnvError
+		broken    *cli.BrokenVenvError
+	)
+
+	switch {
+	case errors.As(err, &usage):
+		return []string{"see py --help for usage"}
+	case errors.As(err, &noMatch):
+		commands := noMatch.InstallCommands()
+		if len(commands) == 0 {
+			return []string{"run py --list to see the pythons that are installed"}
+		}
+		suggestions := make([]string, 0, len(commands))
+		for _, command := range commands {
+			suggestions = append(suggestions, "install it with "+command)
+		}
+		return suggestions
+	case errors.Is(err, cli.ErrNoInterpreter):
+		return []string{"install python or add the directory it's in to $PATH, py --doctor can help"}
+	case errors.As(err, &malformed):
+		return []string{fmt.Sprintf("fix or unset $%s, see py --help", malformed.Key)}
+	case errors.As(err, &broken):
+		return []string{fmt.Sprintf("recreate it with py --venv --force %s, or deactivate it", broken.Path)}
+	default:
+		return nil
+	}
+}
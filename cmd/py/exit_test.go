@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/py/cli"
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+func TestExitCode(t *testing.T) {
+	spec := interpreter.Spec{Major: 3, Minor: 12, Patch: interpreter.Any}
+
+	tests := []struct {
+		err      error
+		name     string
+		wantHint string
+		want     int
+	}{
+		{
+			name:     "plain error",
+			err:      errors.New("boom"),
+			want:     exitError,
+			wantHint: "",
+		},
+		{
+			name:     "usage",
+			err:      usageError{err: errors.New("unknown flag")},
+			want:     exitUsage,
+			wantHint: "py --help",
+		},
+		{
+			name:     "no interpreter",
+			err:      fmt.Errorf("wrapped: %w", cli.ErrNoInterpreter),
+			want:     exitNoInterpreter,
+			wantHint: "$PATH",
+		},
+		{
+			name:     "no match",
+			err:      fmt.Errorf("wrapped: %w", &cli.NoMatchError{Spec: spec}),
+			want:     exitNoMatch,
+			wantHint: "py --list",
+		},
+		{
+			name:     "no match with installer",
+			err:      &cli.NoMatchError{Spec: spec, Installers: []string{"uv"}},
+			want:     exitNoMatch,
+			wantHint: "install it with uv python install 3.12",
+		},
+		{
+			name:     "malformed env",
+			err:      &cli.MalformedEnvError{Key: "PY_PYTHON", Value: "3", Reason: "not X.Y format"},
+			want:     exitMalformedEnv,
+			wantHint: "$PY_PYTHON",
+		},
+		{
+			name:     "broken venv",
+			err:      &cli.BrokenVenvError{Path: ".venv", Python: ".venv/bin/python"},
+			want:     exitBrokenVenv,
+			wantHint: "py --venv --force .venv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.want {
+				t.Errorf("exitCode() = %d, wanted %d", got, tt.want)
+			}
+
+			got := strings.Join(hints(tt.err), "\n")
+			if tt.wantHint == "" && got != "" {
+				t.Errorf("hints() = %q, wanted no hints", got)
+			}
+			if !strings.Contains(got, tt.wantHint) {
+				t.Errorf("hints() = %q, wanted them to contain %q", got, tt.wantHint)
+			}
+		})
+	}
+}
@@ -32,16 +32,15 @@ package main
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/FollowTheProcess/py/cli"
+	"github.com/FollowTheProcess/py/interpreter"
 	"github.com/fatih/color"
-	"github.com/sirupsen/logrus"
 )
 
-const xYParts = 2 // Number of parts in an X.Y version
-
 func main() {
 	// Note: because we require passing a version specifier (e.g. -X or -X.Y)
 	// we can't use the stdlib flag or spf13 pflag packages as these will get
@@ -55,123 +54,91 @@ func main() {
 		title := color.New(color.FgRed).Add(color.Bold)
 		msg := color.New(color.FgWhite).Add(color.Bold)
 		fmt.Fprintf(os.Stderr, "%s: %s\n", title.Sprint("error"), msg.Sprint(err))
-		os.Exit(1)
+
+		// Typed errors get hints on how to fix them and their own exit code
+		hintTitle := color.New(color.FgCyan).Add(color.Bold)
+		for _, hint := range hints(err) {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", hintTitle.Sprint("hint"), hint)
+		}
+		os.Exit(exitCode(err))
 	}
 }
 
 func run(app *cli.App, args []string) error {
-	switch len(args) {
-	case 0:
-		// No arguments, means the user wants to launch a REPL
-		// follow control flow to find which version to launch
-		app.Logger.Debugln("py called with 0 arguments, launching python REPL")
-		if err := app.Launch([]string{}); err != nil {
-			return fmt.Errorf("%w", err)
-		}
-
-	case 1:
-		// We have a single command line argument which could mean several things
-		// dispatch to handleSingleArg
-		arg := args[0]
-		app.Logger.WithField("argument", arg).Debugln("py called with single argument")
-		if err := handleSingleArg(app, arg); err != nil {
-			return err
-		}
-
-	default:
-		// If we get here we have > 1 argument, which could also mean several things
-		// depending on what the first of these arguments is, dispatch to handleMultipleArgs
-		app.Logger.WithField("arguments", args).Debugln("py called with multiple arguments")
-		if err := handleMultipleArgs(app, args); err != nil {
-			return err
-		}
+	opts, err := parseArgs(args, app.Dir)
+	if err != nil {
+		return usageError{err: err}
 	}
 
-	return nil
-}
+	app.Logger.Debug("Parsed arguments", cli.LogKeyCommand, opts.flag, cli.LogKeyArgs, opts.args)
+
+	// --pre opts in to pre-release pythons and --explain swaps launching for an
+	// explanation of how the python was found, for whatever command was asked for
+	app.PreRelease = app.PreRelease || opts.pre
+	app.Explain = app.Explain || opts.explain
 
-// handleSingleArg handles the case where py is passed a single command line argument
-// which could mean several things:
-//  1. known flag (e.g. --list)
-//  2. version specifier of the form -X or -X.Y
-//  3. file (e.g. py script.py)
-func handleSingleArg(app *cli.App, arg string) error {
-	switch {
-	case arg == "--help":
+	switch opts.command {
+	case commandHelp:
 		app.Help()
 
-	case arg == "--list":
-		if err := app.List(); err != nil {
+	case commandList:
+		if err := app.List(cli.ListOptions{Spec: opts.spec, JSON: opts.json, Verbose: opts.verbose}); err != nil {
 			return fmt.Errorf("%w", err)
 		}
 
-	case isMajorSpecifier(arg):
-		// User has passed something like -3
-		major := parseMajorSpecifier(arg)
-		app.Logger.Debugln("Argument was major specifier")
-		if err := app.LaunchMajor(major, []string{}); err != nil {
+	case commandVersion:
+		if err := app.Version(opts.json); err != nil {
 			return fmt.Errorf("%w", err)
 		}
 
-	case isExactSpecifier(arg):
-		// User has passed something like -3.10
-		major, minor := parseExactSpecifier(arg)
-		app.Logger.Debugln("Argument was exact specifier")
-		if err := app.LaunchExact(major, minor, []string{}); err != nil {
+	case commandDoctor:
+		if err := app.Doctor(); err != nil {
 			return fmt.Errorf("%w", err)
 		}
 
-	default:
-		// If we got here, the argument could be a file (e.g. py script.py)
-		// in which case call python with the file as the argument
-		// could also be a single python flag e.g. python -V for version info
-		app.Logger.Debugln("Unrecognised argument. Launching python and passing argument through")
-		if err := app.Launch([]string{arg}); err != nil {
+	case commandListSpecifiers:
+		// Hidden, used by the shell completions to complete installed versions
+		if err := app.ListSpecifiers(); err != nil {
 			return fmt.Errorf("%w", err)
 		}
-	}
 
-	return nil
-}
+	case commandCompletions:
+		if err := app.Completions(opts.shell); err != nil {
+			return fmt.Errorf("%w", err)
+		}
 
-// handleMultipleArgs handles the case in which py was passed > 1 command line argument
-// which could mean a few things depending on what the first argument is:
-//  1. Known flag: error out as they do not support arguments
-//  2. Version specifier (-X or -X.Y): Launch matching version and pass all other args through
-//  3. Unknown: Follow control flow to find a python and pass all args through
-func handleMultipleArgs(app *cli.App, args []string) error {
-	rest := args[1:]
-	switch first := args[0]; {
-	case first == "--help":
-		return fmt.Errorf("cannot use --help with any other arguments")
-
-	case first == "--list":
-		return fmt.Errorf("cannot use --list with any other arguments")
-
-	case isMajorSpecifier(first):
-		// User has passed something like "py -3 first ..."
-		major := parseMajorSpecifier(first)
-		// Strip off the major version specifier and pass remaining args through
-		app.Logger.WithFields(logrus.Fields{"major specifier": first, "args": args[1:]}).Debugln("First arg was major specifier")
-		if err := app.LaunchMajor(major, args[1:]); err != nil {
+	case commandVenv:
+		venvOpts := cli.VenvOptions{Spec: opts.spec, Force: opts.force, UpgradePip: opts.upgradePip}
+		if len(opts.args) != 0 {
+			venvOpts.Path = opts.args[0]
+		}
+		if err := app.CreateVenv(venvOpts); err != nil {
 			return fmt.Errorf("%w", err)
 		}
 
-	case isExactSpecifier(first):
-		// User has passed something like "py -3.10 first ..."
-		major, minor := parseExactSpecifier(first)
-		// Strip off the exact version specifier and pass remaining args through
-		app.Logger.WithFields(logrus.Fields{"exact specifier": first, "args": args[1:]}).Debugln("First arg was exact specifier")
-		if err := app.LaunchExact(major, minor, rest); err != nil {
+	case commandAll:
+		if err := app.RunAll(cli.AllOptions{Spec: opts.spec, Parallel: opts.parallel}, opts.args); err != nil {
 			return fmt.Errorf("%w", err)
 		}
 
-	default:
-		// If we get here it's unknown args
-		// in which case follow the control flow, launch the resulting python
-		// and pass all the arguments through
-		app.Logger.WithField("arguments", args).Debugln("Unrecognised arguments")
-		if err := app.Launch(args); err != nil {
+	case commandPick:
+		if err := app.Pick(cli.PickOptions{Spec: opts.spec, Remember: opts.remember}, opts.args); err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+	case commandLaunch:
+		// No specifier means follow the control flow to find which version to launch,
+		// with no python args at all that means the user wants a REPL
+		if opts.spec == nil {
+			app.Logger.Debug("No specifier, following control flow", cli.LogKeyArgs, opts.args)
+			if err := app.Launch(opts.args); err != nil {
+				return fmt.Errorf("%w", err)
+			}
+			return nil
+		}
+
+		app.Logger.Debug("Launching requested version", cli.LogKeySpecifier, opts.spec.Executable(), cli.LogKeyArgs, opts.args)
+		if err := app.LaunchSpec(*opts.spec, opts.args); err != nil {
 			return fmt.Errorf("%w", err)
 		}
 	}
@@ -220,52 +187,68 @@ func parseMajorSpecifier(arg string) int {
 }
 
 // isExactSpecifier determines if the argument passed to it
-// is a valid exact version specifier (e.g. "-3.9").
+// is a valid exact version specifier (e.g. "-3.9", "-3.12.4" or "-3.13t")
+// or a major version specifier with an architecture (e.g. "-3-64").
 func isExactSpecifier(arg string) bool {
-	// If we don't start with a "-" it's not a major specifier
+	// If we don't start with a "-" it's not an exact specifier
 	if !strings.HasPrefix(arg, "-") {
 		return false
 	}
 
-	// Remove the "-"
-	arg = arg[1:]
-
-	// Whats remaining needs to be "X.Y"
-	parts := strings.Split(arg, ".")
-	if len(parts) != xYParts {
+	// Whats remaining needs to be "X.Y" or "X.Y.Z" with optional ABI flags and architecture
+	spec, err := interpreter.ParseSpec(arg[1:])
+	if err != nil {
 		return false
 	}
 
-	major, minor := parts[0], parts[1]
+	return (spec.Minor != interpreter.Any || spec.Bits != 0) && spec.Implementation == ""
+}
+
+// parseExactSpecifier takes in an argument we already know to be an exact version specifier
+// and returns the parsed specifier.
+//
+// In the interest of performance, this function assumes that 'arg' is already a valid
+// exact version specifier in string form.
+func parseExactSpecifier(arg string) interpreter.Spec {
+	// We ignore the error here because this will only get called
+	// in the case that isExactSpecifier has evaluated to true
+	spec, _ := interpreter.ParseSpec(arg[1:]) //nolint: errcheck
 
-	// Both parts need to be an integer
-	if _, err := strconv.Atoi(major); err != nil {
+	return spec
+}
+
+// isExecutableSpecifier determines if the argument passed to it
+// is a valid executable specifier (e.g. "pypy3.10" or "python3.12")
+// as opposed to a file in 'dir' that happens to look like one. An
+// empty 'dir' means the current working directory.
+func isExecutableSpecifier(arg, dir string) bool {
+	spec, err := interpreter.ParseSpec(arg)
+	if err != nil || spec.Implementation == "" {
 		return false
 	}
 
-	if _, err := strconv.Atoi(minor); err != nil {
+	path := arg
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	// A file on disk always wins, it's a script not a specifier
+	if _, err := os.Stat(path); err == nil {
 		return false
 	}
 
 	return true
 }
 
-// parseExactSpecifier takes in an argument we already know to be an exact version specifier
-// and returns the integer representations.
+// parseExecutableSpecifier takes in an argument we already know to be an executable specifier
+// and returns the parsed specifier.
 //
 // In the interest of performance, this function assumes that 'arg' is already a valid
-// exact version specifier in string form.
-func parseExactSpecifier(arg string) (int, int) {
-	// Remove the "-"
-	arg = arg[1:]
-
-	parts := strings.Split(arg, ".")
-	major, minor := parts[0], parts[1]
-
+// executable specifier in string form.
+func parseExecutableSpecifier(arg string) interpreter.Spec {
 	// We ignore the error here because this will only get called
-	// in the case that isExactSpecifier has evaluated to true
-	majorInt, _ := strconv.Atoi(major) //nolint: errcheck
-	minorInt, _ := strconv.Atoi(minor) //nolint: errcheck
+	// in the case that isExecutableSpecifier has evaluated to true
+	spec, _ := interpreter.ParseSpec(arg) //nolint: errcheck
 
-	return majorInt, minorInt
+	return spec
 }
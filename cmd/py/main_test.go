@@ -2,9 +2,13 @@ package main
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/FollowTheProcess/py/cli"
+	"github.com/FollowTheProcess/py/interpreter"
 )
 
 func TestIsMajorSpecifier(t *testing.T) {
@@ -130,9 +134,49 @@ func TestIsExactSpecifier(t *testing.T) {
 			arg:  "-3.",
 			want: false,
 		},
+		{
+			name: "free-threaded",
+			arg:  "-3.13t",
+			want: true,
+		},
+		{
+			name: "debug",
+			arg:  "-3.12d",
+			want: true,
+		},
+		{
+			name: "unknown ABI flag",
+			arg:  "-3.12x",
+			want: false,
+		},
+		{
+			name: "64 bit",
+			arg:  "-3.12-64",
+			want: true,
+		},
+		{
+			name: "major 32 bit",
+			arg:  "-3-32",
+			want: true,
+		},
+		{
+			name: "unknown bits",
+			arg:  "-3.12-16",
+			want: false,
+		},
 		{
 			name: "version includes patch",
 			arg:  "-3.9.8",
+			want: true,
+		},
+		{
+			name: "patch not valid int",
+			arg:  "-3.9.x",
+			want: false,
+		},
+		{
+			name: "too many components",
+			arg:  "-3.9.8.1",
 			want: false,
 		},
 		{
@@ -190,45 +234,121 @@ func TestParseMajorSpecifier(t *testing.T) {
 
 func TestParseExactSpecifier(t *testing.T) {
 	tests := []struct {
-		name      string
-		arg       string
-		wantMajor int
-		wantMinor int
+		name string
+		arg  string
+		want interpreter.Spec
 	}{
 		{
-			name:      "3.9",
-			arg:       "-3.9",
-			wantMajor: 3,
-			wantMinor: 9,
+			name: "3.9",
+			arg:  "-3.9",
+			want: interpreter.Spec{Major: 3, Minor: 9, Patch: interpreter.Any},
+		},
+		{
+			name: "2.7",
+			arg:  "-2.7",
+			want: interpreter.Spec{Major: 2, Minor: 7, Patch: interpreter.Any},
+		},
+		{
+			name: "3.10",
+			arg:  "-3.10",
+			want: interpreter.Spec{Major: 3, Minor: 10, Patch: interpreter.Any},
+		},
+		{
+			name: "4.0",
+			arg:  "-4.0",
+			want: interpreter.Spec{Major: 4, Minor: 0, Patch: interpreter.Any},
 		},
 		{
-			name:      "2.7",
-			arg:       "-2.7",
-			wantMajor: 2,
-			wantMinor: 7,
+			name: "3.12.4",
+			arg:  "-3.12.4",
+			want: interpreter.Spec{Major: 3, Minor: 12, Patch: 4},
 		},
 		{
-			name:      "3.10",
-			arg:       "-3.10",
-			wantMajor: 3,
-			wantMinor: 10,
+			name: "3.12-32",
+			arg:  "-3.12-32",
+			want: interpreter.Spec{Major: 3, Minor: 12, Patch: interpreter.Any, Bits: 32},
 		},
 		{
-			name:      "4.0",
-			arg:       "-4.0",
-			wantMajor: 4,
-			wantMinor: 0,
+			name: "3.13t",
+			arg:  "-3.13t",
+			want: interpreter.Spec{Major: 3, Minor: 13, ABIFlags: "t", Patch: interpreter.Any},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			major, minor := parseExactSpecifier(tt.arg)
-			if major != tt.wantMajor {
-				t.Errorf("major version difference, got %d, wanted %d", major, tt.wantMajor)
+			if got := parseExactSpecifier(tt.arg); got != tt.want {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
 			}
-			if minor != tt.wantMinor {
-				t.Errorf("minor version difference, got %d, wanted %d", minor, tt.wantMinor)
+		})
+	}
+}
+
+func TestIsExecutableSpecifier(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "python3.12"), []byte("print('hello')\n"), 0o644); err != nil {
+		t.Fatalf("could not write script: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		arg  string
+		dir  string
+		want bool
+	}{
+		{
+			name: "pypy",
+			arg:  "pypy3.10",
+			want: true,
+		},
+		{
+			name: "graalpy major",
+			arg:  "graalpy3",
+			want: true,
+		},
+		{
+			name: "cpython",
+			arg:  "python3.12",
+			want: true,
+		},
+		{
+			name: "no version",
+			arg:  "pypy",
+			want: false,
+		},
+		{
+			name: "version specifier",
+			arg:  "-3.10",
+			want: false,
+		},
+		{
+			name: "script",
+			arg:  "script.py",
+			want: false,
+		},
+		{
+			name: "unknown implementation",
+			arg:  "jython2.7",
+			want: false,
+		},
+		{
+			name: "script in dir",
+			arg:  "python3.12",
+			dir:  dir,
+			want: false,
+		},
+		{
+			name: "script in another dir",
+			arg:  "python3.12",
+			dir:  t.TempDir(),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isExecutableSpecifier(tt.arg, tt.dir); got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
 			}
 		})
 	}
@@ -254,6 +374,66 @@ func TestCLIFlags(t *testing.T) {
 			want:    "",
 			wantErr: false,
 		},
+		{
+			name:    "--impl with no implementation",
+			args:    []string{"--impl"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "--impl with unknown implementation",
+			args:    []string{"--impl", "jython", "-2.7"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "--venv with unknown option",
+			args:    []string{"--venv", "--recreate"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "--venv with two paths",
+			args:    []string{"--venv", "-3", "env1", "env2"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "--doctor with extra arg",
+			args:    []string{"--doctor", "something"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "--all with nothing to run",
+			args:    []string{"--all", "--parallel", "-3"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "--completions with no shell",
+			args:    []string{"--completions"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "--completions with unknown shell",
+			args:    []string{"--completions", "powershell"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "unknown launcher flag",
+			args:    []string{"--lsit"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "--json without --list",
+			args:    []string{"--json", "-3"},
+			want:    "",
+			wantErr: true,
+		},
 		{
 			name:    "--help with extra arg",
 			args:    []string{"--help", "something"},
@@ -277,3 +457,25 @@ func TestCLIFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestExplain(t *testing.T) {
+	appOut := &bytes.Buffer{}
+	appErr := &bytes.Buffer{}
+
+	app := cli.New(appOut, appErr)
+	app.Path = filepath.Join("..", "..", "interpreter", "testdata", "pythonpaths", "pythonpath1")
+
+	// Options can come in any order, and with --explain nothing is launched
+	if err := run(app, []string{"--explain", "--pre", "-3.9", "-c", "pass"}); err != nil {
+		t.Fatalf("run() returned an unexpected error: %v", err)
+	}
+
+	if !app.Explain || !app.PreRelease {
+		t.Errorf("options not applied: Explain = %v, PreRelease = %v", app.Explain, app.PreRelease)
+	}
+
+	want := filepath.Join("pythonpath1", "python3.9") + " -c pass\n"
+	if got := appOut.String(); !strings.Contains(got, "Would launch: ") || !strings.HasSuffix(got, want) {
+		t.Errorf("got %q, wanted it to end with %q", got, want)
+	}
+}
@@ -2,10 +2,7 @@ module github.com/FollowTheProcess/py
 
 go 1.21
 
-require (
-	github.com/fatih/color v1.17.0
-	github.com/sirupsen/logrus v1.9.3
-)
+require github.com/fatih/color v1.17.0
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
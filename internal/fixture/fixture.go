@@ -0,0 +1,21 @@
+// Package fixture holds the test fixtures shared between py's packages, it is only
+// imported by tests.
+package fixture
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PythonPath returns a $PATH made up of the fake python directories under
+// interpreter/testdata/pythonpaths, 'root' is the path to the root of the module
+// from the calling test's package e.g. "..".
+func PythonPath(root string) string {
+	pythonPaths := filepath.Join(root, "interpreter", "testdata", "pythonpaths")
+	return strings.Join([]string{
+		filepath.Join(pythonPaths, "pythonpath1"),
+		filepath.Join(pythonPaths, "pythonpath2"),
+		filepath.Join(pythonPaths, "pythonpath3"),
+	}, string(os.PathListSeparator))
+}
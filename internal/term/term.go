@@ -0,0 +1,8 @@
+// Package term is a deliberately minimal terminal package, just enough to put a terminal
+// into raw mode for py --pick without pulling in a dependency.
+package term
+
+import "errors"
+
+// ErrNotSupported is returned by MakeRaw on platforms py doesn't know how to drive a terminal on.
+var ErrNotSupported = errors.New("terminal raw mode is not supported on this platform")
@@ -0,0 +1,8 @@
+package term
+
+import "syscall"
+
+const (
+	ioctlGetTermios = syscall.TIOCGETA // Get the termios of a terminal
+	ioctlSetTermios = syscall.TIOCSETA // Set the termios of a terminal
+)
@@ -0,0 +1,8 @@
+package term
+
+import "syscall"
+
+const (
+	ioctlGetTermios = syscall.TCGETS // Get the termios of a terminal
+	ioctlSetTermios = syscall.TCSETS // Set the termios of a terminal
+)
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package term
+
+// IsTerminal reports whether 'fd' is a terminal, which is never on this platform.
+func IsTerminal(fd uintptr) bool {
+	return false
+}
+
+// MakeRaw always returns ErrNotSupported on this platform.
+func MakeRaw(fd uintptr) (restore func() error, err error) {
+	return nil, ErrNotSupported
+}
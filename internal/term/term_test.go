@@ -0,0 +1,32 @@
+package term //nolint: testpackage // Need access to internals
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNotATerminal(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "term")
+	if err != nil {
+		t.Fatalf("could not create temp file: %v", err)
+	}
+	defer file.Close()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("could not create pipe: %v", err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+
+	for _, f := range []*os.File{file, reader} {
+		if IsTerminal(f.Fd()) {
+			t.Errorf("IsTerminal(%s) = true, wanted false", f.Name())
+		}
+
+		if restore, err := MakeRaw(f.Fd()); err == nil {
+			restore() //nolint: errcheck
+			t.Errorf("MakeRaw(%s) did not return an error", f.Name())
+		}
+	}
+}
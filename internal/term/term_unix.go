@@ -0,0 +1,66 @@
+//go:build linux || darwin
+
+package term
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// IsTerminal reports whether 'fd' is a terminal.
+func IsTerminal(fd uintptr) bool {
+	_, err := getState(fd)
+	return err == nil
+}
+
+// MakeRaw puts the terminal 'fd' into raw mode, where input is read a byte at a time
+// without being echoed or turned into signals (e.g. ctrl+c), and returns a function
+// that restores it to how it was.
+//
+// Output processing is left alone so "\n" still starts a new line.
+func MakeRaw(fd uintptr) (restore func() error, err error) {
+	old, err := getState(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := *old
+	raw.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	if err := setState(fd, &raw); err != nil {
+		return nil, err
+	}
+
+	return func() error { return setState(fd, old) }, nil
+}
+
+// getState returns the current termios settings of the terminal 'fd'.
+func getState(fd uintptr) (*syscall.Termios, error) {
+	var state syscall.Termios
+	if err := ioctl(fd, ioctlGetTermios, &state); err != nil {
+		return nil, fmt.Errorf("could not get terminal state: %w", err)
+	}
+	return &state, nil
+}
+
+// setState applies the termios settings 'state' to the terminal 'fd'.
+func setState(fd uintptr, state *syscall.Termios) error {
+	if err := ioctl(fd, ioctlSetTermios, state); err != nil {
+		return fmt.Errorf("could not set terminal state: %w", err)
+	}
+	return nil
+}
+
+// ioctl makes the termios ioctl 'request' on 'fd'.
+func ioctl(fd, request uintptr, state *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(state)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
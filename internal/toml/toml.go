@@ -0,0 +1,434 @@
+// Package toml is a deliberately minimal TOML parser, just enough to read PEP 723
+// script metadata and the bits of pyproject.toml py cares about without pulling in a dependency.
+package toml
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parser parses a single TOML document.
+//
+// It understands bare, quoted and dotted keys, basic and literal strings (including multi-line ones),
+// arrays (including multi-line ones with comments and trailing commas), tables and inline tables,
+// and treats any other scalar (integers, booleans etc.) as it's raw text. Arrays of tables ([[name]])
+// are skipped.
+type parser struct {
+	src string // The TOML document
+	pos int    // Current position in src
+}
+
+// Parse parses the TOML document 'src'. Values are either a string, a []any of values
+// or for tables, a nested map[string]any.
+func Parse(src string) (map[string]any, error) {
+	p := &parser{src: src}
+	root := make(map[string]any)
+	table := root
+
+	for {
+		p.skipWhitespace(true)
+		if p.eof() {
+			return root, nil
+		}
+
+		if p.peek() == '[' {
+			var err error
+			table, err = p.parseTableHeader(root)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		key, err := p.parseKeyValue(table)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only a comment may follow a value on the same line
+		if err := p.endOfLine(); err != nil {
+			return nil, fmt.Errorf("bad value for key %q: %w", key, err)
+		}
+	}
+}
+
+// parseKeyValue parses a key = value pair into 'table', returning the key. The value of a dotted
+// key (e.g. tool.ruff.line-length) goes in the nested tables it names.
+func (p *parser) parseKeyValue(table map[string]any) (string, error) {
+	path, err := p.parseKeyPath()
+	if err != nil {
+		return "", err
+	}
+	key := strings.Join(path, ".")
+
+	p.skipWhitespace(false)
+	if p.eof() || p.peek() != '=' {
+		return "", fmt.Errorf("expected '=' after key %q", key)
+	}
+	p.pos++
+	p.skipWhitespace(false)
+
+	value, err := p.parseValue()
+	if err != nil {
+		return "", fmt.Errorf("bad value for key %q: %w", key, err)
+	}
+
+	table, err = subTable(table, path[:len(path)-1])
+	if err != nil {
+		return "", err
+	}
+
+	name := path[len(path)-1]
+	if _, ok := table[name]; ok {
+		return "", fmt.Errorf("duplicate key %q", key)
+	}
+	table[name] = value
+
+	return key, nil
+}
+
+// subTable returns the table at 'path' in 'table', creating any that don't exist yet.
+func subTable(table map[string]any, path []string) (map[string]any, error) {
+	for _, name := range path {
+		next, ok := table[name]
+		if !ok {
+			next = make(map[string]any)
+			table[name] = next
+		}
+
+		nested, ok := next.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("key %q is not a table", name)
+		}
+		table = nested
+	}
+
+	return table, nil
+}
+
+// parseTableHeader parses a [table.name] header, returning the (possibly nested) table
+// in 'root' that the keys following it belong in, creating it if needed.
+func (p *parser) parseTableHeader(root map[string]any) (map[string]any, error) {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end == -1 {
+		end = len(p.src) - p.pos
+	}
+	line := p.src[p.pos : p.pos+end]
+
+	if strings.HasPrefix(line, "[[") {
+		// Array of tables, we never need these so collect the keys somewhere they'll be ignored
+		closing := strings.Index(line, "]]")
+		if closing == -1 {
+			return nil, fmt.Errorf("unterminated table header %q", line)
+		}
+		p.pos += closing + len("]]")
+		return make(map[string]any), p.endOfLine()
+	}
+
+	p.pos++ // The opening '['
+	path, err := p.parseKeyPath()
+	if err != nil {
+		return nil, fmt.Errorf("bad table header %q: %w", line, err)
+	}
+
+	p.skipWhitespace(false)
+	if p.eof() || p.peek() != ']' {
+		return nil, fmt.Errorf("unterminated table header %q", line)
+	}
+	p.pos++
+
+	table, err := subTable(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return table, p.endOfLine()
+}
+
+// endOfLine checks that only whitespace or a comment remains on the current line.
+func (p *parser) endOfLine() error {
+	p.skipWhitespace(false)
+	if !p.eof() && p.peek() != '\n' {
+		return fmt.Errorf("unexpected %q at end of line", p.peek())
+	}
+	return nil
+}
+
+// eof reports whether the parser has consumed all of src.
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+// peek returns the current byte without consuming it.
+func (p *parser) peek() byte {
+	return p.src[p.pos]
+}
+
+// skipWhitespace skips spaces, tabs and comments, and newlines too if 'newlines' is true.
+func (p *parser) skipWhitespace(newlines bool) {
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == ' ', c == '\t', c == '\r':
+			p.pos++
+		case c == '\n' && newlines:
+			p.pos++
+		case c == '#':
+			// Comment runs to the end of the line, but leave the newline alone
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end == -1 {
+				p.pos = len(p.src)
+				return
+			}
+			p.pos += end
+		default:
+			return
+		}
+	}
+}
+
+// parseKeyPath parses a key, which may be dotted e.g. tool."py launcher".default, into it's parts.
+func (p *parser) parseKeyPath() ([]string, error) {
+	var path []string
+	for {
+		p.skipWhitespace(false)
+		if p.eof() {
+			return nil, errors.New("expected a key")
+		}
+
+		part, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, part)
+
+		p.skipWhitespace(false)
+		if p.eof() || p.peek() != '.' {
+			return path, nil
+		}
+		p.pos++
+	}
+}
+
+// parseKey parses a single bare or quoted key.
+func (p *parser) parseKey() (string, error) {
+	if c := p.peek(); c == '"' || c == '\'' {
+		return p.parseString()
+	}
+
+	start := p.pos
+	for !p.eof() && isBareKeyChar(p.peek()) {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", fmt.Errorf("expected a key, got %q", p.peek())
+	}
+
+	return p.src[start:p.pos], nil
+}
+
+// parseValue parses a string, an array, an inline table or any other scalar as raw text.
+func (p *parser) parseValue() (any, error) {
+	if p.eof() {
+		return nil, errors.New("missing value")
+	}
+
+	switch p.peek() {
+	case '"', '\'':
+		return p.parseString()
+	case '[':
+		return p.parseArray()
+	case '{':
+		return p.parseInlineTable()
+	default:
+		start := p.pos
+		for !p.eof() && !strings.ContainsRune(",]}#\n", rune(p.peek())) {
+			p.pos++
+		}
+		raw := strings.TrimSpace(p.src[start:p.pos])
+		if raw == "" {
+			return nil, errors.New("missing value")
+		}
+		return raw, nil
+	}
+}
+
+// parseString parses a basic ("...") or literal ('...') string, or their triple quoted
+// multi-line equivalents.
+func (p *parser) parseString() (string, error) {
+	quote := p.peek()
+	if strings.HasPrefix(p.src[p.pos:], strings.Repeat(string(quote), 3)) { //nolint: mnd // Triple quote
+		return p.parseMultilineString(quote)
+	}
+
+	start := p.pos
+	p.pos++
+	for !p.eof() {
+		switch c := p.peek(); {
+		case c == '\n':
+			return "", errors.New("unterminated string")
+		case c == '\\' && quote == '"':
+			// Skip whatever is escaped so an escaped quote doesn't end the string
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			if quote == '\'' {
+				return p.src[start+1 : p.pos-1], nil
+			}
+			s, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return "", fmt.Errorf("bad string %s: %w", p.src[start:p.pos], err)
+			}
+			return s, nil
+		default:
+			p.pos++
+		}
+	}
+
+	return "", errors.New("unterminated string")
+}
+
+// parseMultilineString parses a multi-line basic or literal string, whichever 'quote' opens.
+func (p *parser) parseMultilineString(quote byte) (string, error) {
+	delim := strings.Repeat(string(quote), 3) //nolint: mnd // Triple quote
+	p.pos += len(delim)
+
+	// A newline straight after the opening quotes isn't part of the string
+	if strings.HasPrefix(p.src[p.pos:], "\r\n") {
+		p.pos += 2
+	} else if strings.HasPrefix(p.src[p.pos:], "\n") {
+		p.pos++
+	}
+
+	start := p.pos
+	for !p.eof() {
+		switch {
+		case p.peek() == '\\' && quote == '"':
+			p.pos += 2
+		case strings.HasPrefix(p.src[p.pos:], delim):
+			// Up to two more quotes may come before the closing ones and are part of the string
+			end := p.pos
+			for extra := 0; extra < 2 && strings.HasPrefix(p.src[end+1:], delim); extra++ {
+				end++
+			}
+			p.pos = end + len(delim)
+			if quote == '\'' {
+				return p.src[start:end], nil
+			}
+			return unescape(p.src[start:end])
+		default:
+			p.pos++
+		}
+	}
+
+	return "", errors.New("unterminated multi-line string")
+}
+
+// unescape processes the escapes in the body of a multi-line basic string, including
+// a backslash at the end of a line which removes the newline and any whitespace after it.
+func unescape(s string) (string, error) {
+	var b strings.Builder
+	for s != "" {
+		if s[0] != '\\' {
+			i := strings.IndexByte(s, '\\')
+			if i == -1 {
+				i = len(s)
+			}
+			b.WriteString(s[:i])
+			s = s[i:]
+			continue
+		}
+
+		if rest := strings.TrimLeft(s[1:], " \t\r"); strings.HasPrefix(rest, "\n") {
+			s = strings.TrimLeft(rest, " \t\r\n")
+			continue
+		}
+
+		value, _, tail, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return "", fmt.Errorf("bad escape in string: %w", err)
+		}
+		b.WriteRune(value)
+		s = tail
+	}
+
+	return b.String(), nil
+}
+
+// parseInlineTable parses an inline table e.g. {name = "me", email = "me@example.com"}.
+func (p *parser) parseInlineTable() (map[string]any, error) {
+	p.pos++ // The opening '{'
+	table := make(map[string]any)
+
+	for {
+		p.skipWhitespace(true)
+		if p.eof() {
+			return nil, errors.New("unterminated inline table")
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return table, nil
+		}
+
+		if _, err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+
+		p.skipWhitespace(true)
+		if p.eof() {
+			return nil, errors.New("unterminated inline table")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return table, nil
+		default:
+			return nil, fmt.Errorf("expected ',' or '}' in inline table, got %q", p.peek())
+		}
+	}
+}
+
+// parseArray parses an array of values, which may span multiple lines.
+func (p *parser) parseArray() ([]any, error) {
+	p.pos++ // The opening '['
+	values := []any{}
+
+	for {
+		p.skipWhitespace(true)
+		if p.eof() {
+			return nil, errors.New("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return values, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		p.skipWhitespace(true)
+		if p.eof() {
+			return nil, errors.New("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return values, nil
+		default:
+			return nil, fmt.Errorf("expected ',' or ']' in array, got %q", p.peek())
+		}
+	}
+}
+
+// isBareKeyChar reports whether 'c' may appear in a bare TOML key.
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
@@ -0,0 +1,315 @@
+package toml //nolint: testpackage // Need access to internals
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			src:     "",
+			want:    map[string]any{},
+			wantErr: false,
+		},
+		{
+			name:    "basic string",
+			src:     `requires-python = ">=3.11"`,
+			want:    map[string]any{"requires-python": ">=3.11"},
+			wantErr: false,
+		},
+		{
+			name:    "literal string",
+			src:     `requires-python = '>=3.11'`,
+			want:    map[string]any{"requires-python": ">=3.11"},
+			wantErr: false,
+		},
+		{
+			name:    "escapes",
+			src:     `name = "say \"hello\"\t"`,
+			want:    map[string]any{"name": "say \"hello\"\t"},
+			wantErr: false,
+		},
+		{
+			name:    "quoted key",
+			src:     `"requires-python" = ">=3.11"`,
+			want:    map[string]any{"requires-python": ">=3.11"},
+			wantErr: false,
+		},
+		{
+			name:    "inline array",
+			src:     `dependencies = ["requests<3", 'rich']`,
+			want:    map[string]any{"dependencies": []any{"requests<3", "rich"}},
+			wantErr: false,
+		},
+		{
+			name: "multi-line array with comments and trailing comma",
+			src: `dependencies = [
+  "requests<3",  # HTTP
+  # "old-thing",
+  "rich",
+]`,
+			want:    map[string]any{"dependencies": []any{"requests<3", "rich"}},
+			wantErr: false,
+		},
+		{
+			name:    "empty array",
+			src:     `dependencies = []`,
+			want:    map[string]any{"dependencies": []any{}},
+			wantErr: false,
+		},
+		{
+			name:    "nested array",
+			src:     `matrix = [["a", "b"], []]`,
+			want:    map[string]any{"matrix": []any{[]any{"a", "b"}, []any{}}},
+			wantErr: false,
+		},
+		{
+			name:    "other scalars are raw",
+			src:     "answer = 42\nenabled = true # Comment",
+			want:    map[string]any{"answer": "42", "enabled": "true"},
+			wantErr: false,
+		},
+		{
+			name:    "tables",
+			src:     "requires-python = \">=3.11\"\n\n[tool.uv]\nrequires-python = \"nope\"",
+			want:    map[string]any{"requires-python": ">=3.11", "tool": map[string]any{"uv": map[string]any{"requires-python": "nope"}}},
+			wantErr: false,
+		},
+		{
+			name: "pyproject",
+			src: `[build-system]
+requires = ["hatchling"]
+
+[project]  # The important bit
+name = "thing"
+requires-python = ">=3.10"
+
+[[tool.mypy.overrides]]
+module = "thing.*"
+
+[tool."py launcher"]
+default = "3.12"
+`,
+			want: map[string]any{
+				"build-system": map[string]any{"requires": []any{"hatchling"}},
+				"project":      map[string]any{"name": "thing", "requires-python": ">=3.10"},
+				"tool":         map[string]any{"py launcher": map[string]any{"default": "3.12"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "dotted keys",
+			src:  "[tool]\nruff.line-length = 120\n\"py launcher\" . default = \"3.12\"\nruff.lint = {select = [\"E\"], isort.known-first-party = [\"thing\"]}",
+			want: map[string]any{"tool": map[string]any{
+				"ruff":        map[string]any{"line-length": "120", "lint": map[string]any{"select": []any{"E"}, "isort": map[string]any{"known-first-party": []any{"thing"}}}},
+				"py launcher": map[string]any{"default": "3.12"},
+			}},
+			wantErr: false,
+		},
+		{
+			name:    "quoted table header with a dot",
+			src:     "[tool.\"py.launcher\"]\ndefault = \"3.12\"",
+			want:    map[string]any{"tool": map[string]any{"py.launcher": map[string]any{"default": "3.12"}}},
+			wantErr: false,
+		},
+		{
+			name:    "dotted key over a value",
+			src:     "a = \"b\"\na.c = \"d\"",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated table header",
+			src:     "[project\nname = \"thing\"",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "empty table name",
+			src:     "[project.]",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "table over a value",
+			src:     "project = \"thing\"\n[project]",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "junk after table header",
+			src:     "[project] name = \"thing\"",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "missing equals",
+			src:     `requires-python ">=3.11"`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "missing value",
+			src:     `requires-python =`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated string",
+			src:     `requires-python = ">=3.11`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated array",
+			src:     `dependencies = ["rich"`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "missing comma",
+			src:     `dependencies = ["rich" "requests"]`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "two values on a line",
+			src:     `a = "b" c = "d"`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "duplicate key",
+			src:     "a = \"b\"\na = \"c\"",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "inline table",
+			src:     `license = {text = "MIT", files = ["LICENSE"]}`,
+			want:    map[string]any{"license": map[string]any{"text": "MIT", "files": []any{"LICENSE"}}},
+			wantErr: false,
+		},
+		{
+			name:    "empty inline table",
+			src:     `a = {}`,
+			want:    map[string]any{"a": map[string]any{}},
+			wantErr: false,
+		},
+		{
+			name:    "array of inline tables",
+			src:     "authors = [\n  {name = \"A\", email = \"a@example.com\"},\n  { name = 'B' },\n]",
+			want:    map[string]any{"authors": []any{map[string]any{"name": "A", "email": "a@example.com"}, map[string]any{"name": "B"}}},
+			wantErr: false,
+		},
+		{
+			name:    "unterminated inline table",
+			src:     `a = {b = "c"`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "inline table missing comma",
+			src:     `a = {b = "c" d = "e"}`,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name:    "multi-line string",
+			src:     "a = \"\"\"\nline one\n\"line\" two\\t\"\"\"",
+			want:    map[string]any{"a": "line one\n\"line\" two\t"},
+			wantErr: false,
+		},
+		{
+			name:    "multi-line string line ending backslash",
+			src:     "a = \"\"\"one \\\n    two\"\"\"\"",
+			want:    map[string]any{"a": "one two\""},
+			wantErr: false,
+		},
+		{
+			name:    "multi-line literal string",
+			src:     "a = '''\nC:\\path\n\"\"\"'''",
+			want:    map[string]any{"a": "C:\\path\n\"\"\""},
+			wantErr: false,
+		},
+		{
+			name:    "unterminated multi-line string",
+			src:     "a = \"\"\"b\nc",
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "realistic pyproject",
+			src: `[build-system]
+requires = ["hatchling>=1.18"]
+build-backend = "hatchling.build"
+
+[project]
+name = "thing"
+version = "0.1.0"
+description = """
+A thing that does \
+stuff."""
+readme = "README.md"
+license = {text = "MIT"}
+authors = [{name = "A. Person", email = "a@example.com"}]
+requires-python = ">=3.11"
+classifiers = [
+    "Programming Language :: Python :: 3",  # Keep in sync
+]
+dependencies = ["rich>=13"]
+
+[project.optional-dependencies]
+dev = ["pytest", "ruff"]
+
+[project.urls]
+Homepage = "https://example.com"
+
+[tool.ruff.lint]
+select = ["E", "F"]
+per-file-ignores = {"tests/*" = ["S101"]}
+`,
+			want: map[string]any{
+				"build-system": map[string]any{"requires": []any{"hatchling>=1.18"}, "build-backend": "hatchling.build"},
+				"project": map[string]any{
+					"name":                  "thing",
+					"version":               "0.1.0",
+					"description":           "A thing that does stuff.",
+					"readme":                "README.md",
+					"license":               map[string]any{"text": "MIT"},
+					"authors":               []any{map[string]any{"name": "A. Person", "email": "a@example.com"}},
+					"requires-python":       ">=3.11",
+					"classifiers":           []any{"Programming Language :: Python :: 3"},
+					"dependencies":          []any{"rich>=13"},
+					"optional-dependencies": map[string]any{"dev": []any{"pytest", "ruff"}},
+					"urls":                  map[string]any{"Homepage": "https://example.com"},
+				},
+				"tool": map[string]any{"ruff": map[string]any{"lint": map[string]any{
+					"select":           []any{"E", "F"},
+					"per-file-ignores": map[string]any{"tests/*": []any{"S101"}},
+				}}},
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.src)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
+			}
+		})
+	}
+}
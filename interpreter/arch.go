@@ -0,0 +1,133 @@
+package interpreter
+
+import (
+	"debug/elf"
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	elfMachineOffset = 18 // Offset of e_machine in an ELF header, the same for 32 and 64 bit
+	headerSize       = 20 // Enough of the file to cover the ELF e_machine or Mach-O cputype
+	bits32           = 32
+	bits64           = 64
+)
+
+// elfMachines maps ELF machine types to the names python's platform.machine() would give them
+// any not in here fall back to the lowercased ELF name e.g. EM_S390 -> "s390".
+var elfMachines = map[elf.Machine]string{
+	elf.EM_X86_64:  "x86_64",
+	elf.EM_386:     "i686",
+	elf.EM_AARCH64: "aarch64",
+	elf.EM_ARM:     "arm",
+	elf.EM_RISCV:   "riscv",
+	elf.EM_PPC64:   "ppc64",
+}
+
+// machoCPUs maps Mach-O cpu types to the names python's platform.machine() would give them.
+var machoCPUs = map[macho.Cpu]string{
+	macho.CpuAmd64: "x86_64",
+	macho.Cpu386:   "i386",
+	macho.CpuArm64: "arm64",
+	macho.CpuArm:   "arm",
+}
+
+// ReadArch reads the executable header of the interpreter at i.Path (following symlinks)
+// to determine the architecture it was built for, loading it into the calling `Interpreter`.
+//
+// Both ELF (Linux) and Mach-O (macOS) executables are supported, a universal Mach-O binary
+// is reported as "universal" and 64 bit. Nothing is executed, so this is safe and cheap to
+// call on every interpreter found.
+func (i *Interpreter) ReadArch() error {
+	return i.readArch(OS)
+}
+
+// readArch is ReadArch but reads the interpreter from 'fsys'.
+func (i *Interpreter) readArch(fsys FS) error {
+	file, err := fsys.Open(i.Path)
+	if err != nil {
+		return fmt.Errorf("could not open interpreter %s: %w", i.Path, err)
+	}
+	defer file.Close()
+
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return fmt.Errorf("could not read executable header of %s: %w", i.Path, err)
+	}
+
+	arch, bits, err := parseExecutableHeader(header)
+	if err != nil {
+		return fmt.Errorf("could not determine architecture of %s: %w", i.Path, err)
+	}
+
+	i.Arch = arch
+	i.Bits = bits
+
+	return nil
+}
+
+// parseExecutableHeader determines the architecture name and bitness from the first
+// headerSize bytes of an executable.
+func parseExecutableHeader(header []byte) (string, int, error) {
+	if strings.HasPrefix(string(header), elf.ELFMAG) {
+		return parseELFHeader(header)
+	}
+
+	// Mach-O magic numbers are written in the byte order of the target, which
+	// for everything anyone runs now is little endian. Fat binaries are always big endian
+	if binary.BigEndian.Uint32(header) == macho.MagicFat {
+		return "universal", bits64, nil
+	}
+
+	var bits int
+	switch binary.LittleEndian.Uint32(header) {
+	case macho.Magic32:
+		bits = bits32
+	case macho.Magic64:
+		bits = bits64
+	default:
+		return "", 0, fmt.Errorf("not an ELF or Mach-O executable")
+	}
+
+	cpu := macho.Cpu(binary.LittleEndian.Uint32(header[4:]))
+	arch, ok := machoCPUs[cpu]
+	if !ok {
+		arch = strings.ToLower(strings.TrimPrefix(cpu.String(), "Cpu"))
+	}
+
+	return arch, bits, nil
+}
+
+// parseELFHeader determines the architecture name and bitness from an ELF header.
+func parseELFHeader(header []byte) (string, int, error) {
+	var bits int
+	switch elf.Class(header[elf.EI_CLASS]) {
+	case elf.ELFCLASS32:
+		bits = bits32
+	case elf.ELFCLASS64:
+		bits = bits64
+	default:
+		return "", 0, fmt.Errorf("unknown ELF class %d", header[elf.EI_CLASS])
+	}
+
+	var order binary.ByteOrder
+	switch elf.Data(header[elf.EI_DATA]) {
+	case elf.ELFDATA2LSB:
+		order = binary.LittleEndian
+	case elf.ELFDATA2MSB:
+		order = binary.BigEndian
+	default:
+		return "", 0, fmt.Errorf("unknown ELF data encoding %d", header[elf.EI_DATA])
+	}
+
+	machine := elf.Machine(order.Uint16(header[elfMachineOffset:]))
+	arch, ok := elfMachines[machine]
+	if !ok {
+		arch = strings.ToLower(strings.TrimPrefix(machine.String(), "EM_"))
+	}
+
+	return arch, bits, nil
+}
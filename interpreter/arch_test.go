@@ -0,0 +1,137 @@
+package interpreter //nolint: testpackage // Need access to internals
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func Test_parseExecutableHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   []byte
+		wantArch string
+		wantBits int
+		wantErr  bool
+	}{
+		{
+			name:     "ELF x86_64",
+			header:   []byte{0x7f, 'E', 'L', 'F', 2, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0x3e, 0},
+			wantArch: "x86_64",
+			wantBits: 64,
+		},
+		{
+			name:     "ELF i686",
+			header:   []byte{0x7f, 'E', 'L', 'F', 1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0x03, 0},
+			wantArch: "i686",
+			wantBits: 32,
+		},
+		{
+			name:     "ELF aarch64",
+			header:   []byte{0x7f, 'E', 'L', 'F', 2, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0xb7, 0},
+			wantArch: "aarch64",
+			wantBits: 64,
+		},
+		{
+			name:     "ELF big endian s390x",
+			header:   []byte{0x7f, 'E', 'L', 'F', 2, 2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0x16},
+			wantArch: "s390",
+			wantBits: 64,
+		},
+		{
+			name:    "ELF bad class",
+			header:  []byte{0x7f, 'E', 'L', 'F', 9, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0x3e, 0},
+			wantErr: true,
+		},
+		{
+			name:     "Mach-O arm64",
+			header:   []byte{0xcf, 0xfa, 0xed, 0xfe, 0x0c, 0, 0, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			wantArch: "arm64",
+			wantBits: 64,
+		},
+		{
+			name:     "Mach-O x86_64",
+			header:   []byte{0xcf, 0xfa, 0xed, 0xfe, 0x07, 0, 0, 0x01, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			wantArch: "x86_64",
+			wantBits: 64,
+		},
+		{
+			name:     "Mach-O universal",
+			header:   []byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			wantArch: "universal",
+			wantBits: 64,
+		},
+		{
+			name:    "shell script",
+			header:  []byte("#!/bin/sh\necho hello"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arch, bits, err := parseExecutableHeader(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExecutableHeader() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if arch != tt.wantArch {
+				t.Errorf("wrong arch: got %q, wanted %q", arch, tt.wantArch)
+			}
+
+			if bits != tt.wantBits {
+				t.Errorf("wrong bits: got %d, wanted %d", bits, tt.wantBits)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ReadArch(t *testing.T) {
+	// The test binary itself is a perfectly good executable to read
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatalf("could not get test executable: %v", err)
+	}
+
+	i := Interpreter{Path: exe}
+	if err := i.ReadArch(); err != nil {
+		t.Fatalf("ReadArch() returned an unexpected error: %v", err)
+	}
+
+	// Only check the platforms we know the names for, anything else just needs to not error
+	want := ""
+	switch {
+	case runtime.GOARCH == "amd64":
+		want = "x86_64"
+	case runtime.GOARCH == "arm64" && runtime.GOOS == "darwin":
+		want = "arm64"
+	case runtime.GOARCH == "arm64":
+		want = "aarch64"
+	}
+
+	if want != "" {
+		if i.Arch != want {
+			t.Errorf("wrong arch: got %q, wanted %q", i.Arch, want)
+		}
+		if i.Bits != 64 {
+			t.Errorf("wrong bits: got %d, wanted 64", i.Bits)
+		}
+	}
+}
+
+func TestInterpreter_ReadArchNotExecutable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "python3.12")
+	if err := os.WriteFile(path, []byte("not an executable at all"), 0o644); err != nil {
+		t.Fatalf("could not write file: %v", err)
+	}
+
+	i := Interpreter{Path: path}
+	if err := i.ReadArch(); err == nil {
+		t.Error("expected an error reading the architecture of a text file, got nil")
+	}
+
+	if i.Arch != "" || i.Bits != 0 {
+		t.Errorf("architecture should be left unknown, got %q, %d", i.Arch, i.Bits)
+	}
+}
@@ -0,0 +1,185 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// constraintOperators are the PEP 440 comparison operators we understand, longest first
+// so that e.g. "==" isn't mistaken for "=".
+var constraintOperators = [...]string{"===", "~=", "==", "!=", "<=", ">=", "<", ">"}
+
+// Constraint is a set of PEP 440 version clauses as found in a requires-python
+// field (e.g. ">=3.10,<3.13"), an interpreter must satisfy every clause to be allowed.
+type Constraint struct {
+	raw     string   // The original text of the constraint
+	clauses []clause // The individual clauses, all of which must be satisfied
+}
+
+// clause is a single operator and version e.g. ">=3.10" or "==3.11.*".
+type clause struct {
+	op       string // The comparison operator e.g. ">="
+	version  []int  // The 1 to 3 version components
+	wildcard bool   // Whether the version ended in ".*", only valid for == and !=
+}
+
+// ParseConstraint parses a PEP 440 version specifier set (e.g. ">=3.10,<3.13" or "~=3.11")
+// as used by the requires-python field of pyproject.toml and inline script metadata.
+//
+// Only release versions of up to 3 components are supported, as that's all a python interpreter has.
+func ParseConstraint(s string) (Constraint, error) {
+	if strings.TrimSpace(s) == "" {
+		return Constraint{}, errors.New("empty version constraint")
+	}
+
+	constraint := Constraint{raw: s}
+	for _, part := range strings.Split(s, ",") {
+		c, err := parseClause(strings.TrimSpace(part))
+		if err != nil {
+			return Constraint{}, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		constraint.clauses = append(constraint.clauses, c)
+	}
+
+	return constraint, nil
+}
+
+// String returns the constraint as it was written.
+func (c Constraint) String() string {
+	return c.raw
+}
+
+// NeedsPatch reports whether any clause of the constraint refers to a patch version,
+// in which case interpreters with an Unknown patch should be probed before checking.
+func (c Constraint) NeedsPatch() bool {
+	for _, cl := range c.clauses {
+		if len(cl.version) == xYZParts {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether 'i' satisfies every clause of the constraint. An Unknown
+// patch version is treated as 0.
+func (c Constraint) Allows(i Interpreter) bool {
+	patch := i.Patch
+	if patch == Unknown {
+		patch = 0
+	}
+	version := []int{i.Major, i.Minor, patch}
+
+	for _, cl := range c.clauses {
+		if !cl.allows(version) {
+			return false
+		}
+	}
+	return true
+}
+
+// allows reports whether the full X.Y.Z 'version' satisfies the clause.
+func (c clause) allows(version []int) bool {
+	switch c.op {
+	case "==", "===":
+		if c.wildcard {
+			return hasPrefix(version, c.version)
+		}
+		return compareVersions(version, c.version) == 0
+	case "!=":
+		if c.wildcard {
+			return !hasPrefix(version, c.version)
+		}
+		return compareVersions(version, c.version) != 0
+	case "~=":
+		// ~=3.10 means >=3.10,==3.*
+		return compareVersions(version, c.version) >= 0 && hasPrefix(version, c.version[:len(c.version)-1])
+	case "<=":
+		return compareVersions(version, c.version) <= 0
+	case ">=":
+		return compareVersions(version, c.version) >= 0
+	case "<":
+		return compareVersions(version, c.version) < 0
+	case ">":
+		return compareVersions(version, c.version) > 0
+	default:
+		return false
+	}
+}
+
+// parseClause parses a single clause e.g. ">=3.10".
+func parseClause(s string) (clause, error) {
+	var op string
+	for _, candidate := range constraintOperators {
+		if strings.HasPrefix(s, candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return clause{}, fmt.Errorf("missing operator in %q", s)
+	}
+
+	rest := strings.TrimSpace(strings.TrimPrefix(s, op))
+	wildcard := strings.HasSuffix(rest, ".*")
+	if wildcard {
+		if op != "==" && op != "!=" {
+			return clause{}, fmt.Errorf("wildcard not allowed with %s in %q", op, s)
+		}
+		rest = strings.TrimSuffix(rest, ".*")
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) > xYZParts {
+		return clause{}, fmt.Errorf("unsupported version %q", rest)
+	}
+	if op == "~=" && len(parts) < xYParts {
+		return clause{}, fmt.Errorf("~= needs at least a major and minor version in %q", s)
+	}
+
+	version := make([]int, 0, len(parts))
+	for _, part := range parts {
+		n, err := parseComponent(part)
+		if err != nil {
+			return clause{}, fmt.Errorf("unsupported version %q", rest)
+		}
+		version = append(version, n)
+	}
+
+	return clause{op: op, version: version, wildcard: wildcard}, nil
+}
+
+// compareVersions compares 'a' and 'b' component by component, padding the shorter
+// with zeros as PEP 440 does, returning -1, 0 or 1.
+func compareVersions(a, b []int) int {
+	for i := 0; i < max(len(a), len(b)); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// hasPrefix reports whether the leading components of 'version' are 'prefix'.
+func hasPrefix(version, prefix []int) bool {
+	if len(prefix) > len(version) {
+		return false
+	}
+	for i, n := range prefix {
+		if version[i] != n {
+			return false
+		}
+	}
+	return true
+}
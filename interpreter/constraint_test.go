@@ -0,0 +1,183 @@
+package interpreter //nolint: testpackage // Need access to internals
+
+import "testing"
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint string
+		wantErr    bool
+	}{
+		{name: "greater or equal", constraint: ">=3.10", wantErr: false},
+		{name: "range", constraint: ">=3.9, <3.13", wantErr: false},
+		{name: "wildcard", constraint: "==3.11.*", wantErr: false},
+		{name: "compatible", constraint: "~=3.10", wantErr: false},
+		{name: "patch", constraint: ">=3.12.4", wantErr: false},
+		{name: "arbitrary equality", constraint: "===3.12", wantErr: false},
+		{name: "empty", constraint: "", wantErr: true},
+		{name: "no operator", constraint: "3.10", wantErr: true},
+		{name: "bad version", constraint: ">=3.x", wantErr: true},
+		{name: "too many components", constraint: ">=3.12.4.1", wantErr: true},
+		{name: "pre-release", constraint: ">=3.13.0b1", wantErr: true},
+		{name: "wildcard with ordering", constraint: ">=3.*", wantErr: true},
+		{name: "compatible with only major", constraint: "~=3", wantErr: true},
+		{name: "trailing comma", constraint: ">=3.10,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseConstraint(tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConstraint() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if err == nil && got.String() != tt.constraint {
+				t.Errorf("String() = %q, wanted %q", got.String(), tt.constraint)
+			}
+		})
+	}
+}
+
+func TestConstraint_Allows(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraint  string
+		interpreter Interpreter
+		want        bool
+	}{
+		{
+			name:        "greater or equal satisfied",
+			constraint:  ">=3.10",
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: Unknown},
+			want:        true,
+		},
+		{
+			name:        "greater or equal exactly",
+			constraint:  ">=3.10",
+			interpreter: Interpreter{Major: 3, Minor: 10, Patch: Unknown},
+			want:        true,
+		},
+		{
+			name:        "greater or equal not satisfied",
+			constraint:  ">=3.10",
+			interpreter: Interpreter{Major: 3, Minor: 9, Patch: 18},
+			want:        false,
+		},
+		{
+			name:        "range satisfied",
+			constraint:  ">=3.9,<3.13",
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: 4},
+			want:        true,
+		},
+		{
+			name:        "range upper bound",
+			constraint:  ">=3.9,<3.13",
+			interpreter: Interpreter{Major: 3, Minor: 13, Patch: 0},
+			want:        false,
+		},
+		{
+			name:        "wildcard satisfied",
+			constraint:  "==3.11.*",
+			interpreter: Interpreter{Major: 3, Minor: 11, Patch: 9},
+			want:        true,
+		},
+		{
+			name:        "wildcard not satisfied",
+			constraint:  "==3.11.*",
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: 0},
+			want:        false,
+		},
+		{
+			name:        "exclusion",
+			constraint:  ">=3.9,!=3.11.*",
+			interpreter: Interpreter{Major: 3, Minor: 11, Patch: 2},
+			want:        false,
+		},
+		{
+			name:        "exact pads with zeros",
+			constraint:  "==3.12",
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: 0},
+			want:        true,
+		},
+		{
+			name:        "exact not a later patch",
+			constraint:  "==3.12",
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: 1},
+			want:        false,
+		},
+		{
+			name:        "compatible release satisfied",
+			constraint:  "~=3.10",
+			interpreter: Interpreter{Major: 3, Minor: 13, Patch: Unknown},
+			want:        true,
+		},
+		{
+			name:        "compatible release below",
+			constraint:  "~=3.10",
+			interpreter: Interpreter{Major: 3, Minor: 9, Patch: Unknown},
+			want:        false,
+		},
+		{
+			name:        "compatible patch release",
+			constraint:  "~=3.12.2",
+			interpreter: Interpreter{Major: 3, Minor: 13, Patch: 0},
+			want:        false,
+		},
+		{
+			name:        "patch bound with unknown patch",
+			constraint:  ">=3.12.4",
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: Unknown},
+			want:        false,
+		},
+		{
+			name:        "less or equal",
+			constraint:  "<=3.11",
+			interpreter: Interpreter{Major: 3, Minor: 11, Patch: 0},
+			want:        true,
+		},
+		{
+			name:        "greater than",
+			constraint:  ">3.11",
+			interpreter: Interpreter{Major: 3, Minor: 11, Patch: 1},
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraint, err := ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseConstraint() returned an unexpected error: %v", err)
+			}
+
+			if got := constraint.Allows(tt.interpreter); got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstraint_NeedsPatch(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       bool
+	}{
+		{constraint: ">=3.10", want: false},
+		{constraint: ">=3.10,<3.12.4", want: true},
+		{constraint: "==3.11.*", want: false},
+		{constraint: "~=3.12.2", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			constraint, err := ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("ParseConstraint() returned an unexpected error: %v", err)
+			}
+
+			if got := constraint.NeedsPatch(); got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
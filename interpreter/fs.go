@@ -0,0 +1,253 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinks is how many symlinks are followed resolving a single path before giving up,
+// the same limit Linux uses.
+const maxSymlinks = 40
+
+// errSymlinkLoop is returned when a path goes through more than maxSymlinks symlinks.
+var errSymlinkLoop = errors.New("too many levels of symbolic links")
+
+// FS is the filesystem python interpreters are discovered on.
+//
+// It is an fs.FS with the extras discovery needs, but unlike an fs.FS every method takes
+// an absolute OS path (e.g. /usr/local/bin/python3.12) so the paths on $PATH can be used as they are.
+// OS is the real filesystem, wrap an fs.FS (e.g. an fstest.MapFS) with FromFS to discover
+// interpreters somewhere else, like in tests.
+type FS interface {
+	fs.StatFS
+	fs.ReadDirFS
+
+	// Lstat is like Stat but does not follow a symlink at 'name'.
+	Lstat(name string) (fs.FileInfo, error)
+
+	// ReadLink returns the destination of the symlink at 'name'.
+	ReadLink(name string) (string, error)
+}
+
+// OS is the real filesystem.
+var OS FS = osFS{}
+
+// osFS implements FS by calling straight through to the os package.
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Lstat(name string) (fs.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (osFS) ReadLink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+// FromFS returns an FS backed by 'fsys', absolute paths are looked up relative to it's root
+// e.g. /usr/bin/python3.12 is "usr/bin/python3.12" in 'fsys'.
+//
+// Symlinks are supported if 'fsys' has Lstat and ReadLink methods, or otherwise by a file
+// whose mode has fs.ModeSymlink set and whose contents are the destination. Either way
+// this is how an fstest.MapFS spells a symlink:
+//
+//	fstest.MapFS{
+//		"usr/bin/python3.12": {Data: []byte("/opt/python/bin/python3.12"), Mode: fs.ModeSymlink},
+//	}
+func FromFS(fsys fs.FS) FS {
+	return rootedFS{fsys: fsys}
+}
+
+// rootedFS adapts an fs.FS to an FS, see FromFS.
+type rootedFS struct {
+	fsys fs.FS
+}
+
+func (r rootedFS) Open(name string) (fs.File, error) {
+	resolved, err := r.resolve(name, "open")
+	if err != nil {
+		return nil, err
+	}
+	return r.fsys.Open(resolved)
+}
+
+func (r rootedFS) Stat(name string) (fs.FileInfo, error) {
+	resolved, err := r.resolve(name, "stat")
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(r.fsys, resolved)
+}
+
+func (r rootedFS) Lstat(name string) (fs.FileInfo, error) {
+	resolved, err := r.resolveDir(name, "lstat")
+	if err != nil {
+		return nil, err
+	}
+	return r.lstat(resolved)
+}
+
+func (r rootedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	resolved, err := r.resolve(name, "readdir")
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(r.fsys, resolved)
+}
+
+func (r rootedFS) ReadLink(name string) (string, error) {
+	resolved, err := r.resolveDir(name, "readlink")
+	if err != nil {
+		return "", err
+	}
+	return r.readLink(resolved)
+}
+
+// lstat stats the fs.FS path 'name' without following a symlink.
+func (r rootedFS) lstat(name string) (fs.FileInfo, error) {
+	if fsys, ok := r.fsys.(interface {
+		Lstat(name string) (fs.FileInfo, error)
+	}); ok {
+		return fsys.Lstat(name)
+	}
+
+	// Without an Lstat, an fs.FS that knows about symlinks at all (like fstest.MapFS before go 1.25)
+	// reports them as they are rather than following them
+	return fs.Stat(r.fsys, name)
+}
+
+// readLink returns the destination of the symlink at the fs.FS path 'name'.
+func (r rootedFS) readLink(name string) (string, error) {
+	if fsys, ok := r.fsys.(interface {
+		ReadLink(name string) (string, error)
+	}); ok {
+		return fsys.ReadLink(name)
+	}
+
+	info, err := r.lstat(name)
+	if err != nil {
+		return "", err
+	}
+
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+
+	target, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return "", err
+	}
+
+	return string(target), nil
+}
+
+// resolveDir is like resolve but only follows symlinks in the directory holding 'name',
+// not 'name' itself.
+func (r rootedFS) resolveDir(name, op string) (string, error) {
+	name = filepath.Clean(name)
+	dir := filepath.Dir(name)
+	resolved, err := r.resolve(dir, op)
+	if err != nil {
+		return "", err
+	}
+	if dir == name {
+		// The root
+		return resolved, nil
+	}
+	return path.Join(resolved, filepath.Base(name)), nil
+}
+
+// resolve converts the absolute path 'name' to a path in the fs.FS, following any symlinks
+// on the way, 'op' is used in the error if it can't.
+func (r rootedFS) resolve(name, op string) (string, error) {
+	parts := strings.Split(toFSPath(name), "/")
+	resolved := "."
+	for links := 0; len(parts) != 0; {
+		next := path.Join(resolved, parts[0])
+		parts = parts[1:]
+
+		info, err := r.lstat(next)
+		if errors.Is(err, fs.ErrNotExist) && len(parts) == 0 {
+			// Let the caller report the missing file as it sees fit
+			return next, nil
+		}
+		if err != nil {
+			return "", &fs.PathError{Op: op, Path: name, Err: err}
+		}
+
+		if info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", &fs.PathError{Op: op, Path: name, Err: errSymlinkLoop}
+		}
+
+		target, err := r.readLink(next)
+		if err != nil {
+			return "", &fs.PathError{Op: op, Path: name, Err: err}
+		}
+
+		// An absolute destination starts again from the root, a relative one is
+		// relative to the directory holding the link
+		if filepath.IsAbs(target) {
+			resolved = "."
+		}
+		parts = append(strings.Split(toFSPath(target), "/"), parts...)
+	}
+
+	return resolved, nil
+}
+
+// toFSPath converts an absolute OS path to the equivalent fs.FS path.
+func toFSPath(name string) string {
+	name = strings.TrimPrefix(name, filepath.VolumeName(name))
+	name = path.Clean(filepath.ToSlash(name))
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// checkExecutable returns an error saying why the interpreter at 'path' on 'fsys'
+// can't be run, or nil if it's an executable regular file (following symlinks).
+func checkExecutable(fsys FS, path string) error {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		if link, lerr := fsys.Lstat(path); lerr == nil && link.Mode()&fs.ModeSymlink != 0 {
+			target, _ := fsys.ReadLink(path) //nolint: errcheck // Only used in the message
+			return fmt.Errorf("dangling symlink to %s", target)
+		}
+		return fmt.Errorf("could not stat: %w", err)
+	}
+
+	switch {
+	case info.IsDir():
+		return errors.New("is a directory")
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("not a regular file (%s)", info.Mode().Type())
+	case info.Mode().Perm()&0o111 == 0: //nolint: mnd // Any execute bit
+		return fmt.Errorf("not executable (%s)", info.Mode().Perm())
+	}
+
+	return nil
+}
@@ -0,0 +1,289 @@
+package interpreter //nolint: testpackage // Need access to internals
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testFS is a filesystem with a few interpreters, some of them symlinks.
+var testFS = fstest.MapFS{
+	"usr/bin/python3.12":                  {Data: []byte("python"), Mode: 0o755},
+	"usr/bin/python3":                     {Data: []byte("python3.12"), Mode: fs.ModeSymlink},
+	"usr/bin/python2.7":                   {Data: []byte("python"), Mode: 0o755},
+	"usr/local/bin/pypy3.10":              {Data: []byte("/opt/pypy/bin/pypy3.10"), Mode: fs.ModeSymlink},
+	"opt/pypy/bin/pypy3.10":               {Data: []byte("pypy"), Mode: 0o755},
+	"opt/python/3.11.4/bin/python3.11":    {Data: []byte("python"), Mode: 0o755},
+	"home/me/.local/bin":                  {Data: []byte("/opt/python/3.11.4/bin"), Mode: fs.ModeSymlink},
+	"home/me/.local/share/loop/python3.9": {Data: []byte("python3.9"), Mode: fs.ModeSymlink},
+	"home/me/.local/share/dangling":       {Data: []byte("/nowhere"), Mode: fs.ModeSymlink},
+}
+
+func TestFromFS(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		wantData   string      // Contents we want Open to read
+		wantLink   string      // Destination we want ReadLink to return
+		wantMode   fs.FileMode // The type we want Lstat to report
+		wantErr    error       // Error we want from Stat
+		wantDirLen int         // Number of entries we want ReadDir to return if it's a directory
+	}{
+		{
+			name:     "regular file",
+			path:     "/usr/bin/python3.12",
+			wantData: "python",
+		},
+		{
+			name:     "relative symlink",
+			path:     "/usr/bin/python3",
+			wantData: "python",
+			wantLink: "python3.12",
+			wantMode: fs.ModeSymlink,
+		},
+		{
+			name:     "absolute symlink",
+			path:     "/usr/local/bin/pypy3.10",
+			wantData: "pypy",
+			wantLink: "/opt/pypy/bin/pypy3.10",
+			wantMode: fs.ModeSymlink,
+		},
+		{
+			name:     "through a symlinked directory",
+			path:     "/home/me/.local/bin/python3.11",
+			wantData: "python",
+		},
+		{
+			name:       "symlinked directory",
+			path:       "/home/me/.local/bin",
+			wantLink:   "/opt/python/3.11.4/bin",
+			wantMode:   fs.ModeSymlink,
+			wantDirLen: 1,
+		},
+		{
+			name:       "directory",
+			path:       "/usr/bin/",
+			wantMode:   fs.ModeDir,
+			wantDirLen: 3,
+		},
+		{
+			name:    "missing",
+			path:    "/usr/bin/python3.13",
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name:    "missing directory",
+			path:    "/usr/share/bin/python3.13",
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name:     "dangling symlink",
+			path:     "/home/me/.local/share/dangling",
+			wantLink: "/nowhere",
+			wantMode: fs.ModeSymlink,
+			wantErr:  fs.ErrNotExist,
+		},
+		{
+			name:     "symlink loop",
+			path:     "/home/me/.local/share/loop/python3.9",
+			wantLink: "python3.9",
+			wantMode: fs.ModeSymlink,
+			wantErr:  errSymlinkLoop,
+		},
+	}
+
+	fsys := FromFS(testFS)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := fsys.Stat(tt.path)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Stat(%s) error = %v, wanted %v", tt.path, err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Stat(%s) returned an unexpected error: %v", tt.path, err)
+			} else if info.Mode()&fs.ModeSymlink != 0 {
+				t.Errorf("Stat(%s) did not follow the symlink", tt.path)
+			}
+
+			if tt.wantData != "" {
+				data, err := fs.ReadFile(fsys, tt.path)
+				if err != nil {
+					t.Fatalf("ReadFile(%s) returned an unexpected error: %v", tt.path, err)
+				}
+				if string(data) != tt.wantData {
+					t.Errorf("ReadFile(%s) = %q, wanted %q", tt.path, data, tt.wantData)
+				}
+			}
+
+			if tt.wantErr == nil || tt.wantLink != "" {
+				info, err := fsys.Lstat(tt.path)
+				if err != nil {
+					t.Fatalf("Lstat(%s) returned an unexpected error: %v", tt.path, err)
+				}
+				if info.Mode().Type() != tt.wantMode {
+					t.Errorf("Lstat(%s) type = %v, wanted %v", tt.path, info.Mode().Type(), tt.wantMode)
+				}
+			}
+
+			link, err := fsys.ReadLink(tt.path)
+			if (err != nil) != (tt.wantLink == "") {
+				t.Errorf("ReadLink(%s) error = %v, wanted a link: %v", tt.path, err, tt.wantLink != "")
+			}
+			if link != tt.wantLink {
+				t.Errorf("ReadLink(%s) = %q, wanted %q", tt.path, link, tt.wantLink)
+			}
+
+			if tt.wantDirLen != 0 {
+				entries, err := fsys.ReadDir(tt.path)
+				if err != nil {
+					t.Fatalf("ReadDir(%s) returned an unexpected error: %v", tt.path, err)
+				}
+				if len(entries) != tt.wantDirLen {
+					t.Errorf("ReadDir(%s) returned %d entries, wanted %d", tt.path, len(entries), tt.wantDirLen)
+				}
+			}
+		})
+	}
+}
+
+func Test_checkExecutable(t *testing.T) {
+	fsys := FromFS(fstest.MapFS{
+		"bin/python3.12":         {Mode: 0o755},
+		"bin/python3.11":         {Mode: 0o644},
+		"bin/python3.10":         {Mode: fs.ModeDir | 0o755},
+		"bin/python3.9":          {Data: []byte("python3.12"), Mode: fs.ModeSymlink},
+		"bin/python3.8":          {Data: []byte("/gone/python3.8"), Mode: fs.ModeSymlink},
+		"bin/python3.7":          {Data: []byte("python3.10"), Mode: fs.ModeSymlink},
+		"bin/python3.6":          {Data: []byte("python3.11"), Mode: fs.ModeSymlink},
+		"bin/python3.5":          {Mode: fs.ModeNamedPipe | 0o755},
+		"bin/python3.4":          {Mode: 0o700},
+		"other/python3.12/empty": {},
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string // Substring of the reason we want, empty if it should be executable
+	}{
+		{
+			name: "executable",
+			path: "/bin/python3.12",
+		},
+		{
+			name: "only the owner can execute",
+			path: "/bin/python3.4",
+		},
+		{
+			name: "symlink to an executable",
+			path: "/bin/python3.9",
+		},
+		{
+			name:    "not executable",
+			path:    "/bin/python3.11",
+			wantErr: "not executable",
+		},
+		{
+			name:    "directory",
+			path:    "/bin/python3.10",
+			wantErr: "is a directory",
+		},
+		{
+			name:    "implied directory",
+			path:    "/other/python3.12",
+			wantErr: "is a directory",
+		},
+		{
+			name:    "dangling symlink",
+			path:    "/bin/python3.8",
+			wantErr: "dangling symlink to /gone/python3.8",
+		},
+		{
+			name:    "symlink to a directory",
+			path:    "/bin/python3.7",
+			wantErr: "is a directory",
+		},
+		{
+			name:    "symlink to a non executable",
+			path:    "/bin/python3.6",
+			wantErr: "not executable",
+		},
+		{
+			name:    "not a regular file",
+			path:    "/bin/python3.5",
+			wantErr: "not a regular file",
+		},
+		{
+			name:    "missing",
+			path:    "/bin/python3.3",
+			wantErr: "could not stat",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkExecutable(fsys, tt.path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkExecutable() returned an unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkExecutable() error = %v, wanted it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetAllFS(t *testing.T) {
+	got, err := GetAllFS(FromFS(testFS), nil, []string{"/usr/bin", "/usr/local/bin", "/home/me/.local/bin"})
+	if err != nil {
+		t.Fatalf("GetAllFS() returned an unexpected error: %v", err)
+	}
+
+	want := []Interpreter{
+		{Path: "/usr/bin/python3.12", Implementation: CPython, Major: 3, Minor: 12, Patch: Unknown, Source: "/usr/bin", Rank: 0},
+		{Path: "/usr/local/bin/pypy3.10", Implementation: PyPy, Major: 3, Minor: 10, Patch: Unknown, Source: "/usr/local/bin", Rank: 1},
+		{Path: "/home/me/.local/bin/python3.11", Implementation: CPython, Major: 3, Minor: 11, Patch: Unknown, Source: "/home/me/.local/bin", Rank: 2},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+
+	if _, err := GetAllFS(FromFS(testFS), nil, []string{"/not/there"}); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("GetAllFS() with a missing directory error = %v, wanted fs.ErrNotExist", err)
+	}
+}
+
+func TestGetAllFS_skipped(t *testing.T) {
+	fsys := FromFS(fstest.MapFS{
+		"bin/python3.12": {Mode: 0o755},
+		"bin/python3.11": {Mode: 0o644},
+	})
+
+	logs := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	got, err := GetAllFS(fsys, logger, []string{"/bin"})
+	if err != nil {
+		t.Fatalf("GetAllFS() returned an unexpected error: %v", err)
+	}
+
+	if len(got) != 1 || got[0].Path != "/bin/python3.12" {
+		t.Errorf("expected only /bin/python3.12, got %v", got)
+	}
+
+	want := `msg="Skipping python interpreter" interpreter=/bin/python3.11 reason="not executable (-rw-r--r--)"`
+	if !strings.Contains(logs.String(), want) {
+		t.Errorf("expected the skip to be logged with it's reason, got:\n%s", logs.String())
+	}
+}
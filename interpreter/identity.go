@@ -0,0 +1,134 @@
+package interpreter
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// fileID identifies the file an interpreter path ends up at, two paths with the same
+// fileID are the same executable e.g. /usr/bin/python3.11 and a symlink to it in /usr/local/bin.
+type fileID struct {
+	dev  uint64 // Device the file lives on, if the filesystem has devices and inodes
+	ino  uint64 // Inode of the file, if the filesystem has devices and inodes
+	path string // The path with every symlink resolved, if it doesn't
+}
+
+// identify returns the fileID of 'path' on 'fsys', or false if it can't be determined
+// in which case it should be assumed to be unique.
+func identify(fsys FS, path string) (fileID, bool) {
+	info, err := fsys.Stat(path)
+	if err != nil {
+		return fileID{}, false
+	}
+
+	if dev, ino, ok := devIno(info); ok {
+		return fileID{dev: dev, ino: ino}, true
+	}
+
+	// Not a real filesystem (e.g. an fstest.MapFS), the best we can do is where the symlinks go
+	resolved, err := realPath(fsys, path, 0)
+	if err != nil {
+		return fileID{}, false
+	}
+
+	return fileID{path: resolved}, true
+}
+
+// realPath returns 'name' with every symlink in it resolved, like filepath.EvalSymlinks
+// but on 'fsys'. 'depth' is the number of symlinks followed so far.
+func realPath(fsys FS, name string, depth int) (string, error) {
+	name = filepath.Clean(name)
+	dir := filepath.Dir(name)
+	if dir == name {
+		// The root
+		return name, nil
+	}
+
+	realDir, err := realPath(fsys, dir, depth)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(realDir, filepath.Base(name))
+	info, err := fsys.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return path, nil
+	}
+
+	if depth >= maxSymlinks {
+		return "", &fs.PathError{Op: "realpath", Path: name, Err: errSymlinkLoop}
+	}
+
+	target, err := fsys.ReadLink(path)
+	if err != nil {
+		return "", err
+	}
+
+	// A relative destination is relative to the directory holding the link
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(realDir, target)
+	}
+
+	return realPath(fsys, target, depth+1)
+}
+
+// dupeKey is what two interpreters must share to be merged by deDupe, the same executable
+// and the same version according to their names.
+type dupeKey struct {
+	id             fileID // The executable
+	implementation string // Implementation from the name e.g. "pypy"
+	abiFlags       string // ABI flags from the name e.g. "t"
+	major          int    // Major version from the name
+	minor          int    // Minor version from the name
+}
+
+// deDupe merges the interpreters in 'interpreters' that are the same executable under names
+// for the same version, keeping the first of each (so the one first on $PATH) with the paths
+// of the others as it's Aliases.
+//
+// Names for different versions are never merged, even if they are the same executable, as
+// they're still how someone would ask for that version e.g. a python3.1 symlink to python3.13
+// mustn't hide python3.13.
+//
+// If the one kept doesn't know it's patch version but an alias does (e.g. a symlink into
+// a versioned install directory), it's taken from the alias.
+func deDupe(fsys FS, interpreters []Interpreter) []Interpreter {
+	seen := make(map[dupeKey]int, len(interpreters)) // dupeKey to index in deDuped
+	var deDuped []Interpreter
+
+	for _, python := range interpreters {
+		id, ok := identify(fsys, python.Path)
+		if !ok {
+			deDuped = append(deDuped, python)
+			continue
+		}
+
+		key := dupeKey{
+			id:             id,
+			implementation: python.Implementation,
+			abiFlags:       python.ABIFlags,
+			major:          python.Major,
+			minor:          python.Minor,
+		}
+
+		i, ok := seen[key]
+		if !ok {
+			seen[key] = len(deDuped)
+			deDuped = append(deDuped, python)
+			continue
+		}
+
+		first := &deDuped[i]
+		first.Aliases = append(first.Aliases, python.Path)
+		if first.Patch == Unknown && python.Patch != Unknown {
+			first.Patch = python.Patch
+			first.PreRelease = python.PreRelease
+		}
+	}
+
+	return deDuped
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package interpreter
+
+import "io/fs"
+
+// devIno always returns false, there are no devices and inodes to compare outside unix
+// so interpreters are identified by where their symlinks go instead.
+func devIno(_ fs.FileInfo) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,251 @@
+package interpreter //nolint: testpackage // Need access to internals
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func Test_realPath(t *testing.T) {
+	fsys := FromFS(fstest.MapFS{
+		"usr/bin/python3.12":                         {Data: []byte("python")},
+		"usr/local/bin/python3.12":                   {Data: []byte("/usr/bin/python3.12"), Mode: fs.ModeSymlink},
+		"usr/local/bin/python3.13":                   {Data: []byte("../Cellar/python@3.13/3.13.1/bin/python3.13"), Mode: fs.ModeSymlink},
+		"usr/local/Cellar/python@3.13/3.13.1/bin":    {Mode: fs.ModeDir},
+		"usr/local/Cellar/python@3.13/3.13.1/bin/py": {Data: []byte("python")},
+		"usr/local/opt/python/bin":                   {Data: []byte("../../Cellar/python@3.13/3.13.1/bin"), Mode: fs.ModeSymlink},
+		"loop/a":                                     {Data: []byte("b"), Mode: fs.ModeSymlink},
+		"loop/b":                                     {Data: []byte("a"), Mode: fs.ModeSymlink},
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "not a symlink",
+			path: "/usr/bin/python3.12",
+			want: "/usr/bin/python3.12",
+		},
+		{
+			name: "absolute symlink",
+			path: "/usr/local/bin/python3.12",
+			want: "/usr/bin/python3.12",
+		},
+		{
+			name:    "relative symlink to nowhere",
+			path:    "/usr/local/bin/python3.13",
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name: "symlinked directory",
+			path: "/usr/local/opt/python/bin/py",
+			want: "/usr/local/Cellar/python@3.13/3.13.1/bin/py",
+		},
+		{
+			name:    "loop",
+			path:    "/loop/a",
+			wantErr: errSymlinkLoop,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := realPath(fsys, tt.path, 0)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("realPath() error = %v, wanted %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("realPath() returned an unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %s, wanted %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_deDupe(t *testing.T) {
+	fsys := FromFS(fstest.MapFS{
+		"usr/bin/python3.11":                                 {Data: []byte("python")},
+		"usr/local/bin/python3.11":                           {Data: []byte("/usr/bin/python3.11"), Mode: fs.ModeSymlink},
+		"usr/local/bin/python3.12":                           {Data: []byte("../Cellar/python@3.12/3.12.4/bin/python3.12"), Mode: fs.ModeSymlink},
+		"usr/local/Cellar/python@3.12/3.12.4/bin/python3.12": {Data: []byte("python")},
+		"opt/bin/python3.11":                                 {Data: []byte("another python")},
+		"root/miniconda/bin/python3.13":                      {Data: []byte("python")},
+		"root/miniconda/bin/python3.1":                       {Data: []byte("python3.13"), Mode: fs.ModeSymlink},
+		"root/miniconda/bin/python3.13t":                     {Data: []byte("python3.13"), Mode: fs.ModeSymlink},
+	})
+
+	tests := []struct {
+		name         string
+		interpreters []Interpreter
+		want         []Interpreter
+	}{
+		{
+			name: "nothing",
+		},
+		{
+			name: "no duplicates",
+			interpreters: []Interpreter{
+				{Path: "/usr/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+				{Path: "/opt/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+			},
+			want: []Interpreter{
+				{Path: "/usr/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+				{Path: "/opt/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+			},
+		},
+		{
+			name: "symlink later on the path",
+			interpreters: []Interpreter{
+				{Path: "/usr/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+				{Path: "/usr/local/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+			},
+			want: []Interpreter{
+				{Path: "/usr/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown, Aliases: []string{"/usr/local/bin/python3.11"}},
+			},
+		},
+		{
+			name: "symlink first on the path",
+			interpreters: []Interpreter{
+				{Path: "/usr/local/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+				{Path: "/opt/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+				{Path: "/usr/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+			},
+			want: []Interpreter{
+				{Path: "/usr/local/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown, Aliases: []string{"/usr/bin/python3.11"}},
+				{Path: "/opt/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown},
+			},
+		},
+		{
+			name: "patch taken from an alias",
+			interpreters: []Interpreter{
+				{Path: "/usr/local/bin/python3.12", Major: 3, Minor: 12, Patch: Unknown},
+				{Path: "/usr/local/Cellar/python@3.12/3.12.4/bin/python3.12", Major: 3, Minor: 12, Patch: 4},
+			},
+			want: []Interpreter{
+				{Path: "/usr/local/bin/python3.12", Major: 3, Minor: 12, Patch: 4, Aliases: []string{"/usr/local/Cellar/python@3.12/3.12.4/bin/python3.12"}},
+			},
+		},
+		{
+			name: "same file different version",
+			interpreters: []Interpreter{
+				{Path: "/root/miniconda/bin/python3.1", Implementation: CPython, Major: 3, Minor: 1, Patch: Unknown},
+				{Path: "/root/miniconda/bin/python3.13", Implementation: CPython, Major: 3, Minor: 13, Patch: Unknown},
+				{Path: "/root/miniconda/bin/python3.13t", Implementation: CPython, Major: 3, Minor: 13, Patch: Unknown, ABIFlags: "t"},
+			},
+			want: []Interpreter{
+				{Path: "/root/miniconda/bin/python3.1", Implementation: CPython, Major: 3, Minor: 1, Patch: Unknown},
+				{Path: "/root/miniconda/bin/python3.13", Implementation: CPython, Major: 3, Minor: 13, Patch: Unknown},
+				{Path: "/root/miniconda/bin/python3.13t", Implementation: CPython, Major: 3, Minor: 13, Patch: Unknown, ABIFlags: "t"},
+			},
+		},
+		{
+			name: "missing files are kept",
+			interpreters: []Interpreter{
+				{Path: "/gone/python3.10", Major: 3, Minor: 10, Patch: Unknown},
+				{Path: "/gone/python3.10", Major: 3, Minor: 10, Patch: Unknown},
+			},
+			want: []Interpreter{
+				{Path: "/gone/python3.10", Major: 3, Minor: 10, Patch: Unknown},
+				{Path: "/gone/python3.10", Major: 3, Minor: 10, Patch: Unknown},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deDupe(fsys, tt.interpreters); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAll_sameFile(t *testing.T) {
+	root := t.TempDir()
+	bin := filepath.Join(root, "bin")
+	local := filepath.Join(root, "local", "bin")
+	other := filepath.Join(root, "other", "bin")
+	for _, dir := range []string{bin, local, other} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("could not create %s: %v", dir, err)
+		}
+	}
+
+	python := filepath.Join(bin, "python3.11")
+	if err := os.WriteFile(python, nil, 0o755); err != nil {
+		t.Fatalf("could not write python: %v", err)
+	}
+
+	// A symlink and a hard link are both the same file, by device and inode
+	if err := os.Symlink(python, filepath.Join(local, "python3.11")); err != nil {
+		t.Fatalf("could not symlink: %v", err)
+	}
+	if err := os.Link(python, filepath.Join(other, "python3.11")); err != nil {
+		t.Fatalf("could not hard link: %v", err)
+	}
+
+	got, err := GetAll([]string{local, bin, other})
+	if err != nil {
+		t.Fatalf("GetAll() returned an unexpected error: %v", err)
+	}
+
+	want := []Interpreter{
+		{
+			Path:           filepath.Join(local, "python3.11"),
+			Implementation: CPython,
+			Major:          3,
+			Minor:          11,
+			Patch:          Unknown,
+			Source:         local,
+			Aliases:        []string{python, filepath.Join(other, "python3.11")},
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
+
+func TestGetAll_sameFileOtherVersion(t *testing.T) {
+	// Like miniconda, where python3.1 is a symlink to python3.13
+	bin := filepath.Join(t.TempDir(), "bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		t.Fatalf("could not create %s: %v", bin, err)
+	}
+
+	python := filepath.Join(bin, "python3.13")
+	if err := os.WriteFile(python, nil, 0o755); err != nil {
+		t.Fatalf("could not write python: %v", err)
+	}
+	if err := os.Symlink("python3.13", filepath.Join(bin, "python3.1")); err != nil {
+		t.Fatalf("could not symlink: %v", err)
+	}
+
+	got, err := GetAll([]string{bin})
+	if err != nil {
+		t.Fatalf("GetAll() returned an unexpected error: %v", err)
+	}
+
+	want := []Interpreter{
+		{Path: filepath.Join(bin, "python3.1"), Implementation: CPython, Major: 3, Minor: 1, Patch: Unknown, Source: bin},
+		{Path: python, Implementation: CPython, Major: 3, Minor: 13, Patch: Unknown, Source: bin},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, wanted %#v", got, want)
+	}
+}
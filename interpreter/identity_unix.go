@@ -0,0 +1,18 @@
+//go:build unix
+
+package interpreter
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// devIno returns the device and inode of the file described by 'info', or false
+// if it didn't come from the real filesystem.
+func devIno(info fs.FileInfo) (dev, ino uint64, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(stat.Dev), stat.Ino, true //nolint: unconvert // Dev is an int32 on darwin
+}
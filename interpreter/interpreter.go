@@ -4,51 +4,125 @@ package interpreter
 
 import (
 	"fmt"
-	"os"
+	"io"
+	"log/slog"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 )
 
+// The python implementations py knows how to discover.
 const (
-	pythonExePrefix = "python"
-	xYParts         = 2 // Number of parts in an X.Y version
+	CPython = "cpython" // The reference implementation, executables named like python3.12
+	PyPy    = "pypy"    // PyPy, executables named like pypy3.10
+	GraalPy = "graalpy" // GraalPy, executables named like graalpy3.11
+	Pyston  = "pyston"  // Pyston, executables named like pyston3.8
 )
 
+// Unknown marks a version component of an Interpreter that could not be determined
+// e.g. the patch version of /usr/bin/python3.12.
+const Unknown = -1
+
+const (
+	xYParts         = 2     // Number of parts in an X.Y version
+	xYZParts        = 3     // Number of parts in an X.Y.Z version
+	versionDirDepth = 2     // How many parent directories to look through for a full X.Y.Z version
+	abiFlagChars    = "dmt" // The characters CPython uses for ABI flags in executable names
+	specialABIFlags = "dt"  // ABI flags marking a special build that must be asked for explicitly
+)
+
+// The keys used in the debug logs, the same as package resolve's so they can be searched together.
+const (
+	logKeyInterpreter = "interpreter" // The path to a single python interpreter
+	logKeyReason      = "reason"      // Why an interpreter was skipped
+)
+
+// discard is the logger used when GetAllFS is given a nil one.
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// versionDirRegex matches a full X.Y.Z version (with optional pre-release) somewhere in a directory name
+// like the ones pyenv (3.12.4, 3.14.0a3), uv (cpython-3.12.4-linux-x86_64-gnu) or Homebrew (python@3.12/3.12.4)
+// install into.
+var versionDirRegex = regexp.MustCompile(`(?:^|[^\d.])(\d+)\.(\d+)\.(\d+)((?:a|b|rc)\d+)?`)
+
+// preReleaseRegex matches a pre-release suffix e.g. "a3", "b1" or "rc2".
+var preReleaseRegex = regexp.MustCompile(`^(a|b|rc)(\d+)$`)
+
+// preReleaseLevels gives the sort order of each pre-release level, a final
+// release (no level at all) sorts after all of them.
+var preReleaseLevels = map[string]int{
+	"a":  1,
+	"b":  2,
+	"rc": 3,
+}
+
+// implementations maps the executable name prefix for each supported implementation
+// to it's name, in the order they are checked.
+var implementations = [...]struct {
+	prefix string // The executable name prefix e.g. "pypy"
+	name   string // The implementation name e.g. PyPy
+}{
+	{prefix: "python", name: CPython},
+	{prefix: "pypy", name: PyPy},
+	{prefix: "graalpy", name: GraalPy},
+	{prefix: "pyston", name: Pyston},
+}
+
 // Interpreter represents a version of a python interpreter
-// only major and minor are included because this is how the executables
-// are stored on disk (e.g. /usr/local/bin/python3.9).
+// major and minor are always present because this is how the executables
+// are stored on disk (e.g. /usr/local/bin/python3.9), the patch version is only
+// known if it's in the install directory (e.g. ~/.pyenv/versions/3.9.18/bin/python3.9)
+// or the interpreter has been probed.
+//
+// The zero value of Patch is 0 not Unknown, so an Interpreter built by hand that
+// doesn't know it's patch version must set Patch to Unknown, otherwise it's
+// treated as an X.Y.0 release.
 type Interpreter struct {
-	Path  string // The absolute path to the interpreter executable
-	Major int    // The intepreter major version e.g. 3
-	Minor int    // The interpreter minor version e.g. 10
+	Path           string // The absolute path to the interpreter executable
+	Implementation string // The python implementation e.g. CPython or PyPy
+	ABIFlags       string // Any ABI flags in the executable name e.g. "t" for python3.13t, empty for a standard build
+	Major          int    // The intepreter major version e.g. 3
+	Minor          int    // The interpreter minor version e.g. 10
+	Patch          int    // The interpreter patch version e.g. 4, or Unknown
+	PreRelease     string // The pre-release e.g. "a3", "b1" or "rc2", empty for a final release or if not known
+	Arch           string // The architecture the interpreter was built for e.g. "x86_64", empty if not known
+	Bits           int    // Whether the interpreter is 32 or 64 bit, 0 if not known
+
+	// Source is the directory the interpreter was found in e.g. /usr/local/bin, and Rank is
+	// it's position in the directories searched (0 being first on $PATH), so when two
+	// interpreters have the same version the one a shell would run sorts first
+	Source string
+	Rank   int
+
+	// Aliases are the other paths found to the same executable e.g. /usr/local/bin/python3.12
+	// symlinked to /usr/bin/python3.12, in the order they were found
+	Aliases []string
 }
 
 // FromFilePath extracts the version information from a python interpreter's filepath
 // and loads the information into the calling `Interpreter`
-// If the filename does not start with `python` or does not have a valid
-// two digit version after it, an error will be returned
+// If the filename does not start with a known implementation prefix (e.g. `python` or `pypy`)
+// or does not have a valid two digit version after it, an error will be returned
 //
-// A valid filepath will look like `/usr/local/bin/python3.9`
+// A valid filepath will look like `/usr/local/bin/python3.9` or `/usr/local/bin/pypy3.10`
 // things like `/usr/local/bin/python` will be rejected as these
 // typically refer to the system version of python which should not be used.
+//
+// The minor version may be followed by ABI flags, so free-threaded (`python3.13t`),
+// debug (`python3.12d`) and pymalloc (`python3.7m`) builds are also accepted.
+//
+// The patch version (and pre-release if there is one) is taken from the names of the parent
+// directories if they contain a matching X.Y.Z version, otherwise it is Unknown.
 func (i *Interpreter) FromFilePath(path string) error {
-	// Make sure the file name starts with `python`
+	// Make sure the file name starts with a known implementation e.g. `python`
 	filename := filepath.Base(path)
-	if !strings.HasPrefix(filename, pythonExePrefix) {
+	implementation, version, ok := splitImplementation(filename)
+	if !ok {
 		return fmt.Errorf("filepath is not a valid python interpreter: %s", path)
 	}
 
-	// Because we know the filename will always start with `python`
-	// we can split off the version simply by indexing from the end of `python`
-	// to the end of the string
-	// This is a naive index that doesn't take UTF-8 runes into account but
-	// since this is by definition a Unix filepath I think that's pretty safe
-	// this will also catch things like `python-config` but we check later for version numbers
-	// so this is fine too
-	version := filename[len(pythonExePrefix):]
-
 	parts := strings.Split(version, ".")
 
 	// If we can't get a part either side of a ".", we have a bad version
@@ -56,7 +130,8 @@ func (i *Interpreter) FromFilePath(path string) error {
 		return fmt.Errorf("malformed interpreter version: %s from filepath: %s", version, path)
 	}
 
-	major, minor := parts[0], parts[1]
+	major := parts[0]
+	minor, flags := splitABIFlags(parts[1])
 
 	majorInt, err := strconv.Atoi(major)
 	if err != nil {
@@ -76,8 +151,11 @@ func (i *Interpreter) FromFilePath(path string) error {
 		return fmt.Errorf("could not resolve path %s to absolute: %w", path, err)
 	}
 
+	i.Implementation = implementation
 	i.Major = majorInt
 	i.Minor = minorInt
+	i.Patch, i.PreRelease = patchFromDirs(path, majorInt, minorInt)
+	i.ABIFlags = flags
 	i.Path = path
 
 	return nil
@@ -93,15 +171,51 @@ func (i Interpreter) String() string {
 //
 // Example
 //
-//	i := Interpreter{Major: 3, Minor: 10, Path:"/usr/bin/python3.10"}
+//	i := Interpreter{Major: 3, Minor: 10, Patch: Unknown, Path:"/usr/bin/python3.10"}
 //	fmt.Println(i.ToString())
 //
 // Output: "3.10	│ /usr/bin/python3.10".
+//
+// The patch version is included when it's known, so with Patch: 4 it's "3.10.4	│ /usr/bin/python3.10".
+//
+// Implementations other than CPython are prefixed with their name e.g. "pypy3.10".
 func (i Interpreter) ToString() string {
+	var prefix string
+	if i.Implementation != CPython {
+		prefix = i.Implementation
+	}
+	// Anything else we know about the interpreter goes in brackets after the path
+	// e.g. "(x86_64, pre-release)"
+	var details []string
+	if i.Arch != "" {
+		details = append(details, i.Arch)
+	}
+	if i.IsPreRelease() {
+		details = append(details, "pre-release")
+	}
+	var extra string
+	if len(details) != 0 {
+		extra = " (" + strings.Join(details, ", ") + ")"
+	}
 	// Note, the vertical bar character below is not the U+007C "Vertical Line" pipe character
 	// '|' but the U+2502 "Box Drawings Light Vertical" character '│'
 	// this is so, when printed it looks like a proper table
-	return fmt.Sprintf("%d.%d\t│ %s", i.Major, i.Minor, i.Path)
+	return fmt.Sprintf("%s%s%s\t│ %s%s", prefix, i.Version(), i.ABIFlags, i.Path, extra)
+}
+
+// Version returns the interpreter's version e.g. "3.10", including the patch version
+// and pre-release if they are known e.g. "3.10.4" or "3.14.0a3".
+func (i Interpreter) Version() string {
+	if i.Patch == Unknown {
+		return fmt.Sprintf("%d.%d", i.Major, i.Minor)
+	}
+	return fmt.Sprintf("%d.%d.%d%s", i.Major, i.Minor, i.Patch, i.PreRelease)
+}
+
+// IsPreRelease reports whether the interpreter is known to be a pre-release
+// (alpha, beta or release candidate) build.
+func (i Interpreter) IsPreRelease() bool {
+	return i.PreRelease != ""
 }
 
 // SatisfiesMajor tests whether the calling Interpreter satisfies the constraint
@@ -116,6 +230,49 @@ func (i Interpreter) SatisfiesExact(major, minor int) bool {
 	return i.Major == major && i.Minor == minor
 }
 
+// SatisfiesPatch tests whether the calling Interpreter satisfies
+// the exact version contraint given by `major`, `minor` and `patch`.
+//
+// An interpreter whose patch version is Unknown never satisfies a patch constraint.
+func (i Interpreter) SatisfiesPatch(major, minor, patch int) bool {
+	return i.SatisfiesExact(major, minor) && i.Patch != Unknown && i.Patch == patch
+}
+
+// SatisfiesBits tests whether the calling Interpreter is a `bits` (32 or 64) bit build.
+//
+// An interpreter whose architecture is not known never satisfies a bits constraint.
+func (i Interpreter) SatisfiesBits(bits int) bool {
+	return i.Bits != 0 && i.Bits == bits
+}
+
+// SatisfiesImplementation tests whether the calling Interpreter is the python
+// implementation given by `implementation` e.g. CPython or PyPy.
+func (i Interpreter) SatisfiesImplementation(implementation string) bool {
+	return i.Implementation == implementation
+}
+
+// SatisfiesABI tests whether the calling Interpreter was built with the ABI flags
+// given by `flags`.
+//
+// Every requested flag must be present, and special builds (free-threaded or debug)
+// only satisfy a request that explicitly asks for them, so `python3.13t` satisfies "t"
+// but not "", whereas `python3.7m` satisfies both "m" and "".
+func (i Interpreter) SatisfiesABI(flags string) bool {
+	for _, flag := range flags {
+		if !strings.ContainsRune(i.ABIFlags, flag) {
+			return false
+		}
+	}
+
+	for _, flag := range i.ABIFlags {
+		if strings.ContainsRune(specialABIFlags, flag) && !strings.ContainsRune(flags, flag) {
+			return false
+		}
+	}
+
+	return true
+}
+
 // byVersion represents a list of python interpreters
 // and enables us to implement sorting which is how we tell which one is
 // the latest python version without relying on filesystem lexical order
@@ -140,7 +297,21 @@ func (bv byVersion) Less(i, j int) bool {
 	// Only get here if majors are equal or i.Major < j.Major
 	if bv[i].Major == bv[j].Major {
 		// If majors are equal, compare minors
-		return bv[i].Minor > bv[j].Minor
+		if bv[i].Minor != bv[j].Minor {
+			return bv[i].Minor > bv[j].Minor
+		}
+		// Then patches. A name-only binary (e.g. /usr/local/bin/python3.12) says nothing about it's
+		// patch so it sorts after every known one, as Unknown is lower than any patch. It must have
+		// a fixed place, letting $PATH decide between a known and an unknown patch isn't an ordering
+		if bv[i].Patch != bv[j].Patch {
+			return bv[i].Patch > bv[j].Patch
+		}
+		// Then a final release is later than any of it's pre-releases
+		if cmp := comparePreRelease(bv[i].PreRelease, bv[j].PreRelease); cmp != 0 {
+			return cmp > 0
+		}
+		// Finally the same version, so whichever is earlier on $PATH
+		return bv[i].Rank < bv[j].Rank
 	}
 
 	// Now only condition remaining is i.Major < j.Major
@@ -156,35 +327,55 @@ func (bv byVersion) Swap(i, j int) {
 // GetAll looks under each path in `paths` for valid python
 // interpreters and returns the ones it finds
 //
-// This is allowed in this context because in usage in this program, `paths` will
-// be populated by searching through $PATH, meaning we don't have to bother checking
-// if files are executable etc and $PATH is unlikely to be cluttered with random
-// files called `python` unless they are the interpreter executables.
+// Only executable regular files (following symlinks) named like an interpreter are
+// returned, so directories, files that aren't executable and dangling symlinks are skipped.
+//
+// Paths to the same executable (e.g. symlinks to it) are returned once, as the one
+// first in `paths`, with the rest in it's Aliases.
 func GetAll(paths []string) ([]Interpreter, error) {
+	return GetAllFS(OS, nil, paths)
+}
+
+// GetAllFS is GetAll but looks for the interpreters on 'fsys' rather than the real filesystem,
+// logging why any candidates were skipped to 'logger' at debug level. A nil 'logger' discards them.
+func GetAllFS(fsys FS, logger *slog.Logger, paths []string) ([]Interpreter, error) {
+	if logger == nil {
+		logger = discard
+	}
+
 	var interpreters []Interpreter
 
-	for _, path := range paths {
-		found, err := getPythonInterpreters(path)
+	for rank, path := range paths {
+		found, err := getPythonInterpreters(fsys, logger, path)
 		if err != nil {
 			return nil, fmt.Errorf("could not fetch interpreters under %s: %w", path, err)
 		}
+		for i := range found {
+			found[i].Source = path
+			found[i].Rank = rank
+		}
 		interpreters = append(interpreters, found...)
 	}
 
-	return interpreters, nil
+	return deDupe(fsys, interpreters), nil
 }
 
+// Sort sorts `interpreters` in place so the latest version is first, returning
+// the sorted slice for convenience.
+//
+// Interpreters with the same version are ordered by their Rank, so the one earliest
+// on $PATH comes first, and are otherwise left in the order they were given.
 func Sort(interpreters []Interpreter) []Interpreter {
 	pythons := interpreters
-	sort.Sort(byVersion(pythons))
+	sort.Stable(byVersion(pythons))
 
 	return pythons
 }
 
-// getPythonInterpreters accepts an absolute path to a directory under which
+// getPythonInterpreters accepts an absolute path to a directory on 'fsys' under which
 // it will search for python interpreters, returning any it finds.
-func getPythonInterpreters(dir string) ([]Interpreter, error) {
-	contents, err := os.ReadDir(dir)
+func getPythonInterpreters(fsys FS, logger *slog.Logger, dir string) ([]Interpreter, error) {
+	contents, err := fsys.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("could not read contents of %s: %w", dir, err)
 	}
@@ -197,6 +388,12 @@ func getPythonInterpreters(dir string) ([]Interpreter, error) {
 		if err := interpreter.FromFilePath(itemPath); err == nil {
 			// Only add if the interpreter is valid and python3, the others we don't care about
 			if interpreter.SatisfiesMajor(3) { //nolint: mnd
+				if err := checkExecutable(fsys, itemPath); err != nil {
+					logger.Debug("Skipping python interpreter", logKeyInterpreter, itemPath, logKeyReason, err.Error())
+					continue
+				}
+				// Not being able to read the architecture isn't fatal, it just stays unknown
+				_ = interpreter.readArch(fsys) //nolint: errcheck
 				interpreters = append(interpreters, interpreter)
 			}
 		}
@@ -204,3 +401,82 @@ func getPythonInterpreters(dir string) ([]Interpreter, error) {
 
 	return interpreters, nil
 }
+
+// patchFromDirs looks through the names of the directories above the interpreter at `path`
+// for a full X.Y.Z version matching `major` and `minor`, returning the patch version
+// and pre-release if it finds one, or Unknown and an empty string.
+func patchFromDirs(path string, major, minor int) (int, string) {
+	dir := filepath.Dir(path)
+	for n := 0; n < versionDirDepth; n++ {
+		for _, match := range versionDirRegex.FindAllStringSubmatch(filepath.Base(dir), -1) {
+			// Errors are impossible here as the regex only matches digits
+			dirMajor, _ := strconv.Atoi(match[1]) //nolint: errcheck
+			dirMinor, _ := strconv.Atoi(match[2]) //nolint: errcheck
+			dirPatch, _ := strconv.Atoi(match[3]) //nolint: errcheck
+			if dirMajor == major && dirMinor == minor {
+				return dirPatch, match[4]
+			}
+		}
+		dir = filepath.Dir(dir)
+	}
+	return Unknown, ""
+}
+
+// comparePreRelease compares two pre-release suffixes (e.g. "a3", "rc1" or "" for a final release)
+// returning a positive number if `a` is later than `b`, negative if it's earlier and 0 if they're equal.
+func comparePreRelease(a, b string) int {
+	aLevel, aSerial := parsePreRelease(a)
+	bLevel, bSerial := parsePreRelease(b)
+	if aLevel != bLevel {
+		return aLevel - bLevel
+	}
+	return aSerial - bSerial
+}
+
+// parsePreRelease splits a pre-release suffix into the sort order of it's level
+// and it's serial number e.g. "b2" -> 2, 2. A final release ("") sorts after
+// every pre-release.
+func parsePreRelease(pre string) (level, serial int) {
+	match := preReleaseRegex.FindStringSubmatch(pre)
+	if match == nil {
+		return len(preReleaseLevels) + 1, 0
+	}
+	// Error is impossible here as the regex only matches digits
+	serial, _ = strconv.Atoi(match[2]) //nolint: errcheck
+	return preReleaseLevels[match[1]], serial
+}
+
+// IsImplementation reports whether `name` is one of the python implementations
+// py knows how to discover e.g. "pypy".
+func IsImplementation(name string) bool {
+	for _, impl := range implementations {
+		if impl.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// splitImplementation splits the implementation prefix off an executable name
+// e.g. "pypy3.10" -> PyPy, "3.10". If the name doesn't start with a known prefix
+// ok will be false.
+func splitImplementation(name string) (implementation, rest string, ok bool) {
+	for _, impl := range implementations {
+		if strings.HasPrefix(name, impl.prefix) {
+			// This is a naive index that doesn't take UTF-8 runes into account but
+			// since this is by definition a Unix filepath I think that's pretty safe
+			// this will also catch things like `python-config` but we check later for version numbers
+			// so this is fine too
+			return impl.name, name[len(impl.prefix):], true
+		}
+	}
+	return "", "", false
+}
+
+// splitABIFlags splits any trailing ABI flag characters off a version component
+// e.g. "13t" -> "13", "t". Anything that isn't a known flag is left alone so it
+// fails integer parsing later on.
+func splitABIFlags(component string) (string, string) {
+	trimmed := strings.TrimRight(component, abiFlagChars)
+	return trimmed, component[len(trimmed):]
+}
@@ -21,27 +21,129 @@ func TestInterpreter_FromFilePath(t *testing.T) {
 		{
 			name:    "valid 3.7",
 			args:    args{filepath: "/usr/local/bin/python3.7"},
-			want:    Interpreter{Major: 3, Minor: 7, Path: "/usr/local/bin/python3.7"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 7, Patch: Unknown, Path: "/usr/local/bin/python3.7"},
 			wantErr: false,
 		},
 		{
 			name:    "valid 3.10",
 			args:    args{filepath: "/usr/local/bin/python3.10"},
-			want:    Interpreter{Major: 3, Minor: 10, Path: "/usr/local/bin/python3.10"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 10, Patch: Unknown, Path: "/usr/local/bin/python3.10"},
 			wantErr: false,
 		},
 		{
 			name:    "valid 4.0",
 			args:    args{filepath: "/usr/local/bin/python4.0"},
-			want:    Interpreter{Major: 4, Minor: 0, Path: "/usr/local/bin/python4.0"},
+			want:    Interpreter{Implementation: CPython, Major: 4, Minor: 0, Patch: Unknown, Path: "/usr/local/bin/python4.0"},
 			wantErr: false,
 		},
 		{
 			name:    "valid 2.7",
 			args:    args{filepath: "/usr/bin/python2.7"},
-			want:    Interpreter{Major: 2, Minor: 7, Path: "/usr/bin/python2.7"},
+			want:    Interpreter{Implementation: CPython, Major: 2, Minor: 7, Patch: Unknown, Path: "/usr/bin/python2.7"},
 			wantErr: false,
 		},
+		{
+			name:    "free-threaded 3.13t",
+			args:    args{filepath: "/usr/local/bin/python3.13t"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 13, Patch: Unknown, ABIFlags: "t", Path: "/usr/local/bin/python3.13t"},
+			wantErr: false,
+		},
+		{
+			name:    "debug 3.12d",
+			args:    args{filepath: "/usr/local/bin/python3.12d"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: Unknown, ABIFlags: "d", Path: "/usr/local/bin/python3.12d"},
+			wantErr: false,
+		},
+		{
+			name:    "pymalloc 3.7m",
+			args:    args{filepath: "/usr/bin/python3.7m"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 7, Patch: Unknown, ABIFlags: "m", Path: "/usr/bin/python3.7m"},
+			wantErr: false,
+		},
+		{
+			name:    "free-threaded debug 3.13td",
+			args:    args{filepath: "/usr/local/bin/python3.13td"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 13, Patch: Unknown, ABIFlags: "td", Path: "/usr/local/bin/python3.13td"},
+			wantErr: false,
+		},
+		{
+			name:    "pypy 3.10",
+			args:    args{filepath: "/usr/local/bin/pypy3.10"},
+			want:    Interpreter{Implementation: PyPy, Major: 3, Minor: 10, Patch: Unknown, Path: "/usr/local/bin/pypy3.10"},
+			wantErr: false,
+		},
+		{
+			name:    "graalpy 3.11",
+			args:    args{filepath: "/usr/local/bin/graalpy3.11"},
+			want:    Interpreter{Implementation: GraalPy, Major: 3, Minor: 11, Patch: Unknown, Path: "/usr/local/bin/graalpy3.11"},
+			wantErr: false,
+		},
+		{
+			name:    "pyston 3.8",
+			args:    args{filepath: "/usr/local/bin/pyston3.8"},
+			want:    Interpreter{Implementation: Pyston, Major: 3, Minor: 8, Patch: Unknown, Path: "/usr/local/bin/pyston3.8"},
+			wantErr: false,
+		},
+		{
+			name:    "unversioned pypy",
+			args:    args{filepath: "/usr/local/bin/pypy"},
+			want:    Interpreter{},
+			wantErr: true,
+		},
+		{
+			name:    "patch from pyenv directory",
+			args:    args{filepath: "/home/me/.pyenv/versions/3.12.4/bin/python3.12"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: 4, Path: "/home/me/.pyenv/versions/3.12.4/bin/python3.12"},
+			wantErr: false,
+		},
+		{
+			name:    "patch from uv directory",
+			args:    args{filepath: "/home/me/.local/share/uv/python/cpython-3.11.9-linux-x86_64-gnu/bin/python3.11"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 11, Patch: 9, Path: "/home/me/.local/share/uv/python/cpython-3.11.9-linux-x86_64-gnu/bin/python3.11"},
+			wantErr: false,
+		},
+		{
+			name:    "patch from homebrew directory",
+			args:    args{filepath: "/opt/homebrew/Cellar/python@3.12/3.12.1/bin/python3.12"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: 1, Path: "/opt/homebrew/Cellar/python@3.12/3.12.1/bin/python3.12"},
+			wantErr: false,
+		},
+		{
+			name:    "pre-release from pyenv directory",
+			args:    args{filepath: "/home/me/.pyenv/versions/3.14.0a3/bin/python3.14"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 14, Patch: 0, PreRelease: "a3", Path: "/home/me/.pyenv/versions/3.14.0a3/bin/python3.14"},
+			wantErr: false,
+		},
+		{
+			name:    "pre-release from uv directory",
+			args:    args{filepath: "/home/me/.local/share/uv/python/cpython-3.14.0rc1-linux-x86_64-gnu/bin/python3.14"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 14, Patch: 0, PreRelease: "rc1", Path: "/home/me/.local/share/uv/python/cpython-3.14.0rc1-linux-x86_64-gnu/bin/python3.14"},
+			wantErr: false,
+		},
+		{
+			name:    "directory version for a different minor is ignored",
+			args:    args{filepath: "/home/me/.pyenv/versions/3.12.4/bin/python3.11"},
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 11, Patch: Unknown, Path: "/home/me/.pyenv/versions/3.12.4/bin/python3.11"},
+			wantErr: false,
+		},
+		{
+			name:    "pypy version in directory is ignored",
+			args:    args{filepath: "/home/me/.pyenv/versions/pypy3.10-7.3.17/bin/pypy3.10"},
+			want:    Interpreter{Implementation: PyPy, Major: 3, Minor: 10, Patch: Unknown, Path: "/home/me/.pyenv/versions/pypy3.10-7.3.17/bin/pypy3.10"},
+			wantErr: false,
+		},
+		{
+			name:    "ABI flags with no minor",
+			args:    args{filepath: "/usr/local/bin/python3.t"},
+			want:    Interpreter{},
+			wantErr: true,
+		},
+		{
+			name:    "config script",
+			args:    args{filepath: "/usr/local/bin/python3.13t-config"},
+			want:    Interpreter{},
+			wantErr: true,
+		},
 		{
 			name:    "no Interpreter numbers (usually means system python)",
 			args:    args{filepath: "/usr/bin/python"},
@@ -110,9 +212,14 @@ func TestInterpreter_FromFilePath(t *testing.T) {
 
 func TestInterpreter_ToString(t *testing.T) {
 	type fields struct {
-		Path  string
-		Major int
-		Minor int
+		Path           string
+		Implementation string
+		ABIFlags       string
+		Major          int
+		Minor          int
+		Patch          int
+		PreRelease     string
+		Arch           string
 	}
 	tests := []struct {
 		name   string
@@ -121,21 +228,56 @@ func TestInterpreter_ToString(t *testing.T) {
 	}{
 		{
 			name:   "python 3.10",
-			fields: fields{Major: 3, Minor: 10, Path: "/usr/local/bin/python3.10"},
+			fields: fields{Implementation: CPython, Major: 3, Minor: 10, Patch: Unknown, Path: "/usr/local/bin/python3.10"},
 			want:   "3.10\t│ /usr/local/bin/python3.10",
 		},
 		{
 			name:   "python 3.9",
-			fields: fields{Major: 3, Minor: 9, Path: "/usr/local/bin/python3.9"},
+			fields: fields{Implementation: CPython, Major: 3, Minor: 9, Patch: Unknown, Path: "/usr/local/bin/python3.9"},
 			want:   "3.9\t│ /usr/local/bin/python3.9",
 		},
+		{
+			name:   "python 3.13t",
+			fields: fields{Implementation: CPython, Major: 3, Minor: 13, Patch: Unknown, ABIFlags: "t", Path: "/usr/local/bin/python3.13t"},
+			want:   "3.13t\t│ /usr/local/bin/python3.13t",
+		},
+		{
+			name:   "python 3.12.4",
+			fields: fields{Implementation: CPython, Major: 3, Minor: 12, Patch: 4, Path: "/usr/local/bin/python3.12"},
+			want:   "3.12.4\t│ /usr/local/bin/python3.12",
+		},
+		{
+			name:   "python 3.14.0a3",
+			fields: fields{Implementation: CPython, Major: 3, Minor: 14, Patch: 0, PreRelease: "a3", Path: "/usr/local/bin/python3.14"},
+			want:   "3.14.0a3\t│ /usr/local/bin/python3.14 (pre-release)",
+		},
+		{
+			name:   "python 3.12 with architecture",
+			fields: fields{Implementation: CPython, Major: 3, Minor: 12, Patch: Unknown, Arch: "aarch64", Path: "/usr/bin/python3.12"},
+			want:   "3.12\t│ /usr/bin/python3.12 (aarch64)",
+		},
+		{
+			name:   "python 3.14.0a3 with architecture",
+			fields: fields{Implementation: CPython, Major: 3, Minor: 14, Patch: 0, PreRelease: "a3", Arch: "x86_64", Path: "/usr/bin/python3.14"},
+			want:   "3.14.0a3\t│ /usr/bin/python3.14 (x86_64, pre-release)",
+		},
+		{
+			name:   "pypy 3.10",
+			fields: fields{Implementation: PyPy, Major: 3, Minor: 10, Patch: Unknown, Path: "/usr/local/bin/pypy3.10"},
+			want:   "pypy3.10\t│ /usr/local/bin/pypy3.10",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			i := &Interpreter{
-				Major: tt.fields.Major,
-				Minor: tt.fields.Minor,
-				Path:  tt.fields.Path,
+				Implementation: tt.fields.Implementation,
+				Major:          tt.fields.Major,
+				Minor:          tt.fields.Minor,
+				Patch:          tt.fields.Patch,
+				PreRelease:     tt.fields.PreRelease,
+				Arch:           tt.fields.Arch,
+				ABIFlags:       tt.fields.ABIFlags,
+				Path:           tt.fields.Path,
 			}
 			if got := i.ToString(); got != tt.want {
 				t.Errorf("got %s, wanted %s", got, tt.want)
@@ -274,6 +416,85 @@ func TestInterpreterSort(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "patch versions",
+			list: []Interpreter{
+				{Major: 3, Minor: 12, Patch: Unknown, Rank: 2},
+				{Major: 3, Minor: 12, Patch: 1, Rank: 1},
+				{Major: 3, Minor: 11, Patch: 9, Rank: 0},
+				{Major: 3, Minor: 12, Patch: 4, Rank: 0},
+			},
+			want: []Interpreter{
+				{Major: 3, Minor: 12, Patch: 4, Rank: 0},
+				{Major: 3, Minor: 12, Patch: 1, Rank: 1},
+				{Major: 3, Minor: 12, Patch: Unknown, Rank: 2},
+				{Major: 3, Minor: 11, Patch: 9, Rank: 0},
+			},
+		},
+		{
+			name: "known patch before unknown patch",
+			list: []Interpreter{
+				{Path: "/home/me/.pyenv/versions/3.12.1/bin/python3.12", Major: 3, Minor: 12, Patch: 1, Rank: 1},
+				{Path: "/usr/local/bin/python3.12", Major: 3, Minor: 12, Patch: Unknown, Rank: 0},
+				{Path: "/home/me/.pyenv/versions/3.11.9/bin/python3.11", Major: 3, Minor: 11, Patch: 9, Rank: 2},
+				{Path: "/usr/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown, Rank: 3},
+			},
+			want: []Interpreter{
+				{Path: "/home/me/.pyenv/versions/3.12.1/bin/python3.12", Major: 3, Minor: 12, Patch: 1, Rank: 1},
+				{Path: "/usr/local/bin/python3.12", Major: 3, Minor: 12, Patch: Unknown, Rank: 0},
+				{Path: "/home/me/.pyenv/versions/3.11.9/bin/python3.11", Major: 3, Minor: 11, Patch: 9, Rank: 2},
+				{Path: "/usr/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown, Rank: 3},
+			},
+		},
+		{
+			name: "pre-releases",
+			list: []Interpreter{
+				{Major: 3, Minor: 14, Patch: 0, PreRelease: "b1"},
+				{Major: 3, Minor: 14, Patch: 0, PreRelease: "a3"},
+				{Major: 3, Minor: 14, Patch: 0},
+				{Major: 3, Minor: 14, Patch: 0, PreRelease: "rc2"},
+				{Major: 3, Minor: 14, Patch: 0, PreRelease: "a10"},
+				{Major: 3, Minor: 13, Patch: 1},
+			},
+			want: []Interpreter{
+				{Major: 3, Minor: 14, Patch: 0},
+				{Major: 3, Minor: 14, Patch: 0, PreRelease: "rc2"},
+				{Major: 3, Minor: 14, Patch: 0, PreRelease: "b1"},
+				{Major: 3, Minor: 14, Patch: 0, PreRelease: "a10"},
+				{Major: 3, Minor: 14, Patch: 0, PreRelease: "a3"},
+				{Major: 3, Minor: 13, Patch: 1},
+			},
+		},
+		{
+			name: "same version earlier on path first",
+			list: []Interpreter{
+				{Path: "/opt/bin/python3.12", Major: 3, Minor: 12, Patch: Unknown, Rank: 2},
+				{Path: "/usr/bin/python3.12", Major: 3, Minor: 12, Patch: Unknown, Rank: 1},
+				{Path: "/usr/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown, Rank: 1},
+				{Path: "/home/me/.local/bin/python3.12", Major: 3, Minor: 12, Patch: Unknown, Rank: 0},
+			},
+			want: []Interpreter{
+				{Path: "/home/me/.local/bin/python3.12", Major: 3, Minor: 12, Patch: Unknown, Rank: 0},
+				{Path: "/usr/bin/python3.12", Major: 3, Minor: 12, Patch: Unknown, Rank: 1},
+				{Path: "/opt/bin/python3.12", Major: 3, Minor: 12, Patch: Unknown, Rank: 2},
+				{Path: "/usr/bin/python3.11", Major: 3, Minor: 11, Patch: Unknown, Rank: 1},
+			},
+		},
+		{
+			name: "same version and rank keep their order",
+			list: []Interpreter{
+				{Path: "/usr/bin/pypy3.10", Implementation: PyPy, Major: 3, Minor: 10, Patch: Unknown},
+				{Path: "/usr/bin/python3.9", Implementation: CPython, Major: 3, Minor: 9, Patch: Unknown},
+				{Path: "/usr/bin/python3.10", Implementation: CPython, Major: 3, Minor: 10, Patch: Unknown},
+				{Path: "/usr/bin/graalpy3.10", Implementation: GraalPy, Major: 3, Minor: 10, Patch: Unknown},
+			},
+			want: []Interpreter{
+				{Path: "/usr/bin/pypy3.10", Implementation: PyPy, Major: 3, Minor: 10, Patch: Unknown},
+				{Path: "/usr/bin/python3.10", Implementation: CPython, Major: 3, Minor: 10, Patch: Unknown},
+				{Path: "/usr/bin/graalpy3.10", Implementation: GraalPy, Major: 3, Minor: 10, Patch: Unknown},
+				{Path: "/usr/bin/python3.9", Implementation: CPython, Major: 3, Minor: 9, Patch: Unknown},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -290,6 +511,31 @@ func TestInterpreterSort(t *testing.T) {
 	}
 }
 
+func TestInterpreterSort_anyOrder(t *testing.T) {
+	// Sorting must be a consistent ordering, so every order of the same interpreters
+	// sorts the same way, mixing known and unknown patches across $PATH included
+	pythons := []Interpreter{
+		{Path: "/a/python3.12", Major: 3, Minor: 12, Patch: 1, Rank: 0},
+		{Path: "/b/python3.12", Major: 3, Minor: 12, Patch: Unknown, Rank: 1},
+		{Path: "/c/python3.12", Major: 3, Minor: 12, Patch: 4, Rank: 2},
+	}
+
+	want := []Interpreter{pythons[2], pythons[0], pythons[1]}
+
+	orders := [][]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+	for _, order := range orders {
+		list := make([]Interpreter, 0, len(order))
+		for _, i := range order {
+			list = append(list, pythons[i])
+		}
+
+		Sort(list)
+		if !reflect.DeepEqual(list, want) {
+			t.Errorf("sorting order %v: got %v, wanted %v", order, list, want)
+		}
+	}
+}
+
 func TestInterpreter_SatisfiesMajor(t *testing.T) {
 	type args struct {
 		version int
@@ -413,12 +659,161 @@ func TestInterpreter_SatisfiesExact(t *testing.T) {
 	}
 }
 
+func TestInterpreter_SatisfiesPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		interpreter Interpreter
+		major       int
+		minor       int
+		patch       int
+		want        bool
+	}{
+		{
+			name:        "3.12.4 satisfies 3.12.4",
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: 4},
+			major:       3,
+			minor:       12,
+			patch:       4,
+			want:        true,
+		},
+		{
+			name:        "3.12.4 does not satisfy 3.12.3",
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: 4},
+			major:       3,
+			minor:       12,
+			patch:       3,
+			want:        false,
+		},
+		{
+			name:        "3.11.4 does not satisfy 3.12.4",
+			interpreter: Interpreter{Major: 3, Minor: 11, Patch: 4},
+			major:       3,
+			minor:       12,
+			patch:       4,
+			want:        false,
+		},
+		{
+			name:        "unknown patch never satisfies",
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: Unknown},
+			major:       3,
+			minor:       12,
+			patch:       0,
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.interpreter.SatisfiesPatch(tt.major, tt.minor, tt.patch); got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_SatisfiesABI(t *testing.T) {
+	tests := []struct {
+		name        string
+		flags       string
+		interpreter Interpreter
+		want        bool
+	}{
+		{
+			name:        "standard build satisfies no flags",
+			interpreter: Interpreter{Major: 3, Minor: 12},
+			flags:       "",
+			want:        true,
+		},
+		{
+			name:        "standard build does not satisfy t",
+			interpreter: Interpreter{Major: 3, Minor: 13},
+			flags:       "t",
+			want:        false,
+		},
+		{
+			name:        "free-threaded satisfies t",
+			interpreter: Interpreter{Major: 3, Minor: 13, ABIFlags: "t"},
+			flags:       "t",
+			want:        true,
+		},
+		{
+			name:        "free-threaded does not satisfy no flags",
+			interpreter: Interpreter{Major: 3, Minor: 13, ABIFlags: "t"},
+			flags:       "",
+			want:        false,
+		},
+		{
+			name:        "debug does not satisfy no flags",
+			interpreter: Interpreter{Major: 3, Minor: 12, ABIFlags: "d"},
+			flags:       "",
+			want:        false,
+		},
+		{
+			name:        "free-threaded debug does not satisfy t",
+			interpreter: Interpreter{Major: 3, Minor: 13, ABIFlags: "td"},
+			flags:       "t",
+			want:        false,
+		},
+		{
+			name:        "free-threaded debug satisfies dt",
+			interpreter: Interpreter{Major: 3, Minor: 13, ABIFlags: "td"},
+			flags:       "dt",
+			want:        true,
+		},
+		{
+			name:        "pymalloc satisfies no flags",
+			interpreter: Interpreter{Major: 3, Minor: 7, ABIFlags: "m"},
+			flags:       "",
+			want:        true,
+		},
+		{
+			name:        "pymalloc satisfies m",
+			interpreter: Interpreter{Major: 3, Minor: 7, ABIFlags: "m"},
+			flags:       "m",
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.interpreter.SatisfiesABI(tt.flags); got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsImplementation(t *testing.T) {
+	tests := []struct {
+		name string
+		impl string
+		want bool
+	}{
+		{name: "cpython", impl: "cpython", want: true},
+		{name: "pypy", impl: "pypy", want: true},
+		{name: "graalpy", impl: "graalpy", want: true},
+		{name: "pyston", impl: "pyston", want: true},
+		{name: "executable prefix is not an implementation", impl: "python", want: false},
+		{name: "unknown", impl: "jython", want: false},
+		{name: "empty", impl: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsImplementation(tt.impl); got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func Test_getPythonInterpreters(t *testing.T) {
 	root, err := os.Getwd()
 	if err != nil {
 		t.Fatalf("could not get cwd: %s", err)
 	}
 	testDir := filepath.Join(root, "testdata", "pythonpaths", "pythonpath1")
+	invalidDir := filepath.Join(root, "testdata", "pythonpaths", "invalid")
 
 	type args struct {
 		dir string
@@ -434,14 +829,34 @@ func Test_getPythonInterpreters(t *testing.T) {
 			args: args{dir: testDir},
 			want: []Interpreter{
 				{
-					Major: 3,
-					Minor: 10,
-					Path:  filepath.Join(testDir, "python3.10"),
+					Implementation: CPython,
+					Major:          3,
+					Minor:          10,
+					Patch:          Unknown,
+					Path:           filepath.Join(testDir, "python3.10"),
 				},
 				{
-					Major: 3,
-					Minor: 9,
-					Path:  filepath.Join(testDir, "python3.9"),
+					Implementation: CPython,
+					Major:          3,
+					Minor:          9,
+					Patch:          Unknown,
+					Path:           filepath.Join(testDir, "python3.9"),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			// Directories, non-executables and dangling symlinks (including to a directory)
+			// are all skipped, only the symlink to an executable is kept
+			name: "invalid candidates",
+			args: args{dir: invalidDir},
+			want: []Interpreter{
+				{
+					Implementation: CPython,
+					Major:          3,
+					Minor:          6,
+					Patch:          Unknown,
+					Path:           filepath.Join(invalidDir, "python3.6"),
 				},
 			},
 			wantErr: false,
@@ -449,7 +864,7 @@ func Test_getPythonInterpreters(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := getPythonInterpreters(tt.args.dir)
+			got, err := getPythonInterpreters(OS, discard, tt.args.dir)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("getPythonInterpreters() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -485,29 +900,68 @@ func TestGetAllPythonInterpreters(t *testing.T) {
 			}},
 			want: []Interpreter{
 				{
-					Major: 3,
-					Minor: 10,
-					Path:  filepath.Join(testDir, "pythonpath1", "python3.10"),
+					Implementation: CPython,
+					Major:          3,
+					Minor:          10,
+					Patch:          Unknown,
+					Path:           filepath.Join(testDir, "pythonpath1", "python3.10"),
+					Source:         filepath.Join(testDir, "pythonpath1"),
+					Rank:           0,
 				},
 				{
-					Major: 3,
-					Minor: 9,
-					Path:  filepath.Join(testDir, "pythonpath1", "python3.9"),
+					Implementation: CPython,
+					Major:          3,
+					Minor:          9,
+					Patch:          Unknown,
+					Path:           filepath.Join(testDir, "pythonpath1", "python3.9"),
+					Source:         filepath.Join(testDir, "pythonpath1"),
+					Rank:           0,
 				},
 				{
-					Major: 3,
-					Minor: 7,
-					Path:  filepath.Join(testDir, "pythonpath2", "python3.7"),
+					Implementation: PyPy,
+					Major:          3,
+					Minor:          10,
+					Patch:          Unknown,
+					Path:           filepath.Join(testDir, "pythonpath2", "pypy3.10"),
+					Source:         filepath.Join(testDir, "pythonpath2"),
+					Rank:           1,
 				},
 				{
-					Major: 3,
-					Minor: 8,
-					Path:  filepath.Join(testDir, "pythonpath2", "python3.8"),
+					Implementation: CPython,
+					Major:          3,
+					Minor:          7,
+					Patch:          Unknown,
+					Path:           filepath.Join(testDir, "pythonpath2", "python3.7"),
+					Source:         filepath.Join(testDir, "pythonpath2"),
+					Rank:           1,
 				},
 				{
-					Major: 3,
-					Minor: 5,
-					Path:  filepath.Join(testDir, "pythonpath3", "python3.5"),
+					Implementation: CPython,
+					Major:          3,
+					Minor:          8,
+					Patch:          Unknown,
+					Path:           filepath.Join(testDir, "pythonpath2", "python3.8"),
+					Source:         filepath.Join(testDir, "pythonpath2"),
+					Rank:           1,
+				},
+				{
+					Implementation: CPython,
+					Major:          3,
+					Minor:          13,
+					Patch:          Unknown,
+					ABIFlags:       "t",
+					Path:           filepath.Join(testDir, "pythonpath3", "python3.13t"),
+					Source:         filepath.Join(testDir, "pythonpath3"),
+					Rank:           2,
+				},
+				{
+					Implementation: CPython,
+					Major:          3,
+					Minor:          5,
+					Patch:          Unknown,
+					Path:           filepath.Join(testDir, "pythonpath3", "python3.5"),
+					Source:         filepath.Join(testDir, "pythonpath3"),
+					Rank:           2,
 				},
 			},
 		},
@@ -0,0 +1,79 @@
+package interpreter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// probeTimeout is how long we give an interpreter to answer a probe, it should
+// only take a few ms but some implementations (looking at you GraalPy) are slow to start.
+const probeTimeout = 5 * time.Second
+
+// probeScript prints everything we want to know about an interpreter on a single line.
+const probeScript = `import sys; v = sys.version_info; print(v.major, v.minor, v.micro, v.releaselevel, v.serial)`
+
+// probeFields is the number of fields printed by probeScript.
+const probeFields = 5
+
+// releaseLevels maps the python sys.version_info.releaselevel to the suffix
+// used in version strings e.g. "alpha" -> "a" for 3.14.0a3.
+var releaseLevels = map[string]string{
+	"alpha":     "a",
+	"beta":      "b",
+	"candidate": "rc",
+	"final":     "",
+}
+
+// Probe runs the interpreter to ask it for the version information that can't
+// be determined from it's filepath (e.g. the patch version or whether it's a pre-release) and loads it into the
+// calling `Interpreter`. The Path must already be set, typically by FromFilePath.
+//
+// Because this means actually executing the interpreter it is much slower than
+// anything else in this package, so should only be used when that information is needed.
+func (i *Interpreter) Probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	stdout := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, i.Path, "-c", probeScript)
+	cmd.Stdout = stdout
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("could not probe interpreter %s: %w", i.Path, err)
+	}
+
+	fields := strings.Fields(stdout.String())
+	if len(fields) != probeFields {
+		return fmt.Errorf("unexpected probe output from %s: %q", i.Path, stdout.String())
+	}
+
+	major, minor, patch, level, serial := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	version := make([]int, 0, len(fields))
+	for _, field := range [...]string{major, minor, patch, serial} {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return fmt.Errorf("unexpected probe output from %s: %q", i.Path, stdout.String())
+		}
+		version = append(version, n)
+	}
+
+	suffix, ok := releaseLevels[level]
+	if !ok {
+		return fmt.Errorf("unexpected release level from %s: %q", i.Path, level)
+	}
+
+	i.Major = version[0]
+	i.Minor = version[1]
+	i.Patch = version[2]
+	i.PreRelease = ""
+	if suffix != "" {
+		i.PreRelease = suffix + strconv.Itoa(version[3])
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package interpreter //nolint: testpackage // Need access to internals
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakePython writes an executable shell script to a temporary directory that prints
+// 'output' when run, standing in for a real interpreter, and returns it's path.
+func fakePython(t *testing.T, name, output string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	script := "#!/bin/sh\necho '" + output + "'\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil { //nolint: gosec // Needs to be executable
+		t.Fatalf("could not write fake python: %v", err)
+	}
+	return path
+}
+
+func TestInterpreter_Probe(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    Interpreter
+		wantErr bool
+	}{
+		{
+			name:    "valid",
+			output:  "3 12 4 final 0",
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: 4},
+			wantErr: false,
+		},
+		{
+			name:    "alpha",
+			output:  "3 12 0 alpha 3",
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: 0, PreRelease: "a3"},
+			wantErr: false,
+		},
+		{
+			name:    "release candidate",
+			output:  "3 12 0 candidate 1",
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: 0, PreRelease: "rc1"},
+			wantErr: false,
+		},
+		{
+			name:    "unknown release level",
+			output:  "3 12 0 gamma 1",
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: Unknown},
+			wantErr: true,
+		},
+		{
+			name:    "garbage",
+			output:  "Python 3.12.4",
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: Unknown},
+			wantErr: true,
+		},
+		{
+			name:    "not a number",
+			output:  "3 12 x final 0",
+			want:    Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: Unknown},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := fakePython(t, "python3.12", tt.output)
+			python := Interpreter{Implementation: CPython, Major: 3, Minor: 12, Patch: Unknown, Path: path}
+
+			err := python.Probe(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Probe() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			tt.want.Path = path
+			if !reflect.DeepEqual(python, tt.want) {
+				t.Errorf("got %#v, wanted %#v", python, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterpreter_ProbeMissing(t *testing.T) {
+	python := Interpreter{Path: filepath.Join(t.TempDir(), "python3.12")}
+	if err := python.Probe(context.Background()); err == nil {
+		t.Error("expected an error probing a missing interpreter, got nil")
+	}
+}
@@ -0,0 +1,219 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Any is used in a Spec to indicate that a version component was not specified
+// and so any value will do.
+const Any = -1
+
+// Spec represents a request for a particular python interpreter e.g. "3", "3.10", "3.12.4", "3.13t" or "3.12-32"
+// as passed to py as a version specifier.
+//
+// Note that unspecified version components must be set to Any, as 0 is a valid version.
+type Spec struct {
+	Implementation string // The requested implementation e.g. PyPy, empty means no preference
+	ABIFlags       string // The requested ABI flags e.g. "t" for a free-threaded build, empty for a standard build
+	Major          int    // The requested major version e.g. 3, or Any
+	Minor          int    // The requested minor version e.g. 10, or Any
+	Patch          int    // The requested patch version e.g. 4, or Any
+	Bits           int    // The requested architecture bitness, 32 or 64, or 0 for no preference
+}
+
+// ParseSpec parses a version specifier (without the leading "-") into a Spec.
+//
+// A valid specifier is of the form X, X.Y, X.Y.Z or any of those followed by ABI flags
+// e.g. "3", "3.10", "3.12.4", "3.13t" or "3.12d". It may also be prefixed with an implementation's
+// executable name to request that implementation e.g. "pypy3.10" or "python3.12", and
+// suffixed with "-64" or "-32" to request an architecture like the Windows launcher e.g. "3.12-32".
+func ParseSpec(spec string) (Spec, error) {
+	implementation, version, ok := splitImplementation(spec)
+	if !ok {
+		version = spec
+	}
+
+	version, bits := splitBits(version)
+	version, flags := splitABIFlags(version)
+
+	parts := strings.Split(version, ".")
+	if len(parts) > xYZParts {
+		return Spec{}, fmt.Errorf("malformed version specifier %q: not X, X.Y or X.Y.Z format", spec)
+	}
+
+	major, err := parseComponent(parts[0])
+	if err != nil {
+		return Spec{}, fmt.Errorf("malformed version specifier %q: major component not an integer", spec)
+	}
+
+	minor := Any
+	if len(parts) >= xYParts {
+		minor, err = parseComponent(parts[1])
+		if err != nil {
+			return Spec{}, fmt.Errorf("malformed version specifier %q: minor component not an integer", spec)
+		}
+	}
+
+	patch := Any
+	if len(parts) == xYZParts {
+		patch, err = parseComponent(parts[2])
+		if err != nil {
+			return Spec{}, fmt.Errorf("malformed version specifier %q: patch component not an integer", spec)
+		}
+	}
+
+	return Spec{Implementation: implementation, Major: major, Minor: minor, Patch: patch, ABIFlags: flags, Bits: bits}, nil
+}
+
+// String returns the version part of the specifier in the same form it would be passed
+// on the command line (again without the leading "-").
+func (s Spec) String() string {
+	var arch string
+	if s.Bits != 0 {
+		arch = fmt.Sprintf("-%d", s.Bits)
+	}
+
+	switch {
+	case s.Major == Any:
+		return s.ABIFlags + arch
+	case s.Minor == Any:
+		return fmt.Sprintf("%d%s%s", s.Major, s.ABIFlags, arch)
+	case s.Patch == Any:
+		return fmt.Sprintf("%d.%d%s%s", s.Major, s.Minor, s.ABIFlags, arch)
+	default:
+		return fmt.Sprintf("%d.%d.%d%s%s", s.Major, s.Minor, s.Patch, s.ABIFlags, arch)
+	}
+}
+
+// Executable returns the name of the executable the Spec is asking for
+// e.g. "python3.10" or "pypy3.10".
+func (s Spec) Executable() string {
+	prefix := implementations[0].prefix
+	for _, impl := range implementations {
+		if impl.name == s.Implementation {
+			prefix = impl.prefix
+		}
+	}
+	return prefix + s.String()
+}
+
+// Matches reports whether the interpreter `i` satisfies every constraint in the Spec.
+func (s Spec) Matches(i Interpreter) bool {
+	switch {
+	case s.Major == Any:
+		// Anything goes
+	case s.Minor == Any:
+		if !i.SatisfiesMajor(s.Major) {
+			return false
+		}
+	case s.Patch == Any:
+		if !i.SatisfiesExact(s.Major, s.Minor) {
+			return false
+		}
+	default:
+		if !i.SatisfiesPatch(s.Major, s.Minor, s.Patch) {
+			return false
+		}
+	}
+
+	if s.Implementation != "" && !i.SatisfiesImplementation(s.Implementation) {
+		return false
+	}
+
+	if s.Bits != 0 && !i.SatisfiesBits(s.Bits) {
+		return false
+	}
+
+	return i.SatisfiesABI(s.ABIFlags)
+}
+
+// Filter returns the interpreters from `interpreters` that match the Spec.
+//
+// If the Spec doesn't ask for a particular implementation, CPython is preferred and
+// other implementations are only returned if there are no matching CPython interpreters.
+func (s Spec) Filter(interpreters []Interpreter) []Interpreter {
+	var matching, cpython []Interpreter
+	for _, python := range interpreters {
+		if s.Matches(python) {
+			matching = append(matching, python)
+			if python.SatisfiesImplementation(CPython) {
+				cpython = append(cpython, python)
+			}
+		}
+	}
+
+	if s.Implementation == "" && len(cpython) != 0 {
+		return cpython
+	}
+
+	return matching
+}
+
+// Nearest returns the interpreter from `interpreters` closest to the Spec when none of
+// them satisfy it, that is one matching everything but the minor (and patch) version
+// whose minor version is closest to the one asked for. Ties go to the newer version e.g.
+// asking for 3.10 with 3.9 and 3.11 available gets 3.11.
+//
+// If the Spec doesn't pin a minor version there's nothing nearer to look for, so
+// Nearest returns false unless something satisfies the Spec exactly.
+func Nearest(s Spec, interpreters []Interpreter) (Interpreter, bool) {
+	if exact := s.Filter(interpreters); len(exact) != 0 {
+		Sort(exact)
+		return exact[0], true
+	}
+
+	if s.Minor == Any {
+		return Interpreter{}, false
+	}
+
+	relaxed := s
+	relaxed.Minor = Any
+	relaxed.Patch = Any
+
+	candidates := relaxed.Filter(interpreters)
+	if len(candidates) == 0 {
+		return Interpreter{}, false
+	}
+
+	// Latest first so the first of any equally near interpreters is the newest
+	Sort(candidates)
+	nearest := candidates[0]
+	for _, python := range candidates[1:] {
+		if distance(python.Minor, s.Minor) < distance(nearest.Minor, s.Minor) {
+			nearest = python
+		}
+	}
+
+	return nearest, true
+}
+
+// distance returns the absolute difference between two version components.
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// parseComponent parses a single version component, unlike strconv.Atoi
+// it only accepts plain digits so things like "+3" are rejected.
+func parseComponent(component string) (int, error) {
+	if component == "" || strings.TrimLeft(component, "0123456789") != "" {
+		return 0, fmt.Errorf("%q is not a valid version component", component)
+	}
+	return strconv.Atoi(component)
+}
+
+// splitBits splits a trailing "-64" or "-32" architecture suffix off a version specifier
+// e.g. "3.12-32" -> "3.12", 32. If there isn't one, bits is 0.
+func splitBits(spec string) (string, int) {
+	for _, bits := range [...]int{bits64, bits32} {
+		suffix := fmt.Sprintf("-%d", bits)
+		if strings.HasSuffix(spec, suffix) {
+			return strings.TrimSuffix(spec, suffix), bits
+		}
+	}
+	return spec, 0
+}
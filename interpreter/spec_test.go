@@ -0,0 +1,508 @@
+package interpreter //nolint: testpackage // Need access to internals
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    Spec
+		wantErr bool
+	}{
+		{
+			name:    "major",
+			spec:    "3",
+			want:    Spec{Major: 3, Minor: Any, Patch: Any},
+			wantErr: false,
+		},
+		{
+			name:    "exact",
+			spec:    "3.10",
+			want:    Spec{Major: 3, Minor: 10, Patch: Any},
+			wantErr: false,
+		},
+		{
+			name:    "free-threaded",
+			spec:    "3.13t",
+			want:    Spec{Major: 3, Minor: 13, ABIFlags: "t", Patch: Any},
+			wantErr: false,
+		},
+		{
+			name:    "debug",
+			spec:    "3.12d",
+			want:    Spec{Major: 3, Minor: 12, ABIFlags: "d", Patch: Any},
+			wantErr: false,
+		},
+		{
+			name:    "major with flags",
+			spec:    "3t",
+			want:    Spec{Major: 3, Minor: Any, ABIFlags: "t", Patch: Any},
+			wantErr: false,
+		},
+		{
+			name:    "pypy exact",
+			spec:    "pypy3.10",
+			want:    Spec{Implementation: PyPy, Major: 3, Minor: 10, Patch: Any},
+			wantErr: false,
+		},
+		{
+			name:    "cpython executable",
+			spec:    "python3.12",
+			want:    Spec{Implementation: CPython, Major: 3, Minor: 12, Patch: Any},
+			wantErr: false,
+		},
+		{
+			name:    "graalpy major",
+			spec:    "graalpy3",
+			want:    Spec{Implementation: GraalPy, Major: 3, Minor: Any, Patch: Any},
+			wantErr: false,
+		},
+		{
+			name:    "implementation with no version",
+			spec:    "pypy",
+			want:    Spec{},
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			spec:    "",
+			want:    Spec{},
+			wantErr: true,
+		},
+		{
+			name:    "only flags",
+			spec:    "t",
+			want:    Spec{},
+			wantErr: true,
+		},
+		{
+			name:    "unknown flag",
+			spec:    "3.12x",
+			want:    Spec{},
+			wantErr: true,
+		},
+		{
+			name:    "signed major",
+			spec:    "+3",
+			want:    Spec{},
+			wantErr: true,
+		},
+		{
+			name:    "no minor",
+			spec:    "3.",
+			want:    Spec{},
+			wantErr: true,
+		},
+		{
+			name:    "patch",
+			spec:    "3.12.4",
+			want:    Spec{Major: 3, Minor: 12, Patch: 4},
+			wantErr: false,
+		},
+		{
+			name:    "patch with flags",
+			spec:    "3.13.1t",
+			want:    Spec{Major: 3, Minor: 13, Patch: 1, ABIFlags: "t"},
+			wantErr: false,
+		},
+		{
+			name:    "64 bit",
+			spec:    "3.12-64",
+			want:    Spec{Major: 3, Minor: 12, Patch: Any, Bits: 64},
+			wantErr: false,
+		},
+		{
+			name:    "32 bit major",
+			spec:    "3-32",
+			want:    Spec{Major: 3, Minor: Any, Patch: Any, Bits: 32},
+			wantErr: false,
+		},
+		{
+			name:    "everything",
+			spec:    "pypy3.10.14-64",
+			want:    Spec{Implementation: PyPy, Major: 3, Minor: 10, Patch: 14, Bits: 64},
+			wantErr: false,
+		},
+		{
+			name:    "free-threaded 64 bit",
+			spec:    "3.13t-64",
+			want:    Spec{Major: 3, Minor: 13, Patch: Any, ABIFlags: "t", Bits: 64},
+			wantErr: false,
+		},
+		{
+			name:    "unknown bits",
+			spec:    "3.12-16",
+			want:    Spec{},
+			wantErr: true,
+		},
+		{
+			name:    "bad patch",
+			spec:    "3.12.x",
+			want:    Spec{},
+			wantErr: true,
+		},
+		{
+			name:    "too many parts",
+			spec:    "3.12.4.1",
+			want:    Spec{},
+			wantErr: true,
+		},
+		{
+			name:    "whitespace",
+			spec:    "3. 9",
+			want:    Spec{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSpec() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpec_String(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		spec Spec
+	}{
+		{
+			name: "major",
+			spec: Spec{Major: 3, Minor: Any, Patch: Any},
+			want: "3",
+		},
+		{
+			name: "exact",
+			spec: Spec{Major: 3, Minor: 10, Patch: Any},
+			want: "3.10",
+		},
+		{
+			name: "free-threaded",
+			spec: Spec{Major: 3, Minor: 13, ABIFlags: "t", Patch: Any},
+			want: "3.13t",
+		},
+		{
+			name: "patch",
+			spec: Spec{Major: 3, Minor: 12, Patch: 4},
+			want: "3.12.4",
+		},
+		{
+			name: "32 bit",
+			spec: Spec{Major: 3, Minor: 13, Patch: Any, ABIFlags: "t", Bits: 32},
+			want: "3.13t-32",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.String(); got != tt.want {
+				t.Errorf("got %s, wanted %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpec_Executable(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		spec Spec
+	}{
+		{
+			name: "no implementation",
+			spec: Spec{Major: 3, Minor: 10, Patch: Any},
+			want: "python3.10",
+		},
+		{
+			name: "cpython",
+			spec: Spec{Implementation: CPython, Major: 3, Minor: Any, Patch: Any},
+			want: "python3",
+		},
+		{
+			name: "pypy",
+			spec: Spec{Implementation: PyPy, Major: 3, Minor: 10, Patch: Any},
+			want: "pypy3.10",
+		},
+		{
+			name: "any version",
+			spec: Spec{Implementation: GraalPy, Major: Any, Minor: Any, Patch: Any},
+			want: "graalpy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.Executable(); got != tt.want {
+				t.Errorf("got %s, wanted %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpec_Matches(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        Spec
+		interpreter Interpreter
+		want        bool
+	}{
+		{
+			name:        "3 matches 3.12",
+			spec:        Spec{Major: 3, Minor: Any, Patch: Any},
+			interpreter: Interpreter{Major: 3, Minor: 12},
+			want:        true,
+		},
+		{
+			name:        "3 does not match 3.13t",
+			spec:        Spec{Major: 3, Minor: Any, Patch: Any},
+			interpreter: Interpreter{Major: 3, Minor: 13, ABIFlags: "t"},
+			want:        false,
+		},
+		{
+			name:        "3.12 matches 3.12",
+			spec:        Spec{Major: 3, Minor: 12, Patch: Any},
+			interpreter: Interpreter{Major: 3, Minor: 12},
+			want:        true,
+		},
+		{
+			name:        "3.12 does not match 3.11",
+			spec:        Spec{Major: 3, Minor: 12, Patch: Any},
+			interpreter: Interpreter{Major: 3, Minor: 11},
+			want:        false,
+		},
+		{
+			name:        "3.13t matches 3.13t",
+			spec:        Spec{Major: 3, Minor: 13, ABIFlags: "t", Patch: Any},
+			interpreter: Interpreter{Major: 3, Minor: 13, ABIFlags: "t"},
+			want:        true,
+		},
+		{
+			name:        "3.13t does not match 3.13",
+			spec:        Spec{Major: 3, Minor: 13, ABIFlags: "t", Patch: Any},
+			interpreter: Interpreter{Major: 3, Minor: 13},
+			want:        false,
+		},
+		{
+			name:        "3.12.4 matches 3.12.4",
+			spec:        Spec{Major: 3, Minor: 12, Patch: 4},
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: 4},
+			want:        true,
+		},
+		{
+			name:        "3.12.4 does not match 3.12.1",
+			spec:        Spec{Major: 3, Minor: 12, Patch: 4},
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: 1},
+			want:        false,
+		},
+		{
+			name:        "3.12.4 does not match unknown patch",
+			spec:        Spec{Major: 3, Minor: 12, Patch: 4},
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: Unknown},
+			want:        false,
+		},
+		{
+			name:        "3.12 matches unknown patch",
+			spec:        Spec{Major: 3, Minor: 12, Patch: Any},
+			interpreter: Interpreter{Major: 3, Minor: 12, Patch: Unknown},
+			want:        true,
+		},
+		{
+			name:        "3.12-64 matches 64 bit",
+			spec:        Spec{Major: 3, Minor: 12, Patch: Any, Bits: 64},
+			interpreter: Interpreter{Major: 3, Minor: 12, Arch: "x86_64", Bits: 64},
+			want:        true,
+		},
+		{
+			name:        "3.12-64 does not match 32 bit",
+			spec:        Spec{Major: 3, Minor: 12, Patch: Any, Bits: 64},
+			interpreter: Interpreter{Major: 3, Minor: 12, Arch: "i686", Bits: 32},
+			want:        false,
+		},
+		{
+			name:        "3.12-32 does not match unknown architecture",
+			spec:        Spec{Major: 3, Minor: 12, Patch: Any, Bits: 32},
+			interpreter: Interpreter{Major: 3, Minor: 12},
+			want:        false,
+		},
+		{
+			name:        "any matches anything",
+			spec:        Spec{Major: Any, Minor: Any, Patch: Any},
+			interpreter: Interpreter{Major: 2, Minor: 7},
+			want:        true,
+		},
+		{
+			name:        "pypy3.10 matches pypy3.10",
+			spec:        Spec{Implementation: PyPy, Major: 3, Minor: 10, Patch: Any},
+			interpreter: Interpreter{Implementation: PyPy, Major: 3, Minor: 10},
+			want:        true,
+		},
+		{
+			name:        "pypy3.10 does not match python3.10",
+			spec:        Spec{Implementation: PyPy, Major: 3, Minor: 10, Patch: Any},
+			interpreter: Interpreter{Implementation: CPython, Major: 3, Minor: 10},
+			want:        false,
+		},
+		{
+			name:        "no implementation matches pypy",
+			spec:        Spec{Major: 3, Minor: 10, Patch: Any},
+			interpreter: Interpreter{Implementation: PyPy, Major: 3, Minor: 10},
+			want:        true,
+		},
+		{
+			name:        "3.13 does not match 3.13t",
+			spec:        Spec{Major: 3, Minor: 13, Patch: Any},
+			interpreter: Interpreter{Major: 3, Minor: 13, ABIFlags: "t"},
+			want:        false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.Matches(tt.interpreter); got != tt.want {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpec_Filter(t *testing.T) {
+	cpython := Interpreter{Implementation: CPython, Major: 3, Minor: 10}
+	pypy := Interpreter{Implementation: PyPy, Major: 3, Minor: 10}
+	newerPypy := Interpreter{Implementation: PyPy, Major: 3, Minor: 11}
+
+	tests := []struct {
+		name         string
+		spec         Spec
+		interpreters []Interpreter
+		want         []Interpreter
+	}{
+		{
+			name:         "prefers cpython",
+			spec:         Spec{Major: 3, Minor: Any, Patch: Any},
+			interpreters: []Interpreter{newerPypy, cpython, pypy},
+			want:         []Interpreter{cpython},
+		},
+		{
+			name:         "falls back to other implementations",
+			spec:         Spec{Major: 3, Minor: 11, Patch: Any},
+			interpreters: []Interpreter{newerPypy, cpython, pypy},
+			want:         []Interpreter{newerPypy},
+		},
+		{
+			name:         "explicit implementation",
+			spec:         Spec{Implementation: PyPy, Major: 3, Minor: Any, Patch: Any},
+			interpreters: []Interpreter{newerPypy, cpython, pypy},
+			want:         []Interpreter{newerPypy, pypy},
+		},
+		{
+			name:         "no matches",
+			spec:         Spec{Major: 3, Minor: 12, Patch: Any},
+			interpreters: []Interpreter{newerPypy, cpython, pypy},
+			want:         nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.Filter(tt.interpreters); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearest(t *testing.T) {
+	interpreters := []Interpreter{
+		{Implementation: CPython, Major: 3, Minor: 9, Patch: Unknown, Path: "/usr/bin/python3.9"},
+		{Implementation: CPython, Major: 3, Minor: 11, Patch: Unknown, Path: "/usr/bin/python3.11"},
+		{Implementation: CPython, Major: 3, Minor: 13, Patch: Unknown, ABIFlags: "t", Path: "/usr/bin/python3.13t"},
+		{Implementation: PyPy, Major: 3, Minor: 8, Patch: Unknown, Path: "/usr/bin/pypy3.8"},
+	}
+
+	tests := []struct {
+		name   string
+		spec   Spec
+		want   string
+		wantOk bool
+	}{
+		{
+			name:   "exact match",
+			spec:   Spec{Major: 3, Minor: 9, Patch: Any},
+			want:   "/usr/bin/python3.9",
+			wantOk: true,
+		},
+		{
+			name:   "tie goes to newer",
+			spec:   Spec{Major: 3, Minor: 10, Patch: Any},
+			want:   "/usr/bin/python3.11",
+			wantOk: true,
+		},
+		{
+			name:   "older is nearer",
+			spec:   Spec{Major: 3, Minor: 7, Patch: Any},
+			want:   "/usr/bin/python3.9",
+			wantOk: true,
+		},
+		{
+			name:   "newer is nearer",
+			spec:   Spec{Major: 3, Minor: 14, Patch: Any},
+			want:   "/usr/bin/python3.11",
+			wantOk: true,
+		},
+		{
+			name:   "keeps ABI flags",
+			spec:   Spec{Major: 3, Minor: 14, Patch: Any, ABIFlags: "t"},
+			want:   "/usr/bin/python3.13t",
+			wantOk: true,
+		},
+		{
+			name:   "keeps implementation",
+			spec:   Spec{Implementation: PyPy, Major: 3, Minor: 10, Patch: Any},
+			want:   "/usr/bin/pypy3.8",
+			wantOk: true,
+		},
+		{
+			name:   "missing patch",
+			spec:   Spec{Major: 3, Minor: 11, Patch: 99},
+			want:   "/usr/bin/python3.11",
+			wantOk: true,
+		},
+		{
+			name:   "different major",
+			spec:   Spec{Major: 4, Minor: 0, Patch: Any},
+			want:   "",
+			wantOk: false,
+		},
+		{
+			name:   "major only has nothing nearer",
+			spec:   Spec{Implementation: GraalPy, Major: 3, Minor: Any, Patch: Any},
+			want:   "",
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Nearest(tt.spec, interpreters)
+			if ok != tt.wantOk {
+				t.Fatalf("got ok %v, wanted %v", ok, tt.wantOk)
+			}
+
+			if got.Path != tt.want {
+				t.Errorf("got %s, wanted %s", got.Path, tt.want)
+			}
+		})
+	}
+}
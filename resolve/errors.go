@@ -0,0 +1,166 @@
+package resolve
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// ErrNoInterpreter is returned when there are no usable python interpreters on $PATH
+// at all, as opposed to a *NoMatchError when there are but none of them are what was asked for.
+var ErrNoInterpreter = errors.New("no python interpreters found on $PATH")
+
+// NoMatchError is returned when there are python interpreters on $PATH but none
+// of them satisfy what was asked for.
+type NoMatchError struct {
+	Requires   string                    // The requires-python constraint asked for, empty if Spec was
+	Candidates []interpreter.Interpreter // The interpreters that were considered
+	Installers []string                  // Tools on $PATH that could install the missing python e.g. "uv"
+	Spec       interpreter.Spec          // The version specifier asked for
+}
+
+// Error implements the error interface for *NoMatchError.
+func (e *NoMatchError) Error() string {
+	if e.Requires != "" {
+		return fmt.Sprintf("no python interpreter satisfying requires-python %q found on $PATH", e.Requires)
+	}
+
+	// With no version asked for, there are candidates but they're all ones the latest never is
+	if e.Spec.Major == interpreter.Any {
+		if excluded := e.excluded(); len(excluded) != 0 {
+			return fmt.Sprintf("no %s interpreter on $PATH can be picked as the latest, %s", e.Spec.Executable(), strings.Join(excluded, " and "))
+		}
+	}
+
+	msg := fmt.Sprintf("no %s interpreter found on $PATH", e.Spec.Executable())
+	installed := installedVersions(e.Candidates)
+	if len(installed) == 0 {
+		return msg
+	}
+
+	if nearest, ok := e.Nearest(); ok {
+		msg += ", the nearest is " + executable(nearest)
+	}
+	return fmt.Sprintf("%s (installed: %s)", msg, strings.Join(installed, ", "))
+}
+
+// excluded says which of the candidates of the implementation asked for were left out as they're
+// never picked as the latest, and how to opt in to them e.g. "pre-releases (python3.14) need
+// --pre or PY_PRERELEASE=1".
+func (e *NoMatchError) excluded() []string {
+	var preReleases, special []string
+	for _, python := range interpreter.Sort(slices.Clone(e.Candidates)) {
+		if e.Spec.Implementation != "" && !python.SatisfiesImplementation(e.Spec.Implementation) {
+			continue
+		}
+
+		switch name := executable(python); {
+		case !python.SatisfiesABI(e.Spec.ABIFlags):
+			if !slices.Contains(special, name) {
+				special = append(special, name)
+			}
+		case python.IsPreRelease():
+			if !slices.Contains(preReleases, name) {
+				preReleases = append(preReleases, name)
+			}
+		}
+	}
+
+	var excluded []string
+	if len(preReleases) != 0 {
+		excluded = append(excluded, fmt.Sprintf("pre-releases (%s) need --pre or %s=1", strings.Join(preReleases, ", "), EnvPreRelease))
+	}
+	if len(special) != 0 {
+		excluded = append(excluded, fmt.Sprintf("free-threaded and debug builds (%s) must be asked for explicitly e.g. py %s", strings.Join(special, ", "), specifier(special[0])))
+	}
+	return excluded
+}
+
+// specifier returns the command line specifier for the executable 'name' e.g. "-3.13t"
+// for python3.13t, other implementations are asked for by name e.g. "pypy3.10".
+func specifier(name string) string {
+	if version, ok := strings.CutPrefix(name, "python"); ok {
+		return "-" + version
+	}
+	return name
+}
+
+// Nearest returns the candidate closest to the version that was asked for, see
+// interpreter.Nearest. It returns false if none are close enough to suggest.
+func (e *NoMatchError) Nearest() (interpreter.Interpreter, bool) {
+	if e.Requires != "" {
+		return interpreter.Interpreter{}, false
+	}
+	return interpreter.Nearest(e.Spec, e.Candidates)
+}
+
+// InstallCommands returns the commands that would install the missing python
+// with each of the Installers, e.g. "uv python install 3.9".
+func (e *NoMatchError) InstallCommands() []string {
+	if e.Requires != "" {
+		return nil
+	}
+
+	var commands []string
+	for _, tool := range e.Installers {
+		if command, ok := installCommand(tool, e.Spec); ok {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// installedVersions returns the distinct executable names of 'interpreters'
+// e.g. "python3.12" or "pypy3.10", latest first.
+func installedVersions(interpreters []interpreter.Interpreter) []string {
+	sorted := interpreter.Sort(slices.Clone(interpreters))
+
+	var versions []string
+	for _, python := range sorted {
+		if name := executable(python); !slices.Contains(versions, name) {
+			versions = append(versions, name)
+		}
+	}
+	return versions
+}
+
+// executable returns the X.Y executable name for 'python' e.g. "python3.13t".
+func executable(python interpreter.Interpreter) string {
+	spec := interpreter.Spec{
+		Implementation: python.Implementation,
+		ABIFlags:       python.ABIFlags,
+		Major:          python.Major,
+		Minor:          python.Minor,
+		Patch:          interpreter.Any,
+	}
+	return spec.Executable()
+}
+
+// MalformedEnvError is returned when an environment variable py reads
+// (e.g. $PY_PYTHON) is set to something it doesn't understand.
+type MalformedEnvError struct {
+	Key    string // The name of the variable e.g. PY_PYTHON
+	Value  string // What it was set to
+	Reason string // What's wrong with it e.g. "not X.Y format"
+}
+
+// Error implements the error interface for *MalformedEnvError.
+func (e *MalformedEnvError) Error() string {
+	return fmt.Sprintf("malformed %s %q: %s", e.Key, e.Value, e.Reason)
+}
+
+// BrokenVenvError is returned when py is told to use a virtual environment (by
+// $VIRTUAL_ENV or finding one in cwd) whose python doesn't exist, usually because
+// the interpreter it was created from has been uninstalled or upgraded.
+type BrokenVenvError struct {
+	Path   string // The root of the virtual environment
+	Python string // The python executable that should be there
+}
+
+// Error implements the error interface for *BrokenVenvError.
+func (e *BrokenVenvError) Error() string {
+	return fmt.Sprintf("virtual environment %s is broken, %s does not exist", e.Path, e.Python)
+}
@@ -0,0 +1,198 @@
+package resolve //nolint: testpackage // Need access to internals
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/py/internal/fixture"
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+func TestErrors(t *testing.T) {
+	spec := interpreter.Spec{Major: 3, Minor: 4, Patch: interpreter.Any}
+
+	tests := []struct {
+		err  error
+		name string
+		want string
+	}{
+		{
+			name: "no match",
+			err:  &NoMatchError{Spec: spec},
+			want: "no python3.4 interpreter found on $PATH",
+		},
+		{
+			name: "no match suggests nearest",
+			err: &NoMatchError{
+				Spec: interpreter.Spec{Major: 3, Minor: 9, Patch: interpreter.Any},
+				Candidates: []interpreter.Interpreter{
+					{Implementation: interpreter.CPython, Major: 3, Minor: 10, Patch: 1},
+					{Implementation: interpreter.CPython, Major: 3, Minor: 12, Patch: 2},
+					{Implementation: interpreter.CPython, Major: 3, Minor: 10, Patch: 4},
+					{Implementation: interpreter.PyPy, Major: 3, Minor: 10, Patch: interpreter.Unknown},
+				},
+			},
+			want: "no python3.9 interpreter found on $PATH, the nearest is python3.10 (installed: python3.12, python3.10, pypy3.10)",
+		},
+		{
+			name: "no match nothing near",
+			err: &NoMatchError{
+				Spec:       interpreter.Spec{Implementation: interpreter.PyPy, Major: 3, Minor: 9, Patch: interpreter.Any},
+				Candidates: []interpreter.Interpreter{{Implementation: interpreter.CPython, Major: 3, Minor: 12, Patch: 2}},
+			},
+			want: "no pypy3.9 interpreter found on $PATH (installed: python3.12)",
+		},
+		{
+			name: "no latest only pre-releases",
+			err: &NoMatchError{
+				Spec: interpreter.Spec{Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any},
+				Candidates: []interpreter.Interpreter{
+					{Implementation: interpreter.CPython, Major: 3, Minor: 14, Patch: 0, PreRelease: "a3"},
+				},
+			},
+			want: "no python interpreter on $PATH can be picked as the latest, pre-releases (python3.14) need --pre or PY_PRERELEASE=1",
+		},
+		{
+			name: "no latest pre-releases and special builds",
+			err: &NoMatchError{
+				Spec: interpreter.Spec{Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any},
+				Candidates: []interpreter.Interpreter{
+					{Implementation: interpreter.CPython, ABIFlags: "t", Major: 3, Minor: 13, Patch: 1},
+					{Implementation: interpreter.CPython, Major: 3, Minor: 14, Patch: 0, PreRelease: "b1"},
+					{Implementation: interpreter.CPython, ABIFlags: "d", Major: 3, Minor: 12, Patch: interpreter.Unknown},
+				},
+			},
+			want: "no python interpreter on $PATH can be picked as the latest, pre-releases (python3.14) need --pre or PY_PRERELEASE=1" +
+				" and free-threaded and debug builds (python3.13t, python3.12d) must be asked for explicitly e.g. py -3.13t",
+		},
+		{
+			name: "no latest of implementation",
+			err: &NoMatchError{
+				Spec:       interpreter.Spec{Implementation: interpreter.PyPy, Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any},
+				Candidates: []interpreter.Interpreter{{Implementation: interpreter.CPython, Major: 3, Minor: 12, Patch: 2}},
+			},
+			want: "no pypy interpreter found on $PATH (installed: python3.12)",
+		},
+		{
+			name: "no match requires-python",
+			err:  &NoMatchError{Requires: ">=4"},
+			want: `no python interpreter satisfying requires-python ">=4" found on $PATH`,
+		},
+		{
+			name: "malformed env",
+			err:  &MalformedEnvError{Key: "PY_PYTHON", Value: "3", Reason: "not X.Y format"},
+			want: `malformed PY_PYTHON "3": not X.Y format`,
+		},
+		{
+			name: "broken venv",
+			err:  &BrokenVenvError{Path: ".venv", Python: ".venv/bin/python"},
+			want: "virtual environment .venv is broken, .venv/bin/python does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("got %q, wanted %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve_typedErrors(t *testing.T) {
+	ctx := context.Background()
+	spec := interpreter.Spec{Major: 3, Minor: 4, Patch: interpreter.Any}
+
+	_, err := Resolve(ctx, Options{Path: fixture.PythonPath(".."), Spec: &spec})
+	var noMatch *NoMatchError
+	if !errors.As(err, &noMatch) {
+		t.Fatalf("expected a *NoMatchError, got %v", err)
+	}
+	if len(noMatch.Candidates) == 0 || noMatch.Spec.Minor != 4 {
+		t.Errorf("NoMatchError missing details: %#v", noMatch)
+	}
+	if errors.Is(err, ErrNoInterpreter) {
+		t.Error("a *NoMatchError should not match ErrNoInterpreter, there are interpreters")
+	}
+
+	if _, err := Resolve(ctx, Options{Path: t.TempDir(), Dir: t.TempDir()}); !errors.Is(err, ErrNoInterpreter) {
+		t.Errorf("expected ErrNoInterpreter, got %v", err)
+	}
+
+	_, err = Resolve(ctx, Options{Path: fixture.PythonPath(".."), Dir: t.TempDir(), PyPython: "3"})
+	var malformed *MalformedEnvError
+	if !errors.As(err, &malformed) || malformed.Key != EnvPyPython || malformed.Value != "3" {
+		t.Errorf("expected a *MalformedEnvError for PY_PYTHON, got %#v", err)
+	}
+
+	cwd := t.TempDir()
+	if err := os.Mkdir(filepath.Join(cwd, ".venv"), 0o755); err != nil {
+		t.Fatalf("could not create venv: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cwd, ".venv", pyvenvCfgFile), nil, 0o644); err != nil {
+		t.Fatalf("could not write pyvenv.cfg: %v", err)
+	}
+	_, err = Resolve(ctx, Options{Path: fixture.PythonPath(".."), Dir: cwd})
+	var broken *BrokenVenvError
+	if !errors.As(err, &broken) || broken.Path != filepath.Join(cwd, ".venv") {
+		t.Errorf("expected a *BrokenVenvError, got %#v", err)
+	}
+
+	_, err = Resolve(ctx, Options{Path: fixture.PythonPath(".."), Dir: t.TempDir(), VirtualEnv: filepath.Join(cwd, "gone")})
+	if !errors.As(err, &broken) || broken.Path != filepath.Join(cwd, "gone") {
+		t.Errorf("expected a *BrokenVenvError for $VIRTUAL_ENV, got %v", err)
+	}
+}
+
+func TestNoMatchError_InstallCommands(t *testing.T) {
+	tests := []struct {
+		name       string
+		installers []string
+		want       []string
+		spec       interpreter.Spec
+	}{
+		{
+			name:       "no installers",
+			spec:       interpreter.Spec{Major: 3, Minor: 9, Patch: interpreter.Any},
+			installers: nil,
+			want:       nil,
+		},
+		{
+			name:       "uv and pyenv",
+			spec:       interpreter.Spec{Major: 3, Minor: 9, Patch: interpreter.Any, Bits: 64},
+			installers: []string{installerUV, installerPyenv},
+			want:       []string{"uv python install 3.9", "pyenv install 3.9"},
+		},
+		{
+			name:       "free threaded patch",
+			spec:       interpreter.Spec{ABIFlags: "t", Major: 3, Minor: 13, Patch: 1},
+			installers: []string{installerUV, installerPyenv},
+			want:       []string{"uv python install 3.13.1t", "pyenv install 3.13.1t"},
+		},
+		{
+			name:       "pypy only with uv",
+			spec:       interpreter.Spec{Implementation: interpreter.PyPy, Major: 3, Minor: 10, Patch: interpreter.Any},
+			installers: []string{installerUV, installerPyenv},
+			want:       []string{"uv python install pypy@3.10"},
+		},
+		{
+			name:       "any version",
+			spec:       interpreter.Spec{Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any},
+			installers: []string{installerUV},
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := &NoMatchError{Spec: tt.spec, Installers: tt.installers}
+			if got := err.InstallCommands(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, wanted %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package resolve
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// The keys used in the structured debug logs, every log line uses these so
+// the logs of a whole resolution can be searched and parsed consistently.
+const (
+	LogKeyStep           = "step"            // The Reason for the step of the control flow being tried e.g. "shebang"
+	LogKeyCommand        = "command"         // The command py was asked to run e.g. "--list"
+	LogKeyArgs           = "args"            // Arguments passed (or to be passed) to python
+	LogKeySpecifier      = "specifier"       // A version specifier e.g. "python3.12"
+	LogKeyInterpreter    = "interpreter"     // The path to a single python interpreter
+	LogKeyInterpreters   = "interpreters"    // The paths to several python interpreters
+	LogKeyEnv            = "env"             // The name of an environment variable e.g. "PY_PYTHON"
+	LogKeyValue          = "value"           // The value of an environment variable
+	LogKeyPath           = "path"            // The $PATH entries being searched
+	LogKeyCwd            = "cwd"             // The current working directory
+	LogKeyVenv           = "venv"            // The path to a virtual environment
+	LogKeyScript         = "script"          // The path to the script being run
+	LogKeyShebang        = "shebang"         // A script's shebang line
+	LogKeyFlags          = "flags"           // Interpreter flags found in a shebang
+	LogKeyVersion        = "version"         // A python version found somewhere e.g. in a shebang
+	LogKeyRequiresPython = "requires-python" // A PEP 440 requires-python constraint
+	LogKeyDependencies   = "dependencies"    // A script's dependencies
+	LogKeyError          = "error"           // An error that was handled rather than returned
+	LogKeyInstallers     = "installers"      // Tools found on $PATH that can install python e.g. "uv"
+)
+
+// discard is the logger used when Options.Logger is nil.
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// interpreterPaths returns the paths of 'interpreters' for logging.
+func interpreterPaths(interpreters []interpreter.Interpreter) []string {
+	paths := make([]string, 0, len(interpreters))
+	for _, python := range interpreters {
+		paths = append(paths, python.Path)
+	}
+	return paths
+}
@@ -0,0 +1,585 @@
+// Package resolve implements py's control flow for deciding which python interpreter
+// to use, so other programs can ask "which python would py use here?" without shelling out to py.
+//
+// Resolve follows the same steps as py does when launching python:
+//
+//  1. The version asked for explicitly (Options.Spec or Options.RequiresPython)
+//  2. An activated virtual environment (Options.VirtualEnv)
+//  3. A .venv or venv directory in Options.Dir
+//  4. The PEP 723 inline metadata of the script in Options.Args (if there is one)
+//  5. The shebang of the script in Options.Args (if there is one)
+//  6. The default version in Options.PyPython
+//  7. The latest version in Options.Path
+//
+// Nothing is read from the environment unless asked for with OptionsFromEnv, so callers
+// decide exactly what Resolve looks at.
+package resolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// The environment variables read by OptionsFromEnv.
+const (
+	EnvPath          = "PATH"              // The directories to search for interpreters
+	EnvVirtualEnv    = "VIRTUAL_ENV"       // The activated virtual environment
+	EnvPyPython      = "PY_PYTHON"         // The default X.Y python version
+	EnvPreRelease    = "PY_PRERELEASE"     // Whether pre-release pythons may be selected
+	EnvScriptVenv    = "PY_SCRIPT_VENV"    // Whether script dependencies get installed into a venv
+	EnvShebangPolicy = "PY_SHEBANG_POLICY" // What happens when a shebang's version is missing
+)
+
+// The values of Options.ShebangPolicy.
+const (
+	ShebangStrict  = "strict"  // Missing shebang version is an error, the default
+	ShebangWarn    = "warn"    // Missing shebang version warns and carries on down the control flow
+	ShebangNearest = "nearest" // Missing shebang version uses the nearest installed version instead
+)
+
+// Reason is the step of the control flow that chose an interpreter.
+type Reason string
+
+// The reasons an interpreter can be chosen, these are also the values logged
+// under LogKeyStep for the step being tried.
+const (
+	ReasonSpecifier      Reason = "specifier"       // Options.Spec asked for it
+	ReasonRequiresPython Reason = "requires-python" // Options.RequiresPython asked for it
+	ReasonVirtualEnv     Reason = "virtual-env"     // It's the python of the activated virtual environment
+	ReasonCwdVenv        Reason = "cwd-venv"        // It's the python of the .venv or venv in Options.Dir
+	ReasonScriptMetadata Reason = "script-metadata" // The script's PEP 723 inline metadata asked for it
+	ReasonShebang        Reason = "shebang"         // The script's shebang asked for it
+	ReasonPyPython       Reason = "py-python"       // Options.PyPython asked for it
+	ReasonLatest         Reason = "latest"          // It's the latest python on Options.Path
+)
+
+// xYParts is the number of parts in an X.Y version.
+const xYParts = 2
+
+// Options configures what Resolve looks at.
+type Options struct {
+	Logger         *slog.Logger      // Where to write debug logs, nil discards them
+	Spec           *interpreter.Spec // The version asked for explicitly e.g. by py -3.12, nil follows the control flow
+	RequiresPython string            // A PEP 440 constraint asked for explicitly e.g. from pyproject.toml, ignored if Spec is set
+	Dir            string            // Where to look for virtual environments and relative scripts, defaults to the working directory
+	Path           string            // The directories to search for interpreters, formatted like $PATH
+	VirtualEnv     string            // The activated virtual environment, like $VIRTUAL_ENV
+	PyPython       string            // The default X.Y version, like $PY_PYTHON
+	ShebangPolicy  string            // What to do when a shebang's version is missing, one of the Shebang constants
+	Args           []string          // The arguments python would be launched with, used to find a script to look at
+	PreRelease     bool              // Whether pre-release (alpha, beta, rc) pythons may be selected as the latest
+	FS             interpreter.FS    // The filesystem to look at, nil means the real one
+
+	// ScriptVenv is whether the caller will install a script's inline metadata dependencies
+	// into a virtual environment, in which case dependencies alone select a python
+	// and they're returned in Result.Dependencies
+	ScriptVenv bool
+}
+
+// OptionsFromEnv returns the Options py itself would use, read from the environment.
+func OptionsFromEnv() Options {
+	// Anything not truthy means no
+	preRelease, _ := strconv.ParseBool(os.Getenv(EnvPreRelease)) //nolint: errcheck
+	scriptVenv, _ := strconv.ParseBool(os.Getenv(EnvScriptVenv)) //nolint: errcheck
+
+	return Options{
+		Path:          os.Getenv(EnvPath),
+		VirtualEnv:    os.Getenv(EnvVirtualEnv),
+		PyPython:      os.Getenv(EnvPyPython),
+		ShebangPolicy: os.Getenv(EnvShebangPolicy),
+		PreRelease:    preRelease,
+		ScriptVenv:    scriptVenv,
+	}
+}
+
+// Result is the interpreter Resolve chose and why.
+type Result struct {
+	// Interpreter is the python that was chosen. For a virtual environment only it's
+	// Path is known (and Patch is interpreter.Unknown), it's version is whatever the
+	// environment was created with
+	Interpreter interpreter.Interpreter
+
+	Reason       Reason   // The step of the control flow that chose it
+	Flags        []string // Interpreter flags from the script's shebang, to go before the arguments
+	Dependencies []string // The script's inline metadata dependencies, only set if Options.ScriptVenv is
+	Trace        []Step   // Every decision made on the way to the interpreter, in order
+}
+
+// Step is a single decision made by Resolve.
+type Step struct {
+	Reason  Reason // The step of the control flow that made the decision
+	Message string // What was decided e.g. "$VIRTUAL_ENV is not set"
+	Warning bool   // Whether it's worth telling the user about, even if they didn't ask how python was found
+}
+
+// Resolve follows py's control flow and returns the python interpreter it would use.
+//
+// If an error is returned, Result.Trace still holds the decisions made up to that point.
+func Resolve(ctx context.Context, opts Options) (Result, error) {
+	r, err := newResolver(opts)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result, err := r.resolve(ctx)
+	result.Trace = r.trace
+	if err != nil {
+		return Result{Trace: r.trace}, err
+	}
+
+	return result, nil
+}
+
+// Find returns every python interpreter on Options.Path, sorted latest first. If Options.Spec
+// is set only those matching it are returned, with it's patch version probed if it asks for one.
+//
+// ErrNoInterpreter is returned if there are no interpreters, and a *NoMatchError if none match.
+func Find(ctx context.Context, opts Options) ([]interpreter.Interpreter, error) {
+	r, err := newResolver(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	interpreters, err := r.interpreters()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(interpreters) == 0 {
+		return nil, ErrNoInterpreter
+	}
+
+	if spec := opts.Spec; spec != nil {
+		if spec.Patch != interpreter.Any {
+			r.probePatchVersions(ctx, interpreters, *spec)
+		}
+
+		var matching []interpreter.Interpreter
+		for _, python := range interpreters {
+			if spec.Matches(python) {
+				matching = append(matching, python)
+			}
+		}
+		if len(matching) == 0 {
+			return nil, r.noMatch(*spec, interpreters)
+		}
+		interpreters = matching
+	}
+
+	// Ensure interpreters are sorted latest to oldest regardless of
+	// any filepath based sorting from ReadDir
+	interpreter.Sort(interpreters)
+	r.logger.Debug("Found python interpreters", LogKeyInterpreters, interpreterPaths(interpreters))
+
+	return interpreters, nil
+}
+
+// ParsePyPython parses 'version', the value of $PY_PYTHON, returning the integer major
+// and minor version parts.
+//
+// A valid value for PY_PYTHON is X.Y, the same as the exact version specifier
+// e.g. "3.10". If 'version' is not a valid format, a *MalformedEnvError is returned.
+func ParsePyPython(version string) (int, int, error) {
+	parts := strings.Split(version, ".")
+
+	if len(parts) != xYParts {
+		return 0, 0, &MalformedEnvError{Key: EnvPyPython, Value: version, Reason: "not X.Y format"}
+	}
+
+	major, minor := parts[0], parts[1]
+
+	majorInt, err := strconv.Atoi(major)
+	if err != nil {
+		return 0, 0, &MalformedEnvError{Key: EnvPyPython, Value: version, Reason: "major component not an integer"}
+	}
+
+	minorInt, err := strconv.Atoi(minor)
+	if err != nil {
+		return 0, 0, &MalformedEnvError{Key: EnvPyPython, Value: version, Reason: "minor component not an integer"}
+	}
+
+	// Now we're safe
+	return majorInt, minorInt, nil
+}
+
+// resolver holds the state of a single resolution.
+type resolver struct {
+	logger *slog.Logger // Where debug logs go, never nil
+	trace  []Step       // The decisions made so far
+	opts   Options      // What to resolve, with the defaults filled in
+}
+
+// newResolver returns a resolver for 'opts', filling in any defaults.
+func newResolver(opts Options) (*resolver, error) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = discard
+	}
+
+	if opts.Dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("error getting cwd: %w", err)
+		}
+		opts.Dir = cwd
+	}
+
+	if opts.ShebangPolicy == "" {
+		opts.ShebangPolicy = ShebangStrict
+	}
+
+	if opts.FS == nil {
+		opts.FS = interpreter.OS
+	}
+
+	return &resolver{logger: logger, opts: opts}, nil
+}
+
+// explain records a decision made by the step 'reason' in the trace.
+func (r *resolver) explain(reason Reason, format string, args ...any) {
+	r.trace = append(r.trace, Step{Reason: reason, Message: fmt.Sprintf(format, args...)})
+}
+
+// warn records a decision made by the step 'reason' that the user should be warned about.
+func (r *resolver) warn(reason Reason, format string, args ...any) {
+	r.trace = append(r.trace, Step{Reason: reason, Message: fmt.Sprintf(format, args...), Warning: true})
+}
+
+// resolve chooses the interpreter with choose, then adds any interpreter flags from the script's
+// shebang, which apply to whichever python is chosen (a virtual environment's included).
+func (r *resolver) resolve(ctx context.Context) (Result, error) {
+	result, err := r.choose(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result.Flags = r.shebangFlags()
+	return result, nil
+}
+
+// choose follows the control flow, returning on the first step that finds an interpreter
+// thus preventing later steps from evaluating. This ensures our order of priority is followed.
+func (r *resolver) choose(ctx context.Context) (Result, error) {
+	// 1) Explicitly asked for version
+	if r.opts.Spec != nil {
+		python, err := r.latestMatching(ctx, *r.opts.Spec)
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Interpreter: python, Reason: ReasonSpecifier}, nil
+	}
+
+	if r.opts.RequiresPython != "" {
+		python, err := r.requiresPython(ctx, r.opts.RequiresPython)
+		if err != nil {
+			return Result{}, err
+		}
+		r.explain(ReasonRequiresPython, "Latest python on $PATH satisfying %q is %s", r.opts.RequiresPython, python.Path)
+		return Result{Interpreter: python, Reason: ReasonRequiresPython}, nil
+	}
+
+	// 2) Activated virtual environment, as marked by the presence of
+	// an environment variable $VIRTUAL_ENV pointing to the directory
+	// e.g. /Users/you/Projects/thisproject/.venv
+	r.logger.Debug("Looking for environment variable", LogKeyStep, ReasonVirtualEnv, LogKeyEnv, EnvVirtualEnv)
+	if path := r.opts.VirtualEnv; path != "" {
+		r.logger.Debug("Found environment variable", LogKeyStep, ReasonVirtualEnv, LogKeyEnv, EnvVirtualEnv, LogKeyValue, path)
+		exe := filepath.Join(path, "bin", "python")
+		if !r.exists(exe) {
+			return Result{}, &BrokenVenvError{Path: path, Python: exe}
+		}
+		r.explain(ReasonVirtualEnv, "$VIRTUAL_ENV is set to %s, using it's python", path)
+		return Result{Interpreter: venvInterpreter(exe), Reason: ReasonVirtualEnv}, nil
+	}
+	r.explain(ReasonVirtualEnv, "$VIRTUAL_ENV is not set")
+
+	// 3) Directory called .venv or venv in Dir
+	r.logger.Debug("Looking for virtual environment in cwd", LogKeyStep, ReasonCwdVenv, LogKeyCwd, r.opts.Dir)
+	exe, err := r.venvPython(r.opts.Dir)
+	if err != nil {
+		return Result{}, err
+	}
+	if exe != "" {
+		r.explain(ReasonCwdVenv, "Found a virtual environment in %s", r.opts.Dir)
+		return Result{Interpreter: venvInterpreter(exe), Reason: ReasonCwdVenv}, nil
+	}
+	r.explain(ReasonCwdVenv, "No .venv or venv directory in %s", r.opts.Dir)
+
+	if script, ok := r.script(); ok {
+		// 4) Look for inline script metadata specifying requires-python
+		result, ok, err := r.scriptMetadataPython(ctx, script)
+		if err != nil {
+			return Result{}, err
+		}
+		if ok {
+			return result, nil
+		}
+
+		// 5) Look for a python shebang line
+		exe, err := r.shebangPython(ctx, script)
+		if err != nil {
+			return Result{}, err
+		}
+		if exe.Path != "" {
+			return Result{Interpreter: exe, Reason: ReasonShebang}, nil
+		}
+		// Note: we don't return here as we want to carry on the control flow
+	} else {
+		r.explain(ReasonScriptMetadata, "No script to look at for inline metadata or a shebang")
+	}
+
+	// 6) PY_PYTHON specifying a X.Y version identifier e.g. 3.10
+	r.logger.Debug("Looking for environment variable", LogKeyStep, ReasonPyPython, LogKeyEnv, EnvPyPython)
+	if version := r.opts.PyPython; version != "" {
+		r.logger.Debug("Found environment variable", LogKeyStep, ReasonPyPython, LogKeyEnv, EnvPyPython, LogKeyValue, version)
+		major, minor, err := ParsePyPython(version)
+		if err != nil {
+			return Result{}, err
+		}
+		r.explain(ReasonPyPython, "$PY_PYTHON asks for %s", version)
+		python, err := r.latestMatching(ctx, interpreter.Spec{Major: major, Minor: minor, Patch: interpreter.Any})
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Interpreter: python, Reason: ReasonPyPython}, nil
+	}
+	r.explain(ReasonPyPython, "$PY_PYTHON is not set")
+
+	// 7) Latest on $PATH
+	r.logger.Debug("Falling back to latest python on $PATH", LogKeyStep, ReasonLatest)
+	python, err := r.latestMatching(ctx, latestSpec)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Interpreter: python, Reason: ReasonLatest}, nil
+}
+
+// latestSpec matches any standard python, special builds (free-threaded, debug) must
+// be asked for explicitly so they're never picked as the latest, and CPython
+// is preferred over any other implementations.
+var latestSpec = interpreter.Spec{Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any}
+
+// latestMatching searches through the path and returns the latest python interpreter
+// satisfying every constraint in 'spec'.
+func (r *resolver) latestMatching(ctx context.Context, spec interpreter.Spec) (interpreter.Interpreter, error) {
+	r.logger.Debug("Searching for python matching version specifier", LogKeySpecifier, spec.Executable())
+	interpreters, err := r.interpreters()
+	if err != nil {
+		return interpreter.Interpreter{}, err
+	}
+
+	if len(interpreters) == 0 {
+		return interpreter.Interpreter{}, ErrNoInterpreter
+	}
+
+	supportingInterpreters := r.matching(ctx, spec, interpreters)
+
+	// Handle the case where none are found
+	if len(supportingInterpreters) == 0 {
+		return interpreter.Interpreter{}, r.noMatch(spec, interpreters)
+	}
+
+	latest := supportingInterpreters[0]
+	r.logger.Debug("Found matching interpreters", LogKeyInterpreters, interpreterPaths(supportingInterpreters))
+	if spec == latestSpec {
+		r.explain(ReasonLatest, "Latest python on $PATH is %s", latest.Path)
+	} else {
+		r.explain(ReasonSpecifier, "Latest python on $PATH matching %s is %s", spec, latest.Path)
+	}
+
+	return latest, nil
+}
+
+// matching returns the interpreters from 'interpreters' that satisfy every constraint
+// in 'spec', sorted latest first. Interpreters are probed for their patch version if
+// the spec asks for one and pre-releases are dropped unless allowed.
+//
+// Pre-releases are dropped before CPython is preferred, so a CPython pre-release doesn't
+// hide a final release of another implementation.
+func (r *resolver) matching(ctx context.Context, spec interpreter.Spec, interpreters []interpreter.Interpreter) []interpreter.Interpreter {
+	if spec.Patch != interpreter.Any {
+		r.probePatchVersions(ctx, interpreters, spec)
+	}
+
+	supportingInterpreters := spec.Filter(r.withoutPreReleases(spec, satisfying(spec, interpreters)))
+	interpreter.Sort(supportingInterpreters)
+
+	return supportingInterpreters
+}
+
+// satisfying returns the interpreters from 'interpreters' that satisfy every constraint in
+// 'spec'. Unlike spec.Filter it doesn't prefer CPython, so anything else that rules
+// interpreters out can be done first.
+func satisfying(spec interpreter.Spec, interpreters []interpreter.Interpreter) []interpreter.Interpreter {
+	var matching []interpreter.Interpreter
+	for _, python := range interpreters {
+		if spec.Matches(python) {
+			matching = append(matching, python)
+		}
+	}
+	return matching
+}
+
+// withoutPreReleases removes any pre-release interpreters from 'interpreters' unless
+// the caller has opted in to them with Options.PreRelease.
+//
+// If 'spec' pins an exact version (e.g. 3.14) and only pre-releases satisfy it, they are kept
+// as the user has clearly asked for that version and there's nothing else it could mean.
+func (r *resolver) withoutPreReleases(spec interpreter.Spec, interpreters []interpreter.Interpreter) []interpreter.Interpreter {
+	if r.opts.PreRelease {
+		return interpreters
+	}
+
+	var finals []interpreter.Interpreter
+	for _, python := range interpreters {
+		if python.IsPreRelease() {
+			r.logger.Debug("Skipping pre-release interpreter", LogKeyInterpreter, python.Path)
+			continue
+		}
+		finals = append(finals, python)
+	}
+
+	if len(finals) == 0 && spec.Minor != interpreter.Any {
+		r.logger.Debug("Only pre-releases satisfy specifier, using them", LogKeySpecifier, spec.Executable())
+		return interpreters
+	}
+
+	return finals
+}
+
+// probePatchVersions probes any interpreters in 'interpreters' that match the
+// major and minor version of 'spec' but whose patch version could not be determined
+// from their filepath, updating them in place.
+//
+// Interpreters that fail to probe are logged and left alone, they simply
+// won't satisfy the patch version.
+func (r *resolver) probePatchVersions(ctx context.Context, interpreters []interpreter.Interpreter, spec interpreter.Spec) {
+	for i, python := range interpreters {
+		if python.Patch != interpreter.Unknown || !python.SatisfiesExact(spec.Major, spec.Minor) {
+			continue
+		}
+		r.probe(ctx, &interpreters[i])
+	}
+}
+
+// probe runs 'python' to find it's patch version, logging rather than returning any error.
+func (r *resolver) probe(ctx context.Context, python *interpreter.Interpreter) {
+	r.logger.Debug("Probing interpreter for patch version", LogKeyInterpreter, python.Path)
+	if err := python.Probe(ctx); err != nil {
+		r.logger.Debug("Could not probe interpreter", LogKeyError, err)
+	}
+}
+
+// interpreters searches through the path and returns a list of all python interpreters.
+func (r *resolver) interpreters() ([]interpreter.Interpreter, error) {
+	paths := r.pathEntries()
+
+	r.logger.Debug("Looking through $PATH for python interpreters", LogKeyPath, paths)
+	interpreters, err := interpreter.GetAllFS(r.opts.FS, r.logger, paths)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching python interpreters: %w", err)
+	}
+
+	return interpreters, nil
+}
+
+// pathEntries goes through Options.Path (which it expects to be $PATH or similar)
+// i.e. separated list of directories, and returns a string slice of the
+// entries in that path.
+//
+// Entries will be de-duplicated prior to returning.
+func (r *resolver) pathEntries() []string {
+	paths := []string{}
+
+	for _, dir := range filepath.SplitList(r.opts.Path) {
+		if dir == "" {
+			// Unix shell semantics: path element "" means "."
+			dir = "."
+		}
+		// Some new thing on macOS that breaks us, there's a new directory
+		// called /var/run/com.apple.security.cryptexd/codex.system/bootstrap/usr/local/bin
+		// which cannot be read by the user, so we'll just skip it.
+		if strings.HasPrefix(dir, "/var/run") {
+			continue
+		}
+		paths = append(paths, dir)
+	}
+
+	// Dedupe
+	paths = deDupe(paths)
+
+	return paths
+}
+
+// venvPython will look for a ".venv/bin/python" or a "venv/bin/python"
+// under 'dir', ensure that it exists and then return it's absolute path
+// .venv will be preferred over venv, venv will only be used if .venv
+// does not exist.
+//
+// If neither is found, an empty string will be returned. If one is a virtual environment
+// (it has a pyvenv.cfg) but it's python is missing, a *BrokenVenvError is returned.
+func (r *resolver) venvPython(dir string) (string, error) {
+	for _, name := range [...]string{".venv", "venv"} {
+		venv := filepath.Join(dir, name)
+		python := filepath.Join(venv, "bin", "python")
+		if r.exists(python) {
+			r.logger.Debug("Found a virtual environment", LogKeyStep, ReasonCwdVenv, LogKeyVenv, venv)
+			return python, nil
+		}
+
+		if r.exists(filepath.Join(venv, pyvenvCfgFile)) {
+			return "", &BrokenVenvError{Path: venv, Python: python}
+		}
+	}
+
+	return "", nil
+}
+
+// venvInterpreter returns the Interpreter for a virtual environment's python at 'exe',
+// of which only the path is known.
+func venvInterpreter(exe string) interpreter.Interpreter {
+	return interpreter.Interpreter{Path: exe, Patch: interpreter.Unknown}
+}
+
+// pyvenvCfgFile is present in the root of every virtual environment.
+const pyvenvCfgFile = "pyvenv.cfg"
+
+// exists returns true if 'path' exists on Options.FS, else false.
+func (r *resolver) exists(path string) bool {
+	if _, err := r.opts.FS.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return false
+	}
+	return true
+}
+
+// isFile returns true if 'path' is a regular file on Options.FS (following symlinks), else false.
+func (r *resolver) isFile(path string) bool {
+	info, err := r.opts.FS.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// deDupe takes in a list of paths (e.g. those returned from pathEntries)
+// and returns a de-duplicated list
+// it is not that common to have a duplicated $PATH entry but it could happen
+// so let's handle it here.
+func deDupe(paths []string) []string {
+	keys := make(map[string]bool)
+	deDuped := []string{}
+	for _, item := range paths {
+		if _, ok := keys[item]; !ok {
+			keys[item] = true
+			deDuped = append(deDuped, item)
+		}
+	}
+
+	return deDuped
+}
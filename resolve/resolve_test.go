@@ -0,0 +1,779 @@
+package resolve //nolint: testpackage // Need access to internals
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/FollowTheProcess/py/internal/fixture"
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+func Test_pathEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "normal path",
+			path: "/usr/bin:/usr/local/bin:/usr/local/somewhere",
+			want: []string{"/usr/bin", "/usr/local/bin", "/usr/local/somewhere"},
+		},
+		{
+			name: "empty",
+			path: "",
+			want: []string{},
+		},
+		{
+			name: "duplicate entries",
+			path: "/usr/bin:/usr/local/bin:/usr/bin:/usr/somewhere:/usr/local/bin",
+			want: []string{"/usr/bin", "/usr/local/bin", "/usr/somewhere"},
+		},
+		{
+			name: "empty entry should be replaced with .",
+			path: "/usr/bin:/usr/local/bin::/usr/somewhere:",
+			want: []string{"/usr/bin", "/usr/local/bin", ".", "/usr/somewhere"},
+		},
+		{
+			name: "multiple empty entries should be one .",
+			path: "/usr/bin::/usr/local/bin::/usr/somewhere:",
+			want: []string{"/usr/bin", ".", "/usr/local/bin", "/usr/somewhere"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestResolver(t, Options{Path: tt.path})
+
+			got := r.pathEntries()
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePyPython(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   string
+		wantMajor int
+		wantMinor int
+		wantErr   bool
+	}{
+		{
+			name:      "valid 3.10",
+			version:   "3.10",
+			wantMajor: 3,
+			wantMinor: 10,
+			wantErr:   false,
+		},
+		{
+			name:      "valid 3.9",
+			version:   "3.9",
+			wantMajor: 3,
+			wantMinor: 9,
+			wantErr:   false,
+		},
+		{
+			name:      "valid 4.0",
+			version:   "4.0",
+			wantMajor: 4,
+			wantMinor: 0,
+			wantErr:   false,
+		},
+		{
+			name:      "no major",
+			version:   ".9",
+			wantMajor: 0,
+			wantMinor: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "no minor",
+			version:   "3.",
+			wantMajor: 0,
+			wantMinor: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "no dot",
+			version:   "39",
+			wantMajor: 0,
+			wantMinor: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "only one number",
+			version:   "3",
+			wantMajor: 0,
+			wantMinor: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "empty string",
+			version:   "",
+			wantMajor: 0,
+			wantMinor: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "whitespace before",
+			version:   " 3.9",
+			wantMajor: 0,
+			wantMinor: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "whitespace after",
+			version:   "3.9 ",
+			wantMajor: 0,
+			wantMinor: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "major not an int",
+			version:   "X.9",
+			wantMajor: 0,
+			wantMinor: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "minor not an int",
+			version:   "3.X",
+			wantMajor: 0,
+			wantMinor: 0,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMajor, gotMinor, err := ParsePyPython(tt.version)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePyPython() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if gotMajor != tt.wantMajor {
+				t.Errorf("wrong major version. got %d, wanted %d", gotMajor, tt.wantMajor)
+			}
+
+			if gotMinor != tt.wantMinor {
+				t.Errorf("wrong minor version. got %d, wanted %d", gotMinor, tt.wantMinor)
+			}
+		})
+	}
+}
+
+func Test_withoutPreReleases(t *testing.T) {
+	final := interpreter.Interpreter{Major: 3, Minor: 13, Patch: 1}
+	alpha := interpreter.Interpreter{Major: 3, Minor: 14, Patch: 0, PreRelease: "a3"}
+	latest := interpreter.Spec{Major: interpreter.Any, Minor: interpreter.Any, Patch: interpreter.Any}
+
+	tests := []struct {
+		name         string
+		spec         interpreter.Spec
+		interpreters []interpreter.Interpreter
+		want         []interpreter.Interpreter
+		preRelease   bool
+	}{
+		{
+			name:         "pre-releases excluded by default",
+			spec:         latest,
+			interpreters: []interpreter.Interpreter{alpha, final},
+			want:         []interpreter.Interpreter{final},
+		},
+		{
+			name:         "pre-releases allowed",
+			spec:         latest,
+			interpreters: []interpreter.Interpreter{alpha, final},
+			want:         []interpreter.Interpreter{alpha, final},
+			preRelease:   true,
+		},
+		{
+			name:         "only pre-releases for major",
+			spec:         interpreter.Spec{Major: 3, Minor: interpreter.Any, Patch: interpreter.Any},
+			interpreters: []interpreter.Interpreter{alpha},
+			want:         nil,
+		},
+		{
+			name:         "only pre-releases for exact version",
+			spec:         interpreter.Spec{Major: 3, Minor: 14, Patch: interpreter.Any},
+			interpreters: []interpreter.Interpreter{alpha},
+			want:         []interpreter.Interpreter{alpha},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestResolver(t, Options{PreRelease: tt.preRelease})
+
+			if got := r.withoutPreReleases(tt.spec, tt.interpreters); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_deDupe(t *testing.T) {
+	type args struct {
+		paths []string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "only 1 path",
+			args: args{paths: []string{"its/just/me/here"}},
+			want: []string{"its/just/me/here"},
+		},
+		{
+			name: "3 different paths",
+			args: args{paths: []string{"a/path", "another/path", "athird/path"}},
+			want: []string{"a/path", "another/path", "athird/path"},
+		},
+		{
+			name: "1 unique, 2 duplicates",
+			args: args{paths: []string{"a/path", "a/path", "aunique/path"}},
+			want: []string{"a/path", "aunique/path"},
+		},
+		{
+			name: "all duplicates",
+			args: args{paths: []string{"a/path", "a/path", "a/path"}},
+			want: []string{"a/path"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deDupe(tt.args.paths); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("deDupe() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Not really necessary but I was just curious and it was easy to do.
+func Benchmark_deDupe(b *testing.B) {
+	// Some paths that contain duplicates
+	paths := []string{
+		"/usr/local/bin/python3.7",
+		"/usr/local/bin/python3.8",
+		"/usr/local/bin/python3.9",
+		"/usr/local/bin/python3.6",
+		"/usr/local/bin/python3.10",
+		"/usr/local/bin/python3.11",
+		"/usr/bin/python3.7",
+		"/usr/bin/python3.8",
+		"/usr/bin/python2.7",
+		"/usr/bin/python",
+		"/usr/bin/python2",
+		"/usr/bin/python3",
+		"/usr/bin/python2",
+		"/usr/bin/python",
+		"/usr/bin/python",
+		"/usr/bin/python",
+		"/usr/bin/python3",
+		"/usr/local/bin/python3.11",
+		"/usr/local/bin/python3.9",
+		"/usr/local/bin/python3.9",
+		"/usr/local/bin/python3.6",
+	}
+
+	// Reset prior to actually running the benchmark
+	// ensures we don't include the initialisation stuff
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		deDupe(paths)
+	}
+}
+
+// newTestResolver returns a resolver for 'opts', defaulting Dir to an empty temporary directory
+// so the tests never find a virtual environment by accident.
+func newTestResolver(t *testing.T, opts Options) *resolver {
+	t.Helper()
+	if opts.Dir == "" {
+		opts.Dir = t.TempDir()
+	}
+
+	r, err := newResolver(opts)
+	if err != nil {
+		t.Fatalf("newResolver() returned an unexpected error: %v", err)
+	}
+	return r
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string   // Contents of script.py in Dir, if not empty it's passed in Args
+		venv     string   // Name of a virtual environment to create in Dir, if any
+		want     string   // Base name of the python we want
+		wantDeps []string // Dependencies we want returned
+		opts     Options
+		flags    []string
+		reason   Reason
+		activate bool // Whether to activate venv with Options.VirtualEnv
+	}{
+		{
+			name:   "latest",
+			want:   "python3.10",
+			reason: ReasonLatest,
+		},
+		{
+			name:   "specifier",
+			opts:   Options{Spec: &interpreter.Spec{Major: 3, Minor: 8, Patch: interpreter.Any}},
+			want:   "python3.8",
+			reason: ReasonSpecifier,
+		},
+		{
+			name:   "specifier ignores venv",
+			venv:   ".venv",
+			opts:   Options{Spec: &interpreter.Spec{Implementation: interpreter.PyPy, Major: 3, Minor: interpreter.Any, Patch: interpreter.Any}},
+			want:   "pypy3.10",
+			reason: ReasonSpecifier,
+		},
+		{
+			name:   "requires-python",
+			opts:   Options{RequiresPython: "<3.9"},
+			want:   "python3.8",
+			reason: ReasonRequiresPython,
+		},
+		{
+			name:     "activated virtual environment",
+			venv:     "env",
+			activate: true,
+			want:     "python",
+			reason:   ReasonVirtualEnv,
+		},
+		{
+			name:   "virtual environment in dir",
+			venv:   "venv",
+			want:   "python",
+			reason: ReasonCwdVenv,
+		},
+		{
+			name:   "py python",
+			opts:   Options{PyPython: "3.9"},
+			want:   "python3.9",
+			reason: ReasonPyPython,
+		},
+		{
+			name:   "shebang",
+			script: "#!/usr/bin/python3.7 -u\n",
+			want:   "python3.7",
+			flags:  []string{"-u"},
+			reason: ReasonShebang,
+		},
+		{
+			name:   "shebang flags carry on",
+			script: "#!/usr/bin/env python -O\n",
+			opts:   Options{PyPython: "3.9"},
+			want:   "python3.9",
+			flags:  []string{"-O"},
+			reason: ReasonPyPython,
+		},
+		{
+			name:   "shebang flags in a virtual environment",
+			script: "#!/usr/bin/env -S python3 -u\n",
+			venv:   ".venv",
+			want:   "python",
+			flags:  []string{"-u"},
+			reason: ReasonCwdVenv,
+		},
+		{
+			name:   "shebang flags with script metadata",
+			script: "#!/usr/bin/env -S python3 -u\n# /// script\n# requires-python = \"<3.8\"\n# ///\n",
+			want:   "python3.7",
+			flags:  []string{"-u"},
+			reason: ReasonScriptMetadata,
+		},
+		{
+			name:     "script metadata",
+			script:   "# /// script\n# requires-python = \"<3.8\"\n# dependencies = [\"rich\"]\n# ///\n",
+			opts:     Options{ScriptVenv: true},
+			want:     "python3.7",
+			wantDeps: []string{"rich"},
+			reason:   ReasonScriptMetadata,
+		},
+		{
+			name:   "script dependencies ignored without script venvs",
+			script: "#!/usr/bin/python3.8\n# /// script\n# dependencies = [\"rich\"]\n# ///\n",
+			want:   "python3.8",
+			reason: ReasonShebang,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := tt.opts
+			opts.Path = fixture.PythonPath("..")
+			opts.Dir = dir
+
+			if tt.script != "" {
+				if err := os.WriteFile(filepath.Join(dir, "script.py"), []byte(tt.script), 0o644); err != nil {
+					t.Fatalf("could not write script: %v", err)
+				}
+				opts.Args = []string{"script.py", "--verbose"}
+			}
+
+			if tt.venv != "" {
+				if err := os.MkdirAll(filepath.Join(dir, tt.venv, "bin"), 0o755); err != nil {
+					t.Fatalf("could not create venv: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, tt.venv, "bin", "python"), nil, 0o755); err != nil {
+					t.Fatalf("could not create venv python: %v", err)
+				}
+				if tt.activate {
+					opts.VirtualEnv = filepath.Join(dir, tt.venv)
+				}
+			}
+
+			got, err := Resolve(context.Background(), opts)
+			if err != nil {
+				t.Fatalf("Resolve() returned an unexpected error: %v", err)
+			}
+
+			if base := filepath.Base(got.Interpreter.Path); base != tt.want {
+				t.Errorf("got %s, wanted %s", base, tt.want)
+			}
+
+			if got.Reason != tt.reason {
+				t.Errorf("got reason %q, wanted %q", got.Reason, tt.reason)
+			}
+
+			// A virtual environment's version isn't known, it mustn't look like X.Y.0
+			isVenv := got.Reason == ReasonVirtualEnv || got.Reason == ReasonCwdVenv
+			if isVenv && got.Interpreter.Patch != interpreter.Unknown {
+				t.Errorf("got venv patch %d, wanted Unknown", got.Interpreter.Patch)
+			}
+
+			if !reflect.DeepEqual(got.Flags, tt.flags) {
+				t.Errorf("got flags %#v, wanted %#v", got.Flags, tt.flags)
+			}
+
+			if !reflect.DeepEqual(got.Dependencies, tt.wantDeps) {
+				t.Errorf("got dependencies %#v, wanted %#v", got.Dependencies, tt.wantDeps)
+			}
+
+			if len(got.Trace) == 0 {
+				t.Error("expected a trace of the decisions made")
+			}
+		})
+	}
+}
+
+func TestResolve_FS(t *testing.T) {
+	// The python in a virtual environment is usually a symlink to the one it was made with
+	fsys := fstest.MapFS{
+		"usr/bin/python3.9":                        {Data: []byte("python"), Mode: 0o755},
+		"usr/bin/python3.12":                       {Data: []byte("python"), Mode: 0o755},
+		"usr/bin/python3.13":                       {Data: []byte("leftover"), Mode: 0o644},
+		"usr/bin/python3.14/lib":                   {Mode: fs.ModeDir},
+		"usr/bin/python3":                          {Data: []byte("python3.12"), Mode: fs.ModeSymlink},
+		"opt/pypy/bin/pypy3.10":                    {Data: []byte("pypy"), Mode: 0o755},
+		"opt/old/bin/python3.9":                    {Data: []byte("python"), Mode: 0o755},
+		"opt/newpypy/bin/pypy3.11":                 {Data: []byte("pypy"), Mode: 0o755},
+		"opt/python/3.14.0a3/bin/python3.14":       {Data: []byte("python"), Mode: 0o755},
+		"home/me/.local/bin":                       {Data: []byte("/opt/pypy/bin"), Mode: fs.ModeSymlink},
+		"empty/bin":                                {Mode: fs.ModeDir},
+		"home/me/project/.venv/bin/python":         {Data: []byte("/usr/bin/python3.9"), Mode: fs.ModeSymlink},
+		"home/me/project/.venv/pyvenv.cfg":         {},
+		"home/me/project/venv/bin/python":          {Data: []byte("/usr/bin/python3.12"), Mode: fs.ModeSymlink},
+		"home/me/project/shebang.py":               {Data: []byte("#!/usr/bin/env pypy3.10 -u\n")},
+		"home/me/project/missing.py":               {Data: []byte("#!/usr/bin/python3.6\n")},
+		"home/me/other/venv/bin/python":            {Data: []byte("/usr/bin/python3.9"), Mode: fs.ModeSymlink},
+		"home/me/broken/.venv/pyvenv.cfg":          {},
+		"home/me/broken/.venv/bin/python":          {Data: []byte("/usr/bin/python3.8"), Mode: fs.ModeSymlink},
+		"home/me/broken/venv/bin/python":           {Data: []byte("/usr/bin/python3.9"), Mode: fs.ModeSymlink},
+		"home/me/scripts/metadata.py":              {Data: []byte("# /// script\n# requires-python = \"<3.10\"\n# ///\n")},
+		"home/me/scripts/no-version.py":            {Data: []byte("#!/usr/bin/python\n")},
+		"home/me/scripts/pkg/__main__.py":          {Data: []byte("#!/usr/bin/python3.9\n")},
+		"home/me/scripts/old.py":                   {Data: []byte("#!/usr/bin/python3.11\n")},
+		"home/me/.virtualenvs/gone/pyvenv.cfg":     {},
+		"home/me/.virtualenvs/working/bin/python3": {Data: []byte("/usr/bin/python3.12"), Mode: fs.ModeSymlink},
+		"home/me/.virtualenvs/working/bin/python":  {Data: []byte("python3"), Mode: fs.ModeSymlink},
+	}
+
+	isBroken := func(err error) bool {
+		var broken *BrokenVenvError
+		return errors.As(err, &broken)
+	}
+	isNoMatch := func(err error) bool {
+		var noMatch *NoMatchError
+		return errors.As(err, &noMatch)
+	}
+	isNoInterpreter := func(err error) bool {
+		return errors.Is(err, ErrNoInterpreter)
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		want    string // Path of the python we want
+		reason  Reason
+		flags   []string
+		wantErr func(err error) bool // Checks the error is the one we want, nil if we don't want one
+	}{
+		{
+			name:   "latest on path",
+			opts:   Options{Path: "/usr/bin", Dir: "/home/me/scripts"},
+			want:   "/usr/bin/python3.12",
+			reason: ReasonLatest,
+		},
+		{
+			name:   "symlinked path entry",
+			opts:   Options{Path: "/home/me/.local/bin", Dir: "/home/me/scripts"},
+			want:   "/home/me/.local/bin/pypy3.10",
+			reason: ReasonLatest,
+		},
+		{
+			name:    "no interpreters on path",
+			opts:    Options{Path: "/empty/bin", Dir: "/home/me/scripts"},
+			wantErr: isNoInterpreter,
+		},
+		{
+			name:   ".venv preferred over venv",
+			opts:   Options{Path: "/usr/bin", Dir: "/home/me/project"},
+			want:   "/home/me/project/.venv/bin/python",
+			reason: ReasonCwdVenv,
+		},
+		{
+			name:   "venv",
+			opts:   Options{Path: "/usr/bin", Dir: "/home/me/other"},
+			want:   "/home/me/other/venv/bin/python",
+			reason: ReasonCwdVenv,
+		},
+		{
+			name:    "venv with a dangling python",
+			opts:    Options{Path: "/usr/bin", Dir: "/home/me/broken"},
+			wantErr: isBroken,
+		},
+		{
+			name:   "activated venv through symlinks",
+			opts:   Options{Path: "/usr/bin", Dir: "/home/me/scripts", VirtualEnv: "/home/me/.virtualenvs/working"},
+			want:   "/home/me/.virtualenvs/working/bin/python",
+			reason: ReasonVirtualEnv,
+		},
+		{
+			name:    "activated venv without a python",
+			opts:    Options{Path: "/usr/bin", Dir: "/home/me/scripts", VirtualEnv: "/home/me/.virtualenvs/gone"},
+			wantErr: isBroken,
+		},
+		{
+			name:   "shebang",
+			opts:   Options{Path: "/usr/bin:/home/me/.local/bin", Dir: "/home/me/scripts", Args: []string{"../project/shebang.py"}},
+			want:   "/home/me/.local/bin/pypy3.10",
+			reason: ReasonShebang,
+			flags:  []string{"-u"},
+		},
+		{
+			name:    "shebang not installed",
+			opts:    Options{Path: "/usr/bin", Dir: "/home/me/scripts", Args: []string{"/home/me/project/missing.py"}},
+			wantErr: isNoMatch,
+		},
+		{
+			name:   "shebang without a version",
+			opts:   Options{Path: "/usr/bin", Dir: "/home/me/scripts", Args: []string{"no-version.py"}},
+			want:   "/usr/bin/python3.12",
+			reason: ReasonLatest,
+		},
+		{
+			name:   "script metadata",
+			opts:   Options{Path: "/usr/bin", Dir: "/home/me/scripts", Args: []string{"metadata.py"}},
+			want:   "/usr/bin/python3.9",
+			reason: ReasonScriptMetadata,
+		},
+		{
+			name:   "requires-python only another implementation satisfies",
+			opts:   Options{Path: "/opt/old/bin:/opt/newpypy/bin", Dir: "/home/me/scripts", RequiresPython: ">=3.10"},
+			want:   "/opt/newpypy/bin/pypy3.11",
+			reason: ReasonRequiresPython,
+		},
+		{
+			name:   "requires-python prefers cpython that satisfies it",
+			opts:   Options{Path: "/opt/old/bin:/opt/newpypy/bin", Dir: "/home/me/scripts", RequiresPython: ">=3.9"},
+			want:   "/opt/old/bin/python3.9",
+			reason: ReasonRequiresPython,
+		},
+		{
+			name:   "latest skips a cpython pre-release",
+			opts:   Options{Path: "/opt/python/3.14.0a3/bin:/opt/pypy/bin", Dir: "/home/me/scripts"},
+			want:   "/opt/pypy/bin/pypy3.10",
+			reason: ReasonLatest,
+		},
+		{
+			name:    "only a pre-release",
+			opts:    Options{Path: "/opt/python/3.14.0a3/bin", Dir: "/home/me/scripts"},
+			wantErr: isNoMatch,
+		},
+		{
+			name:   "nearest to shebang skips a cpython pre-release",
+			opts:   Options{Path: "/opt/python/3.14.0a3/bin:/opt/pypy/bin", Dir: "/home/me/scripts", Args: []string{"old.py"}, ShebangPolicy: ShebangNearest},
+			want:   "/opt/pypy/bin/pypy3.10",
+			reason: ReasonShebang,
+		},
+		{
+			name:   "package directory",
+			opts:   Options{Path: "/usr/bin", Dir: "/home/me/scripts", Args: []string{"pkg"}},
+			want:   "/usr/bin/python3.12",
+			reason: ReasonLatest,
+		},
+		{
+			name:   "script that doesn't exist",
+			opts:   Options{Path: "/usr/bin", Dir: "/home/me/scripts", Args: []string{"missing.py"}},
+			want:   "/usr/bin/python3.12",
+			reason: ReasonLatest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.FS = interpreter.FromFS(fsys)
+
+			got, err := Resolve(context.Background(), opts)
+			if tt.wantErr != nil {
+				if !tt.wantErr(err) {
+					t.Fatalf("Resolve() returned the wrong error: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Resolve() returned an unexpected error: %v", err)
+			}
+
+			if got.Interpreter.Path != tt.want {
+				t.Errorf("got %s, wanted %s", got.Interpreter.Path, tt.want)
+			}
+
+			if got.Reason != tt.reason {
+				t.Errorf("got reason %q, wanted %q", got.Reason, tt.reason)
+			}
+
+			// A virtual environment's version isn't known, it mustn't look like X.Y.0
+			isVenv := got.Reason == ReasonVirtualEnv || got.Reason == ReasonCwdVenv
+			if isVenv && got.Interpreter.Patch != interpreter.Unknown {
+				t.Errorf("got venv patch %d, wanted Unknown", got.Interpreter.Patch)
+			}
+
+			if !reflect.DeepEqual(got.Flags, tt.flags) {
+				t.Errorf("got flags %#v, wanted %#v", got.Flags, tt.flags)
+			}
+		})
+	}
+}
+
+func TestResolve_pathOrder(t *testing.T) {
+	// Two different python3.12s, whichever directory is first on $PATH should win like it would in a shell
+	fsys := interpreter.FromFS(fstest.MapFS{
+		"usr/bin/python3.12":       {Data: []byte("system python"), Mode: 0o755},
+		"usr/local/bin/python3.12": {Data: []byte("homebrew python"), Mode: 0o755},
+		"usr/local/bin/python3.11": {Data: []byte("homebrew python"), Mode: 0o755},
+		"opt/bin/python3.12":       {Data: []byte("another python"), Mode: 0o755},
+
+		// A versioned install knows it's patch version, so it's later than one that doesn't wherever it is on $PATH
+		"home/me/.pyenv/versions/3.12.1/bin/python3.12": {Data: []byte("pyenv python"), Mode: 0o755},
+	})
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "usr/bin first",
+			path: "/usr/bin:/usr/local/bin:/opt/bin",
+			want: "/usr/bin/python3.12",
+		},
+		{
+			name: "usr/local/bin first",
+			path: "/usr/local/bin:/usr/bin:/opt/bin",
+			want: "/usr/local/bin/python3.12",
+		},
+		{
+			name: "opt/bin first",
+			path: "/opt/bin:/usr/local/bin:/usr/bin",
+			want: "/opt/bin/python3.12",
+		},
+		{
+			name: "known patch later on path",
+			path: "/usr/local/bin:/home/me/.pyenv/versions/3.12.1/bin",
+			want: "/home/me/.pyenv/versions/3.12.1/bin/python3.12",
+		},
+		{
+			name: "known patch earlier on path",
+			path: "/home/me/.pyenv/versions/3.12.1/bin:/usr/local/bin",
+			want: "/home/me/.pyenv/versions/3.12.1/bin/python3.12",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{FS: fsys, Path: tt.path, Dir: "/"}
+			got, err := Resolve(context.Background(), opts)
+			if err != nil {
+				t.Fatalf("Resolve() returned an unexpected error: %v", err)
+			}
+
+			if got.Interpreter.Path != tt.want {
+				t.Fatalf("got %s, wanted %s", got.Interpreter.Path, tt.want)
+			}
+
+			found, err := Find(context.Background(), Options{FS: fsys, Path: tt.path, Dir: "/", Spec: &interpreter.Spec{Major: 3, Minor: 12, Patch: interpreter.Any}})
+			if err != nil {
+				t.Fatalf("Find() returned an unexpected error: %v", err)
+			}
+
+			if found[0].Path != tt.want {
+				t.Fatalf("Find() got %s first, wanted %s", found[0].Path, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	ctx := context.Background()
+
+	all, err := Find(ctx, Options{Path: fixture.PythonPath("..")})
+	if err != nil {
+		t.Fatalf("Find() returned an unexpected error: %v", err)
+	}
+
+	// python2.7 is ignored
+	if len(all) != 7 {
+		t.Errorf("expected 7 interpreters, got %d: %v", len(all), all)
+	}
+
+	spec := interpreter.Spec{Major: 3, Minor: 10, Patch: interpreter.Any}
+	matching, err := Find(ctx, Options{Path: fixture.PythonPath(".."), Spec: &spec})
+	if err != nil {
+		t.Fatalf("Find() returned an unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, python := range matching {
+		got = append(got, filepath.Base(python.Path))
+	}
+	if want := []string{"python3.10", "pypy3.10"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, wanted %v", got, want)
+	}
+
+	if _, err := Find(ctx, Options{Path: t.TempDir()}); !errors.Is(err, ErrNoInterpreter) {
+		t.Errorf("expected ErrNoInterpreter, got %v", err)
+	}
+}
@@ -0,0 +1,269 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/FollowTheProcess/py/internal/toml"
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+const (
+	scriptBlockType   = "script"          // The PEP 723 block type we care about
+	requiresPythonKey = "requires-python" // The PEP 723 key holding the python version constraint
+	dependenciesKey   = "dependencies"    // The PEP 723 key holding the list of dependencies
+)
+
+// scriptBlockRegex is the reference regex from PEP 723 for finding metadata blocks.
+var scriptBlockRegex = regexp.MustCompile(`(?m)^# /// (?P<type>[a-zA-Z0-9-]+)$\s(?P<content>(^#(| .*)$\s)+)^# ///$`)
+
+// scriptMetadata is the PEP 723 inline metadata of a python script.
+type scriptMetadata struct {
+	RequiresPython string   // The requires-python version constraint e.g. ">=3.11"
+	Dependencies   []string // The dependencies the script needs installed e.g. "requests<3"
+}
+
+// script returns the path to the script python would run with Options.Args, relative
+// scripts being relative to Options.Dir. It returns false if there isn't one, or it isn't a
+// regular file e.g. a package directory with a __main__.py, which python can also run.
+func (r *resolver) script() (string, bool) {
+	i, ok := findScript(r.opts.Args)
+	if !ok {
+		return "", false
+	}
+
+	script := r.opts.Args[i]
+	if !filepath.IsAbs(script) {
+		script = filepath.Join(r.opts.Dir, script)
+	}
+
+	return script, r.isFile(script)
+}
+
+// findScript looks through the arguments destined for python and returns the
+// index of the script python would run, skipping over any python options e.g.
+// in "-u -X dev script.py --verbose" the script is at index 3.
+//
+// If python would not run a script at all (e.g. -m module, -c command or - for stdin)
+// false is returned.
+func findScript(args []string) (int, bool) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			// End of python options, whatever is next is the script
+			if i+1 < len(args) {
+				return i + 1, true
+			}
+			return 0, false
+		case arg == "-", arg == "--help", arg == "--version":
+			return 0, false
+		case strings.HasPrefix(arg, "--"):
+			// Long options, only one takes an argument
+			if arg == "--check-hash-based-pycs" {
+				i++
+			}
+		case strings.HasPrefix(arg, "-"):
+			// Short options can be grouped e.g. -uB, and those taking an argument
+			// can have it attached e.g. -Xdev or separate e.g. -X dev
+			terminated, takesNext := parseShortOptions(arg[1:])
+			if terminated {
+				return 0, false
+			}
+			if takesNext {
+				i++
+			}
+		default:
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// parseShortOptions inspects a group of python short options (without the leading "-")
+// and reports whether they end the option list without a script (-c or -m)
+// and whether the next argument belongs to the last option (e.g. -X dev).
+func parseShortOptions(opts string) (terminated, takesNext bool) {
+	for i, opt := range opts {
+		switch opt {
+		case 'c', 'm':
+			return true, false
+		case 'X', 'W':
+			// The rest of the group is the argument, if there isn't one it's the next arg
+			return false, i == len(opts)-1
+		}
+	}
+
+	return false, false
+}
+
+// readScriptMetadata reads the PEP 723 inline metadata from the script at 'path' on 'fsys'
+// returning false if it doesn't have any.
+func readScriptMetadata(fsys interpreter.FS, path string) (scriptMetadata, bool, error) {
+	contents, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return scriptMetadata{}, false, fmt.Errorf("could not read %s: %w", path, err)
+	}
+
+	return parseScriptMetadata(string(contents))
+}
+
+// parseScriptMetadata finds and parses the "# /// script" block in 'contents'
+// returning false if there isn't one.
+//
+// Example
+//
+//	# /// script
+//	# requires-python = ">=3.11"
+//	# dependencies = ["requests<3"]
+//	# ///
+func parseScriptMetadata(contents string) (scriptMetadata, bool, error) {
+	var block string
+	found := false
+	for _, match := range scriptBlockRegex.FindAllStringSubmatch(contents, -1) {
+		if match[1] != scriptBlockType {
+			continue
+		}
+		if found {
+			return scriptMetadata{}, false, errors.New("multiple script metadata blocks found")
+		}
+		block = match[2]
+		found = true
+	}
+
+	if !found {
+		return scriptMetadata{}, false, nil
+	}
+
+	// Strip the leading "#" or "# " from every line to get the TOML
+	lines := strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimPrefix(line, "#"), " ")
+	}
+
+	table, err := toml.Parse(strings.Join(lines, "\n"))
+	if err != nil {
+		return scriptMetadata{}, false, fmt.Errorf("invalid script metadata: %w", err)
+	}
+
+	var metadata scriptMetadata
+	if value, ok := table[requiresPythonKey]; ok {
+		requires, ok := value.(string)
+		if !ok {
+			return scriptMetadata{}, false, fmt.Errorf("invalid script metadata: %s must be a string", requiresPythonKey)
+		}
+		metadata.RequiresPython = requires
+	}
+
+	if value, ok := table[dependenciesKey]; ok {
+		values, ok := value.([]any)
+		if !ok {
+			return scriptMetadata{}, false, fmt.Errorf("invalid script metadata: %s must be an array", dependenciesKey)
+		}
+		for _, value := range values {
+			dependency, ok := value.(string)
+			if !ok {
+				return scriptMetadata{}, false, fmt.Errorf("invalid script metadata: %s must only contain strings", dependenciesKey)
+			}
+			metadata.Dependencies = append(metadata.Dependencies, dependency)
+		}
+	}
+
+	return metadata, true, nil
+}
+
+// scriptMetadataPython is called once we know 'script' is a file, it looks for PEP 723
+// inline metadata and if it has a requires-python constraint, returns the latest
+// interpreter satisfying it. If the caller builds script venvs and the script lists dependencies,
+// they're returned too for the caller to install.
+//
+// If there is no metadata, or it doesn't tell us anything about which python to use, false is
+// returned to signal the continuation of the control flow.
+func (r *resolver) scriptMetadataPython(ctx context.Context, script string) (Result, bool, error) {
+	r.logger.Debug("Looking for inline script metadata", LogKeyStep, ReasonScriptMetadata, LogKeyScript, script)
+	metadata, ok, err := readScriptMetadata(r.opts.FS, script)
+	if err != nil {
+		return Result{}, false, err
+	}
+
+	if !ok || (metadata.RequiresPython == "" && len(metadata.Dependencies) == 0) {
+		r.logger.Debug("No inline script metadata, continuing control flow", LogKeyStep, ReasonScriptMetadata)
+		r.explain(ReasonScriptMetadata, "No inline script metadata in %s", script)
+		return Result{}, false, nil
+	}
+
+	r.logger.Debug("Found inline script metadata", LogKeyStep, ReasonScriptMetadata, LogKeyRequiresPython, metadata.RequiresPython, LogKeyDependencies, metadata.Dependencies)
+
+	if len(metadata.Dependencies) != 0 && !r.opts.ScriptVenv {
+		r.logger.Debug("Script has dependencies but script venvs are not enabled, ignoring them", LogKeyStep, ReasonScriptMetadata, LogKeyEnv, EnvScriptVenv)
+		r.explain(ReasonScriptMetadata, "Inline metadata of %s lists dependencies but $%s is not set, ignoring them", script, EnvScriptVenv)
+		if metadata.RequiresPython == "" {
+			return Result{}, false, nil
+		}
+	}
+
+	python, err := r.requiresPython(ctx, metadata.RequiresPython)
+	if err != nil {
+		return Result{}, false, err
+	}
+	r.explain(ReasonScriptMetadata, "Inline metadata of %s requires python %q, latest match is %s", script, metadata.RequiresPython, python.Path)
+
+	result := Result{Interpreter: python, Reason: ReasonScriptMetadata}
+	if r.opts.ScriptVenv {
+		result.Dependencies = metadata.Dependencies
+	}
+
+	return result, true, nil
+}
+
+// requiresPython finds the latest interpreter on the path satisfying the PEP 440
+// 'requires' constraint (e.g. from script metadata or pyproject.toml), an empty
+// constraint is satisfied by anything.
+func (r *resolver) requiresPython(ctx context.Context, requires string) (interpreter.Interpreter, error) {
+	interpreters, err := r.interpreters()
+	if err != nil {
+		return interpreter.Interpreter{}, err
+	}
+
+	// CPython is only preferred among those that satisfy the constraint, so an older
+	// CPython doesn't hide a newer PyPy that would
+	candidates := r.withoutPreReleases(latestSpec, satisfying(latestSpec, interpreters))
+
+	if requires != "" {
+		constraint, err := interpreter.ParseConstraint(requires)
+		if err != nil {
+			return interpreter.Interpreter{}, fmt.Errorf("bad requires-python: %w", err)
+		}
+
+		if constraint.NeedsPatch() {
+			for i, python := range candidates {
+				if python.Patch == interpreter.Unknown {
+					r.probe(ctx, &candidates[i])
+				}
+			}
+		}
+
+		candidates = slices.DeleteFunc(candidates, func(python interpreter.Interpreter) bool {
+			return !constraint.Allows(python)
+		})
+
+		if len(candidates) == 0 {
+			return interpreter.Interpreter{}, &NoMatchError{Requires: requires, Candidates: interpreters}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return interpreter.Interpreter{}, ErrNoInterpreter
+	}
+
+	candidates = latestSpec.Filter(candidates)
+	interpreter.Sort(candidates)
+	return candidates[0], nil
+}
@@ -0,0 +1,331 @@
+package resolve //nolint: testpackage // Need access to internals
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/FollowTheProcess/py/internal/fixture"
+)
+
+func Test_findScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		want   int
+		wantOk bool
+	}{
+		{
+			name:   "no args",
+			args:   []string{},
+			want:   0,
+			wantOk: false,
+		},
+		{
+			name:   "just a script",
+			args:   []string{"script.py"},
+			want:   0,
+			wantOk: true,
+		},
+		{
+			name:   "script with arguments",
+			args:   []string{"script.py", "--verbose", "-c", "thing"},
+			want:   0,
+			wantOk: true,
+		},
+		{
+			name:   "python flags before script",
+			args:   []string{"-u", "-B", "script.py", "--verbose"},
+			want:   2,
+			wantOk: true,
+		},
+		{
+			name:   "grouped flags",
+			args:   []string{"-uB", "script.py"},
+			want:   1,
+			wantOk: true,
+		},
+		{
+			name:   "option with separate argument",
+			args:   []string{"-X", "dev", "script.py"},
+			want:   2,
+			wantOk: true,
+		},
+		{
+			name:   "option with attached argument",
+			args:   []string{"-Xdev", "-Wignore", "script.py"},
+			want:   2,
+			wantOk: true,
+		},
+		{
+			name:   "grouped with trailing option taking argument",
+			args:   []string{"-uW", "ignore", "script.py"},
+			want:   2,
+			wantOk: true,
+		},
+		{
+			name:   "long option with argument",
+			args:   []string{"--check-hash-based-pycs", "always", "script.py"},
+			want:   2,
+			wantOk: true,
+		},
+		{
+			name:   "double dash",
+			args:   []string{"-u", "--", "-weird-name.py"},
+			want:   2,
+			wantOk: true,
+		},
+		{
+			name:   "double dash with nothing after",
+			args:   []string{"--"},
+			want:   0,
+			wantOk: false,
+		},
+		{
+			name:   "module",
+			args:   []string{"-m", "venv", ".venv"},
+			want:   0,
+			wantOk: false,
+		},
+		{
+			name:   "module grouped",
+			args:   []string{"-um", "pip"},
+			want:   0,
+			wantOk: false,
+		},
+		{
+			name:   "command",
+			args:   []string{"-u", "-c", "print('hello')"},
+			want:   0,
+			wantOk: false,
+		},
+		{
+			name:   "stdin",
+			args:   []string{"-", "script.py"},
+			want:   0,
+			wantOk: false,
+		},
+		{
+			name:   "only flags",
+			args:   []string{"-u", "-X", "dev"},
+			want:   0,
+			wantOk: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := findScript(tt.args)
+			if ok != tt.wantOk {
+				t.Fatalf("got ok %v, wanted %v", ok, tt.wantOk)
+			}
+
+			if got != tt.want {
+				t.Errorf("got %d, wanted %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseScriptMetadata(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     scriptMetadata
+		wantOk   bool
+		wantErr  bool
+	}{
+		{
+			name:     "no metadata",
+			contents: "#!/usr/bin/env python3\nprint('hello')\n",
+			want:     scriptMetadata{},
+			wantOk:   false,
+			wantErr:  false,
+		},
+		{
+			name: "requires-python and dependencies",
+			contents: `#!/usr/bin/env python3
+# /// script
+# requires-python = ">=3.11"
+# dependencies = [
+#   "requests<3",
+#   "rich",
+# ]
+# ///
+
+import requests
+`,
+			want:    scriptMetadata{RequiresPython: ">=3.11", Dependencies: []string{"requests<3", "rich"}},
+			wantOk:  true,
+			wantErr: false,
+		},
+		{
+			name: "only requires-python",
+			contents: `# /// script
+# requires-python = ">=3.9,<3.12"
+# ///
+`,
+			want:    scriptMetadata{RequiresPython: ">=3.9,<3.12"},
+			wantOk:  true,
+			wantErr: false,
+		},
+		{
+			name: "blank comment lines and tool tables",
+			contents: `# /// script
+# requires-python = ">=3.12"
+#
+# [tool.uv]
+# exclude-newer = "2024-01-01T00:00:00Z"
+# ///
+`,
+			want:    scriptMetadata{RequiresPython: ">=3.12"},
+			wantOk:  true,
+			wantErr: false,
+		},
+		{
+			name: "other block types are ignored",
+			contents: `# /// pyproject
+# requires-python = ">=3.12"
+# ///
+`,
+			want:    scriptMetadata{},
+			wantOk:  false,
+			wantErr: false,
+		},
+		{
+			name: "unclosed block",
+			contents: `# /// script
+# requires-python = ">=3.12"
+
+print("hello")
+`,
+			want:    scriptMetadata{},
+			wantOk:  false,
+			wantErr: false,
+		},
+		{
+			name: "multiple blocks",
+			contents: `# /// script
+# requires-python = ">=3.12"
+# ///
+
+# /// script
+# requires-python = ">=3.11"
+# ///
+`,
+			want:    scriptMetadata{},
+			wantOk:  false,
+			wantErr: true,
+		},
+		{
+			name: "requires-python not a string",
+			contents: `# /// script
+# requires-python = [">=3.12"]
+# ///
+`,
+			want:    scriptMetadata{},
+			wantOk:  false,
+			wantErr: true,
+		},
+		{
+			name: "dependencies not an array",
+			contents: `# /// script
+# dependencies = "rich"
+# ///
+`,
+			want:    scriptMetadata{},
+			wantOk:  false,
+			wantErr: true,
+		},
+		{
+			name: "bad TOML",
+			contents: `# /// script
+# requires-python >=3.12
+# ///
+`,
+			want:    scriptMetadata{},
+			wantOk:  false,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := parseScriptMetadata(tt.contents)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseScriptMetadata() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if ok != tt.wantOk {
+				t.Errorf("got ok %v, wanted %v", ok, tt.wantOk)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, wanted %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_requiresPython(t *testing.T) {
+	path := fixture.PythonPath("..")
+
+	tests := []struct {
+		name     string
+		requires string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "no constraint is the latest",
+			requires: "",
+			want:     "python3.10",
+			wantErr:  false,
+		},
+		{
+			name:     "upper bound",
+			requires: "<3.10",
+			want:     "python3.9",
+			wantErr:  false,
+		},
+		{
+			name:     "range",
+			requires: ">=3.6,<3.9",
+			want:     "python3.8",
+			wantErr:  false,
+		},
+		{
+			name:     "exclusion",
+			requires: "<3.10,!=3.9.*",
+			want:     "python3.8",
+			wantErr:  false,
+		},
+		{
+			name:     "nothing satisfies",
+			requires: ">=3.14",
+			want:     "",
+			wantErr:  true,
+		},
+		{
+			name:     "bad constraint",
+			requires: "3.10",
+			want:     "",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestResolver(t, Options{Path: path})
+			got, err := r.requiresPython(context.Background(), tt.requires)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("requiresPython() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if err == nil && filepath.Base(got.Path) != tt.want {
+				t.Errorf("got %s, wanted %s", filepath.Base(got.Path), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package resolve
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// shebangPython is called once we know 'script' is a file, it attempts to open the file,
+// look for a shebang line and parse it, returning the python interpreter it asks for.
+// Any interpreter flags in the shebang are left to shebangFlags.
+//
+// If it does not find a valid shebang line or there is no version found in it
+// the returned interpreter will have no path to signal the continuation of the control flow.
+// What happens if the version it asks for isn't installed depends on Options.ShebangPolicy:
+//   - strict: it's an error
+//   - warn: a warning is recorded and the control flow continues
+//   - nearest: a warning is recorded and the nearest installed version is used
+func (r *resolver) shebangPython(ctx context.Context, script string) (interpreter.Interpreter, error) {
+	policy := r.opts.ShebangPolicy
+	switch policy {
+	case ShebangStrict, ShebangWarn, ShebangNearest:
+	default:
+		reason := fmt.Sprintf("must be one of %s, %s or %s", ShebangStrict, ShebangWarn, ShebangNearest)
+		return interpreter.Interpreter{}, &MalformedEnvError{Key: EnvShebangPolicy, Value: policy, Reason: reason}
+	}
+
+	r.logger.Debug("Argument is a file", LogKeyStep, ReasonShebang, LogKeyScript, script)
+	shebang, err := r.readShebang(script)
+	if err != nil {
+		return interpreter.Interpreter{}, err
+	}
+
+	version, _ := r.parseShebang(shebang)
+
+	// Shebang is a version specifier e.g. /usr/bin/python3, /usr/bin/python3.9 or /usr/bin/python3.13t
+	spec, err := interpreter.ParseSpec(version)
+	if err != nil {
+		// The shebang either wasn't valid or had no version identifier e.g. /usr/bin/python
+		// in which case, continue the control flow
+		r.logger.Debug("Unrecognised or missing version in shebang line, continuing control flow", LogKeyStep, ReasonShebang, LogKeyVersion, version)
+		r.explain(ReasonShebang, "No python version in the shebang of %s", script)
+		return interpreter.Interpreter{}, nil
+	}
+
+	r.logger.Debug("Shebang line refers to version specifier", LogKeyStep, ReasonShebang, LogKeySpecifier, spec.Executable())
+
+	interpreters, err := r.interpreters()
+	if err != nil {
+		return interpreter.Interpreter{}, err
+	}
+
+	if matching := r.matching(ctx, spec, interpreters); len(matching) != 0 {
+		r.explain(ReasonShebang, "Shebang of %s asks for %s, latest match is %s", script, spec.Executable(), matching[0].Path)
+		return matching[0], nil
+	}
+
+	r.explain(ReasonShebang, "Shebang of %s asks for %s which is not installed, $%s is %q", script, spec.Executable(), EnvShebangPolicy, policy)
+
+	switch policy {
+	case ShebangWarn:
+		r.warn(ReasonShebang, "%s asks for %s which is not installed, ignoring it", script, spec.Executable())
+		return interpreter.Interpreter{}, nil
+	case ShebangNearest:
+		if nearest, ok := interpreter.Nearest(spec, r.withoutPreReleases(spec, interpreters)); ok {
+			r.warn(ReasonShebang, "%s asks for %s which is not installed, using %s instead", script, spec.Executable(), nearest.Path)
+			r.explain(ReasonShebang, "Nearest installed version is %s", nearest.Path)
+			return nearest, nil
+		}
+	}
+
+	return interpreter.Interpreter{}, r.noMatch(spec, interpreters)
+}
+
+// shebangFlags returns the interpreter flags from the shebang of the script python would
+// run with Options.Args, if there is one, e.g. [-u] from "#!/usr/bin/env -S python3 -u".
+// They're passed to whichever python is chosen, however it was chosen.
+func (r *resolver) shebangFlags() []string {
+	script, ok := r.script()
+	if !ok {
+		return nil
+	}
+
+	shebang, err := r.readShebang(script)
+	if err != nil {
+		r.logger.Debug("Could not read shebang for interpreter flags", LogKeyScript, script, LogKeyError, err)
+		return nil
+	}
+
+	_, flags := r.parseShebang(shebang)
+	if len(flags) != 0 {
+		r.explain(ReasonShebang, "Passing %s from the shebang of %s to python", strings.Join(flags, " "), script)
+	}
+	return flags
+}
+
+// readShebang returns the first line of 'script', which may or may not be a shebang.
+func (r *resolver) readShebang(script string) (string, error) {
+	file, err := r.opts.FS.Open(script)
+	if err != nil {
+		return "", fmt.Errorf("could not open %s: %w", script, err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan()
+	return scanner.Text(), nil
+}
+
+// parseShebang takes a line of text (as read from a file) and returns
+// the string version of a python version it may represent along with any
+// interpreter flags that follow the python executable
+//
+// The python executable may live anywhere (e.g. /opt/bin/python3.11) or be
+// looked up through env, including env -S to allow flags on systems whose
+// kernel passes the whole shebang as a single argument
+//
+// If 'shebang' is not a valid python shebang line, or if no python version is specified
+// an empty version will be returned. This is the signal to use the remaining control flow to
+// determine the appropriate python version to launch
+//
+// Example
+//
+//	version, flags := r.parseShebang("#!/usr/bin/env -S python3.9 -u")
+//	fmt.Println(version, flags)
+//
+// Output: "3.9" [-u].
+func (r *resolver) parseShebang(shebang string) (string, []string) {
+	r.logger.Debug("Checking for a python shebang line", LogKeyStep, ReasonShebang)
+	if !strings.HasPrefix(shebang, "#!") {
+		return "", nil
+	}
+
+	// Whitespace is allowed between #! and the path e.g. #! /usr/bin/python
+	tokens := strings.Fields(strings.TrimPrefix(shebang, "#!"))
+	if len(tokens) == 0 {
+		return "", nil
+	}
+
+	if filepath.Base(tokens[0]) == "env" {
+		tokens = skipEnvOptions(tokens[1:])
+		if len(tokens) == 0 {
+			return "", nil
+		}
+	}
+
+	name := filepath.Base(tokens[0])
+	version, ok := pythonVersionFromName(name)
+	if !ok {
+		return "", nil
+	}
+
+	r.logger.Debug("Found python shebang line", LogKeyStep, ReasonShebang, LogKeyShebang, shebang)
+
+	var flags []string
+	if len(tokens) > 1 {
+		flags = tokens[1:]
+		r.logger.Debug("Found interpreter flags in shebang line", LogKeyStep, ReasonShebang, LogKeyFlags, flags)
+	}
+
+	r.logger.Debug("Found potential python version in shebang line", LogKeyStep, ReasonShebang, LogKeyVersion, version)
+	return version, flags
+}
+
+// skipEnvOptions takes the tokens following env in a shebang line and skips over
+// any options and environment variable assignments, returning the tokens
+// starting from the command env would run.
+func skipEnvOptions(tokens []string) []string {
+	for len(tokens) > 0 {
+		token := tokens[0]
+		switch {
+		case token == "-S", token == "--split-string":
+			// The rest of the line is the command, which we've already split
+			tokens = tokens[1:]
+		case strings.HasPrefix(token, "--split-string="):
+			tokens[0] = strings.TrimPrefix(token, "--split-string=")
+		case strings.HasPrefix(token, "-S"):
+			// Joined form e.g. -Spython3
+			tokens[0] = strings.TrimPrefix(token, "-S")
+		case token == "-u", token == "--unset", token == "-C", token == "--chdir":
+			// These take an argument, skip both
+			if len(tokens) < 2 { //nolint: mnd // Option and it's argument
+				return nil
+			}
+			tokens = tokens[2:]
+		case strings.HasPrefix(token, "-"), strings.Contains(token, "="):
+			// Any other flag (e.g. -i) or a NAME=VALUE assignment
+			tokens = tokens[1:]
+		default:
+			return tokens
+		}
+	}
+
+	return nil
+}
+
+// pythonVersionFromName takes the base name of an executable from a shebang line
+// and returns the version specifier it refers to e.g. python3.9 -> "3.9", pypy3.10 -> "pypy3.10"
+// and python -> "", returning false if it's not a python executable at all.
+func pythonVersionFromName(name string) (string, bool) {
+	if strings.HasPrefix(name, "python") {
+		return strings.TrimPrefix(name, "python"), true
+	}
+
+	// Alternative implementations keep their name in the specifier
+	spec, err := interpreter.ParseSpec(name)
+	if err != nil || spec.Implementation == "" {
+		return "", false
+	}
+
+	return name, true
+}
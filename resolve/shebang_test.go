@@ -0,0 +1,282 @@
+package resolve //nolint: testpackage // Need access to internals
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/FollowTheProcess/py/internal/fixture"
+)
+
+func Test_parseShebang(t *testing.T) {
+	tests := []struct {
+		name      string
+		shebang   string
+		want      string
+		wantFlags []string
+	}{
+		{
+			name:    "python3 returns 3",
+			shebang: "#!/usr/bin/python3",
+			want:    "3",
+		},
+		{
+			name:    "python3.9 returns 3.9",
+			shebang: "#!/usr/bin/python3.9",
+			want:    "3.9",
+		},
+		{
+			name:    "python3.10 returns 3.10",
+			shebang: "#!/usr/bin/python3.10",
+			want:    "3.10",
+		},
+		{
+			name:    "python4.12 returns 4.12",
+			shebang: "#!/usr/bin/python4.12",
+			want:    "4.12",
+		},
+		{
+			name:    "no version returns nothing",
+			shebang: "#!/usr/bin/python",
+			want:    "",
+		},
+		{
+			name:    "no version returns nothing (local)",
+			shebang: "#!/usr/local/bin/python",
+			want:    "",
+		},
+		{
+			name:    "no version returns nothing (env)",
+			shebang: "#!/usr/bin/env python",
+			want:    "",
+		},
+		{
+			name:    "local 3.9 returns 3.9",
+			shebang: "#!/usr/local/bin/python3.9",
+			want:    "3.9",
+		},
+		{
+			name:    "env 3.9 returns 3.9",
+			shebang: "#!/usr/bin/env python3.9",
+			want:    "3.9",
+		},
+		{
+			name:    "local 4.12 returns 4.12",
+			shebang: "#!/usr/local/bin/python4.12",
+			want:    "4.12",
+		},
+		{
+			name:    "env 4.12 returns 4.12",
+			shebang: "#!/usr/bin/env python4.12",
+			want:    "4.12",
+		},
+		{
+			name:    "whitespace isn't counted",
+			shebang: "#! /usr/bin/env python3",
+			want:    "3",
+		},
+		{
+			name:    "any path is recognised",
+			shebang: "#!/opt/bin/python3.11",
+			want:    "3.11",
+		},
+		{
+			name:    "bare python",
+			shebang: "#!python3.12",
+			want:    "3.12",
+		},
+		{
+			name:      "flags are kept",
+			shebang:   "#!/opt/bin/python3.11 -O",
+			want:      "3.11",
+			wantFlags: []string{"-O"},
+		},
+		{
+			name:      "flags with arguments",
+			shebang:   "#!/usr/bin/python3 -X dev",
+			want:      "3",
+			wantFlags: []string{"-X", "dev"},
+		},
+		{
+			name:      "env -S",
+			shebang:   "#!/usr/bin/env -S python3 -u",
+			want:      "3",
+			wantFlags: []string{"-u"},
+		},
+		{
+			name:      "env -S joined",
+			shebang:   "#!/usr/bin/env -Spython3.12 -u -B",
+			want:      "3.12",
+			wantFlags: []string{"-u", "-B"},
+		},
+		{
+			name:    "env --split-string",
+			shebang: "#!/usr/bin/env --split-string=python3.10",
+			want:    "3.10",
+		},
+		{
+			name:      "env with options and assignments",
+			shebang:   "#!/usr/bin/env -i -u HOME PYTHONUTF8=1 python3.9 -E",
+			want:      "3.9",
+			wantFlags: []string{"-E"},
+		},
+		{
+			name:      "no version keeps flags",
+			shebang:   "#!/usr/bin/env -S python -u",
+			want:      "",
+			wantFlags: []string{"-u"},
+		},
+		{
+			name:    "alternative implementation",
+			shebang: "#!/usr/bin/env pypy3.10",
+			want:    "pypy3.10",
+		},
+		{
+			name:    "free-threaded",
+			shebang: "#!/usr/bin/python3.13t",
+			want:    "3.13t",
+		},
+		{
+			name:    "not python",
+			shebang: "#!/bin/bash -e",
+			want:    "",
+		},
+		{
+			name:    "env with nothing to run",
+			shebang: "#!/usr/bin/env -S",
+			want:    "",
+		},
+		{
+			name:    "empty shebang",
+			shebang: "#!",
+			want:    "",
+		},
+		{
+			name:    "no #! means no shebang",
+			shebang: "/usr/bin/python",
+			want:    "",
+		},
+		{
+			name:    "non valid path returns nothing",
+			shebang: "#!/somewhere/not/recognised/python",
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestResolver(t, Options{})
+			got, flags := r.parseShebang(tt.shebang)
+			if got != tt.want {
+				t.Errorf("got %s, wanted %s", got, tt.want)
+			}
+
+			if !reflect.DeepEqual(flags, tt.wantFlags) {
+				t.Errorf("got flags %#v, wanted %#v", flags, tt.wantFlags)
+			}
+		})
+	}
+}
+
+func Test_shebangPython(t *testing.T) {
+	tests := []struct {
+		name     string
+		shebang  string
+		policy   string
+		want     string
+		wantWarn bool
+		wantErr  bool
+	}{
+		{
+			name:    "installed version",
+			shebang: "#!/usr/bin/python3.9",
+			policy:  "",
+			want:    "python3.9",
+		},
+		{
+			name:    "installed version with flags",
+			shebang: "#!/usr/bin/env -S python3.9 -u",
+			policy:  "",
+			want:    "python3.9",
+		},
+		{
+			name:    "no version",
+			shebang: "#!/usr/bin/env python -u",
+			policy:  "",
+			want:    "",
+		},
+		{
+			name:    "missing version strict by default",
+			shebang: "#!/usr/bin/python3.6",
+			policy:  "",
+			wantErr: true,
+		},
+		{
+			name:    "missing version strict",
+			shebang: "#!/usr/bin/python3.6",
+			policy:  "strict",
+			wantErr: true,
+		},
+		{
+			name:     "missing version warn",
+			shebang:  "#!/usr/bin/python3.6",
+			policy:   "warn",
+			want:     "",
+			wantWarn: true,
+		},
+		{
+			name:     "missing version nearest",
+			shebang:  "#!/usr/bin/python3.6",
+			policy:   "nearest",
+			want:     "python3.7",
+			wantWarn: true,
+		},
+		{
+			name:     "missing version nearest with flags",
+			shebang:  "#!/usr/bin/python3.12 -O",
+			policy:   "nearest",
+			want:     "python3.10",
+			wantWarn: true,
+		},
+		{
+			name:    "missing major nearest",
+			shebang: "#!/usr/bin/python4.1",
+			policy:  "nearest",
+			wantErr: true,
+		},
+		{
+			name:    "bad policy",
+			shebang: "#!/usr/bin/python3.9",
+			policy:  "sometimes",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script := filepath.Join(t.TempDir(), "script.py")
+			if err := os.WriteFile(script, []byte(tt.shebang+"\nprint('hello')\n"), 0o644); err != nil {
+				t.Fatalf("could not write script: %v", err)
+			}
+
+			r := newTestResolver(t, Options{Path: fixture.PythonPath(".."), ShebangPolicy: tt.policy})
+
+			got, err := r.shebangPython(context.Background(), script)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("shebangPython() error = %v, wantErr = %v", err, tt.wantErr)
+			}
+
+			if filepath.Base(got.Path) != filepath.Base(tt.want) {
+				t.Errorf("got %q, wanted %q", got.Path, tt.want)
+			}
+
+			warned := slices.ContainsFunc(r.trace, func(step Step) bool { return step.Warning })
+			if warned != tt.wantWarn {
+				t.Errorf("warned = %v, wanted %v (trace: %v)", warned, tt.wantWarn, r.trace)
+			}
+		})
+	}
+}
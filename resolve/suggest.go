@@ -0,0 +1,87 @@
+package resolve
+
+import (
+	"path/filepath"
+
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+// The tools py knows how to suggest for installing a missing python, in order of preference.
+const (
+	installerUV    = "uv"
+	installerPyenv = "pyenv"
+)
+
+// installers lists the tools that can install a python, in the order they're suggested.
+var installers = [...]string{installerUV, installerPyenv}
+
+// noMatch returns a *NoMatchError for 'spec' not matching any of 'candidates', with
+// the install tools available on r.opts.Path filled in so the error can suggest how to
+// install the missing python.
+func (r *resolver) noMatch(spec interpreter.Spec, candidates []interpreter.Interpreter) *NoMatchError {
+	return &NoMatchError{
+		Spec:       spec,
+		Candidates: candidates,
+		Installers: r.findInstallers(),
+	}
+}
+
+// findInstallers returns the tools in installers that are on r.opts.Path.
+func (r *resolver) findInstallers() []string {
+	var found []string
+	for _, tool := range installers {
+		if r.onPath(tool) {
+			found = append(found, tool)
+		}
+	}
+
+	if len(found) != 0 {
+		r.logger.Debug("Found tools to install python with", LogKeyInstallers, found)
+	}
+
+	return found
+}
+
+// onPath reports whether there is an executable file called 'name' in any of
+// the directories in r.opts.Path.
+func (r *resolver) onPath(name string) bool {
+	for _, dir := range filepath.SplitList(r.opts.Path) {
+		if dir == "" {
+			continue
+		}
+		info, err := r.opts.FS.Stat(filepath.Join(dir, name))
+		if err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0o111 != 0 { //nolint: mnd // Any execute bit
+			return true
+		}
+	}
+	return false
+}
+
+// installCommand returns the command 'tool' uses to install the python asked
+// for by 'spec', or false if it can't install it.
+func installCommand(tool string, spec interpreter.Spec) (string, bool) {
+	if spec.Major == interpreter.Any {
+		return "", false
+	}
+
+	// Drop the architecture, neither tool lets you pick it
+	spec.Bits = 0
+	version := spec.String()
+
+	switch tool {
+	case installerUV:
+		if spec.Implementation != "" && spec.Implementation != interpreter.CPython {
+			return "uv python install " + spec.Implementation + "@" + version, true
+		}
+		return "uv python install " + version, true
+	case installerPyenv:
+		// pyenv names other implementations by their release not the python
+		// version they support, so we can't say what to install
+		if spec.Implementation != "" && spec.Implementation != interpreter.CPython {
+			return "", false
+		}
+		return "pyenv install " + version, true
+	default:
+		return "", false
+	}
+}
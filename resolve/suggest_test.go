@@ -0,0 +1,38 @@
+package resolve //nolint: testpackage // Need access to internals
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/FollowTheProcess/py/internal/fixture"
+	"github.com/FollowTheProcess/py/interpreter"
+)
+
+func TestResolve_installers(t *testing.T) {
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "uv"), nil, 0o755); err != nil {
+		t.Fatalf("could not write fake uv: %v", err)
+	}
+	// Not executable so not an installer
+	if err := os.WriteFile(filepath.Join(bin, "pyenv"), nil, 0o644); err != nil {
+		t.Fatalf("could not write fake pyenv: %v", err)
+	}
+
+	spec := interpreter.Spec{Major: 3, Minor: 4, Patch: interpreter.Any}
+	_, err := Resolve(context.Background(), Options{Path: fixture.PythonPath("..") + string(os.PathListSeparator) + bin, Spec: &spec})
+	var noMatch *NoMatchError
+	if !errors.As(err, &noMatch) {
+		t.Fatalf("expected a *NoMatchError, got %v", err)
+	}
+	if !reflect.DeepEqual(noMatch.Installers, []string{installerUV}) {
+		t.Errorf("got installers %q, wanted [uv]", noMatch.Installers)
+	}
+	if !strings.Contains(err.Error(), "the nearest is python3.5") {
+		t.Errorf("expected the nearest python3.5 to be suggested, got %q", err.Error())
+	}
+}